@@ -33,6 +33,10 @@ type Client struct {
 	do      DoFunc
 	stats   *Stats
 
+	// like do but without the total timeout of the HTTP client, for streams
+	// and upgraded connections which are bounded by their context instead
+	streamDo DoFunc
+
 	clientID      string
 	clientUUID    string
 	clientVersion string
@@ -84,18 +88,27 @@ func New(baseURL string, opts ...Option) *Client {
 	_ = options.HTTPClient
 
 	// get the inner do func (e.g. the one that connects to the API)
-	do := func(req *http.Request) (*http.Response, error) {
-		return options.HTTPClient.Do(req)
-	}
+	chain := func(httpClient *http.Client) DoFunc {
+		do := func(req *http.Request) (*http.Response, error) {
+			return httpClient.Do(req)
+		}
 
-	// apply middlewares in reverse order (last middleware wraps first)
-	for i := len(options.Middlewares) - 1; i >= 0; i-- {
-		do = options.Middlewares[i](do)
+		// apply middlewares in reverse order (last middleware wraps first)
+		for i := len(options.Middlewares) - 1; i >= 0; i-- {
+			do = options.Middlewares[i](do)
+		}
+		return do
 	}
 
+	// a timeout of the HTTP client covers reading the whole response body
+	// and prevents connection upgrades
+	streamClient := *options.HTTPClient
+	streamClient.Timeout = 0
+
 	client := &Client{
-		baseURL: baseURL,
-		do:      do,
+		baseURL:  baseURL,
+		do:       chain(options.HTTPClient),
+		streamDo: chain(&streamClient),
 		// Defaults; callers may override via SetClientInfo.
 		clientID:      httputil.ClientID,
 		clientVersion: "",
@@ -107,6 +120,7 @@ func New(baseURL string, opts ...Option) *Client {
 		client.stats = NewStats()
 		// Add stats middleware
 		client.do = StatsMiddleware(client.stats)(client.do)
+		client.streamDo = StatsMiddleware(client.stats)(client.streamDo)
 	}
 
 	return client
@@ -123,6 +137,10 @@ func (c *Client) SetClientInfo(id, uuid, version string) {
 
 // Request makes a raw HTTP request to the API
 func (c *Client) Request(ctx context.Context, method, endpoint string, query map[string]string, body any) (*http.Response, error) {
+	return c.request(ctx, c.do, method, endpoint, query, body)
+}
+
+func (c *Client) request(ctx context.Context, do DoFunc, method, endpoint string, query map[string]string, body any) (*http.Response, error) {
 	req, err := httputil.BuildRequest(ctx, c.baseURL, method, endpoint, query, body)
 	if err != nil {
 		return nil, err
@@ -134,7 +152,7 @@ func (c *Client) Request(ctx context.Context, method, endpoint string, query map
 	// HTTP client will automatically set Content-Length for known body sizes
 
 	// execute request
-	res, err := c.do(req)
+	res, err := do(req)
 	if err != nil {
 		return nil, c.wrapConnectionError(err)
 	}
@@ -201,6 +219,41 @@ func (c *Client) DeleteJSON(ctx context.Context, endpoint string, out any) error
 	return c.doJSON(ctx, http.MethodDelete, endpoint, nil, nil, out)
 }
 
+// GetBytes makes a GET request and returns the raw response body, e.g. for
+// documents which are not JSON. Unlike GetStream, the timeout of the HTTP
+// client covers the request and the reading of the body.
+func (c *Client) GetBytes(ctx context.Context, endpoint string, query map[string]string) ([]byte, error) {
+	res, err := c.Request(ctx, http.MethodGet, path.Join(APIBasePath, endpoint), query, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close() //nolint:errcheck
+	if res.StatusCode >= 300 {
+		return nil, c.handleHTTPError(res)
+	}
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, c.wrapConnectionError(err)
+	}
+	return data, nil
+}
+
+// GetStream makes a GET request and returns the response body, e.g. for
+// binary downloads. The total timeout of the HTTP client does not apply, as
+// it would cut off large downloads; the context bounds the request and the
+// reading of the body instead. The caller must close the returned reader.
+func (c *Client) GetStream(ctx context.Context, endpoint string, query map[string]string) (io.ReadCloser, error) {
+	res, err := c.request(ctx, c.streamDo, http.MethodGet, path.Join(APIBasePath, endpoint), query, nil)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 300 {
+		defer res.Body.Close() //nolint:errcheck
+		return nil, c.handleHTTPError(res)
+	}
+	return res.Body, nil
+}
+
 // wrapConnectionError converts syscall errors to domain errors
 func (c *Client) wrapConnectionError(err error) error {
 	urlError := &url.Error{}
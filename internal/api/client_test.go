@@ -305,6 +305,110 @@ func TestDeleteJSON(t *testing.T) {
 	}
 }
 
+func TestGetStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v0/pcap/key" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte{0xd4, 0xc3, 0xb2, 0xa1}) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	client := New(server.URL, WithHTTPClient(&http.Client{Timeout: 10 * time.Second}))
+
+	body, err := client.GetStream(context.Background(), "pcap/key", nil)
+	if err != nil {
+		t.Fatalf("GetStream failed: %v", err)
+	}
+	defer body.Close() //nolint:errcheck
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if len(data) != 4 || data[0] != 0xd4 {
+		t.Errorf("unexpected data %x", data)
+	}
+
+	if _, err := client.GetStream(context.Background(), "pcap/other", nil); err == nil {
+		t.Error("expected error for missing capture")
+	}
+}
+
+func TestGetStream_NoClientTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for range 3 {
+			w.Write([]byte("chunk")) //nolint:errcheck
+			w.(http.Flusher).Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	// the download takes longer than the timeout of the HTTP client
+	client := New(server.URL, WithHTTPClient(&http.Client{Timeout: 50 * time.Millisecond}))
+
+	body, err := client.GetStream(context.Background(), "pcap/key", nil)
+	if err != nil {
+		t.Fatalf("GetStream failed: %v", err)
+	}
+	defer body.Close() //nolint:errcheck
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if string(data) != "chunkchunkchunk" {
+		t.Errorf("unexpected data %q", data)
+	}
+
+	// the context still applies
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	body, err = client.GetStream(ctx, "pcap/key", nil)
+	if err != nil {
+		t.Fatalf("GetStream failed: %v", err)
+	}
+	defer body.Close() //nolint:errcheck
+	if _, err := io.ReadAll(body); err == nil {
+		t.Error("expected error when the context is done")
+	}
+}
+
+func TestGetBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v0/labs/lab1/download":
+			w.Write([]byte("lab:\n  title: lab1\n")) //nolint:errcheck
+		case "/api/v0/labs/stalled/download":
+			w.Write([]byte("lab:\n")) //nolint:errcheck
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := New(server.URL, WithHTTPClient(&http.Client{Timeout: 100 * time.Millisecond}))
+
+	data, err := client.GetBytes(context.Background(), "labs/lab1/download", nil)
+	if err != nil {
+		t.Fatalf("GetBytes failed: %v", err)
+	}
+	if string(data) != "lab:\n  title: lab1\n" {
+		t.Errorf("unexpected data %q", data)
+	}
+
+	if _, err := client.GetBytes(context.Background(), "labs/other/download", nil); err == nil {
+		t.Error("expected error for missing lab")
+	}
+
+	// the timeout of the HTTP client covers reading the body
+	if _, err := client.GetBytes(context.Background(), "labs/stalled/download", nil); err == nil {
+		t.Error("expected error for a stalled body")
+	}
+}
+
 func TestHandleHTTPError(t *testing.T) {
 	tests := []struct {
 		name         string
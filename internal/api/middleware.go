@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/rschmied/gocmlclient/internal/httputil"
 	cmlerrors "github.com/rschmied/gocmlclient/pkg/errors"
 )
 
@@ -51,11 +52,12 @@ func LoggingMiddleware(logger *slog.Logger) Middleware {
 	}
 }
 
-// LogRequestBodyMiddleware logs request bodies for debugging
+// LogRequestBodyMiddleware logs request bodies for debugging, streamed
+// bodies are not logged as they can be read only once.
 func LogRequestBodyMiddleware(logger *slog.Logger) Middleware {
 	return func(next DoFunc) DoFunc {
 		return func(req *http.Request) (*http.Response, error) {
-			if req.Body != nil {
+			if _, streamed := req.Body.(*httputil.StreamBody); req.Body != nil && !streamed {
 				body, err := io.ReadAll(req.Body)
 				if err != nil {
 					logger.Error("Failed to read request body", "error", err)
@@ -100,6 +102,12 @@ func RetryMiddleware(policy RetryPolicy) Middleware {
 		return func(req *http.Request) (*http.Response, error) {
 			ctx := req.Context()
 
+			// a streamed body can be sent only once, it is not buffered
+			// for retries
+			if _, ok := req.Body.(*httputil.StreamBody); ok {
+				return next(req)
+			}
+
 			getBody, err := makeGetBody(req)
 			if err != nil {
 				return nil, err
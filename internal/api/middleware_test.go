@@ -555,6 +555,45 @@ func TestRetryMiddlewareWithBody(t *testing.T) {
 	}
 }
 
+// TestRetryMiddlewareStreamBody tests that a streamed body is sent once and
+// not buffered
+func TestRetryMiddlewareStreamBody(t *testing.T) {
+	callCount := 0
+	next := func(req *http.Request) (*http.Response, error) {
+		callCount++
+		return &http.Response{
+			StatusCode: 500,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil
+	}
+
+	policy := RetryPolicy{
+		MaxRetries:    3,
+		InitialDelay:  1 * time.Millisecond,
+		MaxDelay:      100 * time.Millisecond,
+		BackoffFactor: 2.0,
+	}
+	wrappedNext := RetryMiddleware(policy)(next)
+
+	req, err := httputil.BuildRequest(context.Background(), "http://example.com", "POST", "/import", nil, io.MultiReader(strings.NewReader("lab: {}")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res, err := wrappedNext(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res.Body.Close() //nolint:errcheck
+
+	if res.StatusCode != 500 {
+		t.Errorf("expected status 500, got %d", res.StatusCode)
+	}
+	if callCount != 1 {
+		t.Errorf("expected 1 call, got %d", callCount)
+	}
+}
+
 // TestRetryMiddlewareBodyError tests retry middleware with body read errors
 func TestRetryMiddlewareBodyError(t *testing.T) {
 	callCount := 0
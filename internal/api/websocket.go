@@ -0,0 +1,233 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/rschmied/gocmlclient/internal/httputil"
+)
+
+// websocketGUID is used to compute the Sec-WebSocket-Accept header (RFC 6455).
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// websocket opcodes
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xa
+)
+
+// DialWebSocket opens a websocket connection to `path`, which is relative
+// to the controller and not to the API base path (e.g. "/ws/pty/key"). The
+// handshake runs through the regular middlewares and transport, including
+// authentication and TLS settings, but not the total timeout of the HTTP
+// client: the context bounds the handshake, the connection outlives it. The
+// returned connection exposes the messages as a plain byte stream: data from
+// text and binary messages is returned by Read, Write sends binary messages.
+func (c *Client) DialWebSocket(ctx context.Context, path string) (io.ReadWriteCloser, error) {
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	u := base.ResolveReference(&url.URL{Path: path})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	httputil.ApplyClientIdentityHeaders(req.Header, c.clientID, c.clientUUID, c.clientVersion)
+
+	res, err := c.streamDo(req)
+	if err != nil {
+		return nil, c.wrapConnectionError(err)
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		defer res.Body.Close() //nolint:errcheck
+		if res.StatusCode >= 400 {
+			return nil, c.handleHTTPError(res)
+		}
+		return nil, fmt.Errorf("websocket handshake: unexpected status %d", res.StatusCode)
+	}
+	rwc, ok := res.Body.(io.ReadWriteCloser)
+	if !ok {
+		res.Body.Close() //nolint:errcheck
+		return nil, errors.New("websocket handshake: connection can't be upgraded")
+	}
+	if res.Header.Get("Sec-WebSocket-Accept") != websocketAccept(key) {
+		rwc.Close() //nolint:errcheck
+		return nil, errors.New("websocket handshake: invalid accept key")
+	}
+	return &wsConn{rwc: rwc, br: bufio.NewReader(rwc)}, nil
+}
+
+func websocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// wsConn is a minimal client side websocket connection (RFC 6455).
+type wsConn struct {
+	rwc io.ReadWriteCloser
+	br  *bufio.Reader
+
+	// remaining payload of the current data frame, and its mask
+	remaining uint64
+	mask      []byte
+	maskPos   int
+
+	wmu    sync.Mutex
+	closed bool
+}
+
+// Read returns payload data of text and binary messages. Control frames
+// are handled transparently, a close frame ends the stream with io.EOF.
+func (c *wsConn) Read(p []byte) (int, error) {
+	for c.remaining == 0 {
+		opcode, length, mask, err := c.readHeader()
+		if err != nil {
+			return 0, err
+		}
+		switch opcode {
+		case wsContinuation, wsText, wsBinary:
+			c.remaining, c.mask, c.maskPos = length, mask, 0
+		default:
+			payload := make([]byte, length)
+			if _, err := io.ReadFull(c.br, payload); err != nil {
+				return 0, err
+			}
+			unmask(payload, mask, 0)
+			switch opcode {
+			case wsPing:
+				if err := c.writeFrame(wsPong, payload); err != nil {
+					return 0, err
+				}
+			case wsClose:
+				_ = c.writeFrame(wsClose, payload)
+				return 0, io.EOF
+			}
+		}
+	}
+
+	if uint64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.br.Read(p)
+	unmask(p[:n], c.mask, c.maskPos)
+	c.maskPos += n
+	c.remaining -= uint64(n)
+	return n, err
+}
+
+func (c *wsConn) readHeader() (opcode byte, length uint64, mask []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.br, head[:]); err != nil {
+		return 0, 0, nil, err
+	}
+	opcode = head[0] & 0x0f
+	length = uint64(head[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return 0, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return 0, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if head[1]&0x80 != 0 {
+		mask = make([]byte, 4)
+		if _, err = io.ReadFull(c.br, mask); err != nil {
+			return 0, 0, nil, err
+		}
+	}
+	return opcode, length, mask, nil
+}
+
+// Write sends `p` as one binary message.
+func (c *wsConn) Write(p []byte) (int, error) {
+	if err := c.writeFrame(wsBinary, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// writeFrame writes a single, masked frame as required for clients.
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closed {
+		return net.ErrClosed
+	}
+
+	frame := make([]byte, 0, len(payload)+14)
+	frame = append(frame, 0x80|opcode)
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	mask := make([]byte, 4)
+	if _, err := rand.Read(mask); err != nil {
+		return err
+	}
+	frame = append(frame, mask...)
+	start := len(frame)
+	frame = append(frame, payload...)
+	unmask(frame[start:], mask, 0)
+
+	_, err := c.rwc.Write(frame)
+	return err
+}
+
+// Close sends a close frame and closes the connection.
+func (c *wsConn) Close() error {
+	_ = c.writeFrame(wsClose, nil)
+	c.wmu.Lock()
+	c.closed = true
+	c.wmu.Unlock()
+	return c.rwc.Close()
+}
+
+// unmask applies the (un)masking of RFC 6455 section 5.3, `pos` is the
+// position of `data` within the payload.
+func unmask(data, mask []byte, pos int) {
+	if len(mask) == 0 {
+		return
+	}
+	for i := range data {
+		data[i] ^= mask[(pos+i)%4]
+	}
+}
@@ -2,9 +2,11 @@ package auth
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"net/http"
 
+	"github.com/rschmied/gocmlclient/internal/httputil"
 	"github.com/rschmied/gocmlclient/internal/logging"
 )
 
@@ -36,7 +38,10 @@ func NewTransport(base http.RoundTripper, manager *Manager, skip []string) *Tran
 	}
 }
 
-// RoundTrip implements http.RoundTripper
+// RoundTrip implements http.RoundTripper. Requests which fail with 401 are
+// replayed once with a fresh token, except for requests with a streamed body
+// (*httputil.StreamBody): the body is sent as is, hence it can't be replayed
+// and an error is returned after refreshing the token.
 func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 	// Skip authentication for certain endpoints
 	if t.shouldSkipAuth(req) {
@@ -46,9 +51,11 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 
 	logging.Debug("add auth", "method", req.Method, "url", req.URL.String())
 
-	// Store the request body in a buffer for potential retries
+	// Store the request body in a buffer for potential retries, streamed
+	// bodies are forwarded as is
+	_, streamed := req.Body.(*httputil.StreamBody)
 	var reqBodyBuf []byte
-	if req.Body != nil {
+	if req.Body != nil && !streamed {
 		var err error
 		reqBodyBuf, err = io.ReadAll(req.Body)
 		if err != nil {
@@ -99,6 +106,10 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 			return nil, err
 		}
 
+		if streamed {
+			return nil, fmt.Errorf("%s %s: unauthorized, streamed request body can't be replayed with the refreshed token", req.Method, req.URL.Path)
+		}
+
 		// Retry with the new token
 		retryReq := req.Clone(req.Context())
 		retryReq.Header.Set("Authorization", "Bearer "+newToken)
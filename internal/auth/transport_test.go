@@ -11,6 +11,8 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/rschmied/gocmlclient/internal/httputil"
 )
 
 type mockProviderForTransport struct {
@@ -521,6 +523,70 @@ func TestTransportRoundTripWithStreamingBody(t *testing.T) {
 	_ = resp.Body.Close()
 }
 
+func TestTransportRoundTripWithStreamBody(t *testing.T) {
+	received := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		first := make([]byte, 5)
+		if _, err := io.ReadFull(r.Body, first); err != nil {
+			t.Errorf("failed to read request body: %v", err)
+		}
+		close(received)
+		rest, _ := io.ReadAll(r.Body)
+		if got := string(first) + string(rest); got != "first, rest" {
+			t.Errorf("expected body %q, got %q", "first, rest", got)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// the rest of the body is only written once the server got the first
+	// part, a transport buffering the body fails
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = pw.Write([]byte("first"))
+		select {
+		case <-received:
+			_, _ = pw.Write([]byte(", rest"))
+			_ = pw.Close()
+		case <-time.After(5 * time.Second):
+			_ = pw.CloseWithError(fmt.Errorf("body read before it was sent"))
+		}
+	}()
+
+	manager := createMockManager("test-token", time.Now().Add(time.Hour), nil)
+	transport := NewTransport(http.DefaultTransport, manager, nil)
+
+	req, _ := http.NewRequest("POST", server.URL+"/api/import", &httputil.StreamBody{Reader: pr})
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("request with stream body failed: %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestTransportRoundTripWithStreamBody401(t *testing.T) {
+	callCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callCount++
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	manager := createMockManager("test-token", time.Now().Add(time.Hour), nil)
+	transport := NewTransport(http.DefaultTransport, manager, nil)
+
+	body := &httputil.StreamBody{Reader: strings.NewReader("topology")}
+	req, _ := http.NewRequest("POST", server.URL+"/api/import", body)
+	_, err := transport.RoundTrip(req)
+	if err == nil || !strings.Contains(err.Error(), "can't be replayed") {
+		t.Errorf("expected replay error, got %v", err)
+	}
+	if callCount != 1 {
+		t.Errorf("expected 1 call (no replay), got %d", callCount)
+	}
+}
+
 func TestTransportRoundTripCustomSkipPatterns(t *testing.T) {
 	// Create a test server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
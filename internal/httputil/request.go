@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"strings"
 )
 
 // ClientID is the identifier used for the HTTP User-Agent header.
@@ -37,7 +38,9 @@ func BuildRequest(ctx context.Context, baseURL, method, endpoint string, query m
 
 	// prepare request body
 	var bodyReader io.Reader
-	if body != nil {
+	if r, ok := streamBody(body); ok {
+		bodyReader = &StreamBody{Reader: r}
+	} else if body != nil {
 		bodyBytes, marshalErr := marshalBody(body)
 		if marshalErr != nil {
 			return nil, fmt.Errorf("marshal body: %w", marshalErr)
@@ -57,6 +60,31 @@ func BuildRequest(ctx context.Context, baseURL, method, endpoint string, query m
 	return req, nil
 }
 
+// StreamBody is a request body which is streamed from its reader instead of
+// being buffered. It can be read only once, hence requests with such a body
+// are not retried. Closing it does not close the reader.
+type StreamBody struct {
+	io.Reader
+}
+
+// Close implements io.Closer, the reader is owned by the caller.
+func (b *StreamBody) Close() error {
+	return nil
+}
+
+// streamBody returns the body if it is a reader to be streamed. In-memory
+// readers are excluded, their content is already buffered and requests with
+// them can be retried.
+func streamBody(body any) (io.Reader, bool) {
+	switch v := body.(type) {
+	case *bytes.Buffer, *bytes.Reader, *strings.Reader:
+		return nil, false
+	case io.Reader:
+		return v, true
+	}
+	return nil, false
+}
+
 // marshalBody handles different body types
 func marshalBody(body any) ([]byte, error) {
 	switch v := body.(type) {
@@ -187,6 +187,27 @@ func TestBuildRequestWithReaderBody(t *testing.T) {
 	}
 }
 
+func TestBuildRequestStreamBody(t *testing.T) {
+	ctx := context.Background()
+	baseURL := "https://api.example.com"
+
+	// a reader which is not held in memory is passed through unread
+	reader := io.MultiReader(strings.NewReader("streamed "), strings.NewReader("content"))
+	req, err := BuildRequest(ctx, baseURL, "POST", "/upload", nil, reader)
+	assert.NoError(t, err)
+	assert.IsType(t, &StreamBody{}, req.Body)
+	assert.Nil(t, req.GetBody)
+
+	body, err := io.ReadAll(req.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "streamed content", string(body))
+
+	// in-memory readers can be replayed
+	req, err = BuildRequest(ctx, baseURL, "POST", "/upload", nil, strings.NewReader("content"))
+	assert.NoError(t, err)
+	assert.NotNil(t, req.GetBody)
+}
+
 func TestBuildRequestContext(t *testing.T) {
 	type myKey string
 	const testKey myKey = "test-key"
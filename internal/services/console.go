@@ -0,0 +1,148 @@
+// Package services, console specific
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+
+	"github.com/rschmied/gocmlclient/internal/api"
+	"github.com/rschmied/gocmlclient/internal/httputil"
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+// ConsoleService provides access to the console logs of nodes and to
+// interactive console sessions. The console of a node is identified by the
+// device number of its serial device, see Node.SerialDevices.
+type ConsoleService struct {
+	apiClient *api.Client
+
+	// optional, replaces the websocket of the controller for sessions
+	Dialer ConsoleDialer
+}
+
+// Ensure ConsoleService implements interface
+var _ ConsoleServiceInterface = (*ConsoleService)(nil)
+
+// ConsoleServiceInterface defines methods needed by other services/clients.
+type ConsoleServiceInterface interface {
+	Log(ctx context.Context, labID, nodeID models.UUID, device int) (models.ConsoleLog, error)
+	LogSince(ctx context.Context, labID, nodeID models.UUID, device int, cursor models.ConsoleCursor) (models.ConsoleLog, error)
+	Tail(ctx context.Context, labID, nodeID models.UUID, device, lines int) (string, error)
+	Stream(ctx context.Context, labID, nodeID models.UUID, device int, opts models.WaitOptions) iter.Seq2[string, error]
+	Open(ctx context.Context, node *models.Node, device int) (*ConsoleSession, error)
+	OpenKey(ctx context.Context, consoleKey models.UUID) (*ConsoleSession, error)
+}
+
+// NewConsoleService creates a new console service
+func NewConsoleService(apiClient *api.Client) *ConsoleService {
+	return &ConsoleService{
+		apiClient: apiClient,
+	}
+}
+
+func consoleLogURL(labID, nodeID models.UUID, device int) string {
+	return fmt.Sprintf("%s/consoles/%d/log", nodeURL(labID, nodeID), device)
+}
+
+func (s *ConsoleService) get(ctx context.Context, labID, nodeID models.UUID, device int, query map[string]string) (string, error) {
+	var log string
+	if err := s.apiClient.GetJSON(ctx, consoleLogURL(labID, nodeID, device), query, &log); err != nil {
+		return "", errors.Wrapf(err, "console log of node %s", nodeID)
+	}
+	return log, nil
+}
+
+// Log returns the buffered console log of the serial device `device` of the
+// node. The last line is included even if it is not complete (yet).
+func (s *ConsoleService) Log(ctx context.Context, labID, nodeID models.UUID, device int) (models.ConsoleLog, error) {
+	log, err := s.get(ctx, labID, nodeID, device, nil)
+	if err != nil {
+		return models.ConsoleLog{}, err
+	}
+	lines, partial := splitConsoleLines(log)
+	result := models.ConsoleLog{Lines: lines, Cursor: models.NewConsoleCursor(lines)}
+	if len(partial) > 0 {
+		result.Lines = append(result.Lines, partial)
+	}
+	return result, nil
+}
+
+// LogSince returns the complete lines which were added to the console log
+// after `cursor`, the cursor being the one of a previous result. The zero
+// cursor returns all lines. As the controller drops old lines when its buffer
+// is full, the position is found by content, see models.ConsoleCursor. If the
+// lines of the cursor were dropped in the meantime, all lines of the buffer
+// are returned.
+func (s *ConsoleService) LogSince(ctx context.Context, labID, nodeID models.UUID, device int, cursor models.ConsoleCursor) (models.ConsoleLog, error) {
+	log, err := s.get(ctx, labID, nodeID, device, nil)
+	if err != nil {
+		return models.ConsoleLog{}, err
+	}
+	lines, _ := splitConsoleLines(log)
+	lines, next := cursor.Since(lines)
+	return models.ConsoleLog{Lines: lines, Cursor: next}, nil
+}
+
+// Tail returns the last `lines` lines of the console log as text.
+func (s *ConsoleService) Tail(ctx context.Context, labID, nodeID models.UUID, device, lines int) (string, error) {
+	query := httputil.NewQueryBuilder().Set("lines", strconv.Itoa(lines)).Build()
+	return s.get(ctx, labID, nodeID, device, query)
+}
+
+// Stream returns the lines added to the console log from now on, as they
+// arrive. The log is polled as controlled by `opts`, the timeout of `opts`
+// ends the stream. The stream also ends when the context is done or when the
+// consumer stops the iteration. A failed poll is yielded as error and ends
+// the stream.
+//
+//	for line, err := range client.Console.Stream(ctx, labID, nodeID, 0, opts) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(line)
+//	}
+func (s *ConsoleService) Stream(ctx context.Context, labID, nodeID models.UUID, device int, opts models.WaitOptions) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		start, err := s.LogSince(ctx, labID, nodeID, device, models.ConsoleCursor{})
+		if err != nil {
+			yield("", err)
+			return
+		}
+		cursor := start.Cursor
+
+		stopped := false
+		err = waitFor(ctx, opts, func(ctx context.Context) (bool, error) {
+			log, err := s.LogSince(ctx, labID, nodeID, device, cursor)
+			if err != nil {
+				return false, err
+			}
+			cursor = log.Cursor
+			for _, line := range log.Lines {
+				if !yield(line, nil) {
+					stopped = true
+					return true, nil
+				}
+			}
+			return false, nil
+		})
+		if err != nil && !stopped && ctx.Err() == nil && !stderrors.Is(err, errors.ErrTimeout) {
+			yield("", err)
+		}
+	}
+}
+
+// splitConsoleLines splits the log into complete lines and the trailing
+// incomplete line, if any. Carriage returns are removed.
+func splitConsoleLines(log string) ([]string, string) {
+	log = strings.ReplaceAll(log, "\r", "")
+	if len(log) == 0 {
+		return nil, ""
+	}
+	lines := strings.Split(log, "\n")
+	return lines[:len(lines)-1], lines[len(lines)-1]
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+// consoleWebSocketPath is the controller path of the console websocket, the
+// console key is appended.
+const consoleWebSocketPath = "/ws/pty/"
+
+// ConsoleDialer opens the byte stream of the serial console identified by
+// its console key. The console service uses a websocket to the controller
+// by default, tests or proxies can provide their own.
+type ConsoleDialer interface {
+	DialConsole(ctx context.Context, consoleKey models.UUID) (io.ReadWriteCloser, error)
+}
+
+// DialConsole implements ConsoleDialer via the controller's websocket.
+func (s *ConsoleService) DialConsole(ctx context.Context, consoleKey models.UUID) (io.ReadWriteCloser, error) {
+	return s.apiClient.DialWebSocket(ctx, consoleWebSocketPath+string(consoleKey))
+}
+
+// Open opens an interactive session on the serial console `device` of the
+// node. The console key is taken from the operational data of the node
+// (NodeOperational.SerialConsoles, or SerialDevices), the node must be
+// running.
+func (s *ConsoleService) Open(ctx context.Context, node *models.Node, device int) (*ConsoleSession, error) {
+	for _, console := range serialConsoles(node) {
+		if console.DeviceNumber == device && len(console.ConsoleKey) > 0 {
+			return s.OpenKey(ctx, console.ConsoleKey)
+		}
+	}
+	return nil, errors.Wrapf(errors.ErrElementNotFound, "console %d of node %s", device, node.Label)
+}
+
+// serialConsoles returns the serial consoles of the node from its
+// operational data and its serial devices.
+func serialConsoles(node *models.Node) []models.SerialConsole {
+	var consoles []models.SerialConsole
+	if node.Operational != nil {
+		consoles = append(consoles, node.Operational.SerialConsoles...)
+	}
+	for _, dev := range node.SerialDevices {
+		consoles = append(consoles, models.SerialConsole{ConsoleKey: dev.ConsoleKey, DeviceNumber: dev.DeviceNumber})
+	}
+	return consoles
+}
+
+// OpenKey opens an interactive session on the serial console identified by
+// the console key.
+func (s *ConsoleService) OpenKey(ctx context.Context, consoleKey models.UUID) (*ConsoleSession, error) {
+	var dialer ConsoleDialer = s
+	if s.Dialer != nil {
+		dialer = s.Dialer
+	}
+	conn, err := dialer.DialConsole(ctx, consoleKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open console %s", consoleKey)
+	}
+	return NewConsoleSession(conn), nil
+}
+
+// ConsoleSession is an interactive serial console session with
+// expect-style helpers. All output received from the console is kept in the
+// transcript. A session is safe for use by one caller at a time.
+type ConsoleSession struct {
+	conn io.ReadWriteCloser
+
+	mu         sync.Mutex
+	pending    strings.Builder // received, not yet consumed by Expect
+	transcript strings.Builder
+	err        error         // read error, ends the session
+	notify     chan struct{} // closed and replaced when data arrives
+}
+
+// NewConsoleSession creates a session on top of an open console stream,
+// e.g. one provided by a custom ConsoleDialer.
+func NewConsoleSession(conn io.ReadWriteCloser) *ConsoleSession {
+	session := &ConsoleSession{conn: conn, notify: make(chan struct{})}
+	go session.readLoop()
+	return session
+}
+
+func (s *ConsoleSession) readLoop() {
+	buf := make([]byte, 4096)
+	for {
+		n, err := s.conn.Read(buf)
+		s.mu.Lock()
+		if n > 0 {
+			s.pending.Write(buf[:n])
+			s.transcript.Write(buf[:n])
+		}
+		if err != nil {
+			s.err = err
+		}
+		close(s.notify)
+		s.notify = make(chan struct{})
+		s.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Send writes `text` to the console as is.
+func (s *ConsoleSession) Send(text string) error {
+	_, err := io.WriteString(s.conn, text)
+	return err
+}
+
+// SendLine writes `line` followed by a carriage return, like pressing enter.
+func (s *ConsoleSession) SendLine(line string) error {
+	return s.Send(line + "\r")
+}
+
+// Expect waits until the output received since the last match matches
+// `re` and returns this output up to the end of the match. The wait ends
+// with an error wrapping errors.ErrTimeout when `timeout` (if not zero) or
+// the context deadline expires, the unmatched output is part of the error.
+func (s *ConsoleSession) Expect(ctx context.Context, re *regexp.Regexp, timeout time.Duration) (string, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	for {
+		s.mu.Lock()
+		pending := s.pending.String()
+		if loc := re.FindStringIndex(pending); loc != nil {
+			s.pending.Reset()
+			s.pending.WriteString(pending[loc[1]:])
+			s.mu.Unlock()
+			return pending[:loc[1]], nil
+		}
+		readErr, notify := s.err, s.notify
+		s.mu.Unlock()
+
+		if readErr != nil {
+			return pending, fmt.Errorf("expect %q: %w", re, readErr)
+		}
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return pending, fmt.Errorf("expect %q, got %q: %w", re, pending, errors.ErrTimeout)
+			}
+			return pending, ctx.Err()
+		case <-notify:
+		}
+	}
+}
+
+// SendExpect sends the line and waits for `re`, see Expect. This is the
+// usual way to run a command, with `re` matching the prompt.
+func (s *ConsoleSession) SendExpect(ctx context.Context, line string, re *regexp.Regexp, timeout time.Duration) (string, error) {
+	if err := s.SendLine(line); err != nil {
+		return "", err
+	}
+	return s.Expect(ctx, re, timeout)
+}
+
+// Transcript returns all output received from the console so far.
+func (s *ConsoleSession) Transcript() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.transcript.String()
+}
+
+// Close closes the console stream.
+func (s *ConsoleSession) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rschmied/gocmlclient/internal/api"
+	"github.com/rschmied/gocmlclient/internal/testutil"
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+var promptRE = regexp.MustCompile(`router>`)
+
+// fakeRouter emulates a router console: input is echoed, commands are
+// answered followed by the prompt, "exit" ends the session.
+func fakeRouter(console *testutil.FakeConsole) {
+	if console.Key != "key-1" {
+		return
+	}
+	_ = console.Ping()
+	_, _ = io.WriteString(console, "\r\nrouter>")
+
+	r := bufio.NewReader(console)
+	for {
+		line, err := r.ReadString('\r')
+		if err != nil {
+			return
+		}
+		_, _ = io.WriteString(console, line+"\n")
+		switch strings.TrimSpace(line) {
+		case "show version":
+			_, _ = io.WriteString(console, "Cisco IOS Software, fake\r\nrouter>")
+		case "exit":
+			return
+		default:
+			_, _ = io.WriteString(console, "% Invalid input\r\nrouter>")
+		}
+	}
+}
+
+func newConsoleSessionTestService(t *testing.T) *ConsoleService {
+	server := testutil.NewFakeConsoleServer(t, fakeRouter)
+	client := api.New(server.URL, api.WithHTTPClient(server.Client()))
+	return NewConsoleService(client)
+}
+
+func TestConsoleSession(t *testing.T) {
+	service := newConsoleSessionTestService(t)
+	ctx := context.Background()
+
+	node := &models.Node{
+		Label: "r1",
+		Operational: &models.NodeOperational{
+			SerialConsoles: []models.SerialConsole{{ConsoleKey: "key-1", DeviceNumber: 0}},
+		},
+	}
+	session, err := service.Open(ctx, node, 0)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer session.Close() //nolint:errcheck
+
+	_, err = session.Expect(ctx, promptRE, time.Second)
+	assert.NoError(t, err)
+
+	out, err := session.SendExpect(ctx, "show version", promptRE, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "show version\r\nCisco IOS Software, fake\r\nrouter>", out)
+
+	out, err = session.SendExpect(ctx, "show nothing", regexp.MustCompile(`% (\w+) input`), time.Second)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(out, "% Invalid input"))
+
+	// the prompt after the error message is still pending
+	_, err = session.Expect(ctx, promptRE, time.Second)
+	assert.NoError(t, err)
+
+	_, err = session.Expect(ctx, promptRE, 20*time.Millisecond)
+	assert.ErrorIs(t, err, errors.ErrTimeout)
+
+	assert.NoError(t, session.SendLine("exit"))
+	_, err = session.Expect(ctx, promptRE, time.Second)
+	assert.ErrorIs(t, err, io.EOF)
+
+	assert.Equal(t, "\r\nrouter>show version\r\nCisco IOS Software, fake\r\nrouter>"+
+		"show nothing\r\n% Invalid input\r\nrouter>exit\r\n", session.Transcript())
+}
+
+func TestConsoleSession_NoConsole(t *testing.T) {
+	service := NewConsoleService(nil)
+	node := &models.Node{Label: "r1", SerialDevices: []models.SerialDevice{{ConsoleKey: "key-1", DeviceNumber: 0}}}
+	_, err := service.Open(context.Background(), node, 1)
+	assert.ErrorIs(t, err, errors.ErrElementNotFound)
+}
+
+func TestConsoleSession_HandshakeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"description": "forbidden"}`, http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	service := NewConsoleService(api.New(server.URL, api.WithHTTPClient(server.Client())))
+	_, err := service.OpenKey(context.Background(), "key-1")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "open console key-1")
+}
+
+type pipeDialer struct {
+	conn net.Conn
+	key  models.UUID
+}
+
+func (d *pipeDialer) DialConsole(ctx context.Context, consoleKey models.UUID) (io.ReadWriteCloser, error) {
+	d.key = consoleKey
+	return d.conn, nil
+}
+
+func TestConsoleSession_Dialer(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close() //nolint:errcheck
+
+	service := NewConsoleService(nil)
+	dialer := &pipeDialer{conn: client}
+	service.Dialer = dialer
+
+	session, err := service.OpenKey(context.Background(), "key-2")
+	assert.NoError(t, err)
+	defer session.Close() //nolint:errcheck
+	assert.Equal(t, models.UUID("key-2"), dialer.key)
+
+	go func() {
+		buf := make([]byte, 64)
+		n, _ := server.Read(buf)
+		_, _ = server.Write([]byte("got " + string(buf[:n]) + "\n$ "))
+	}()
+	out, err := session.SendExpect(context.Background(), "ls", regexp.MustCompile(`\$ $`), time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "got ls\r\n$ ", out)
+}
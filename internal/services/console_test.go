@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rschmied/gocmlclient/internal/testutil"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+const consoleLogMockURL = "https://mock/api/v0/labs/lab1/nodes/n1/consoles/0/log"
+
+// fakeConsoleLog serves a console log which grows by one chunk per request.
+// With a limit, only the last `limit` bytes are kept, like the size-limited
+// buffer of the controller.
+type fakeConsoleLog struct {
+	mu     sync.Mutex
+	chunks []string
+	log    string
+	limit  int
+}
+
+func (f *fakeConsoleLog) register() {
+	httpmock.RegisterResponder("GET", consoleLogMockURL,
+		func(req *http.Request) (*http.Response, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if len(f.chunks) > 0 {
+				f.log += f.chunks[0]
+				f.chunks = f.chunks[1:]
+			}
+			if f.limit > 0 && len(f.log) > f.limit {
+				f.log = f.log[len(f.log)-f.limit:]
+			}
+			body, _ := json.Marshal(f.log)
+			return httpmock.NewBytesResponse(200, body), nil
+		})
+}
+
+func TestConsoleLog(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	log := &fakeConsoleLog{chunks: []string{"line 1\r\nline 2\r\nRouter>", "\r\nline 4\r\n"}}
+	log.register()
+
+	service := NewConsoleService(client)
+	ctx := context.Background()
+
+	result, err := service.Log(ctx, "lab1", "n1", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"line 1", "line 2", "Router>"}, result.Lines)
+	assert.Equal(t, models.NewConsoleCursor([]string{"line 1", "line 2"}), result.Cursor)
+
+	result, err = service.LogSince(ctx, "lab1", "n1", 0, result.Cursor)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Router>", "line 4"}, result.Lines)
+
+	// the zero cursor is the start of the log
+	result, err = service.LogSince(ctx, "lab1", "n1", 0, models.ConsoleCursor{})
+	assert.NoError(t, err)
+	assert.Len(t, result.Lines, 4)
+}
+
+func TestConsoleLogSince_Rollover(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	// the buffer holds three lines, the oldest ones are dropped
+	log := &fakeConsoleLog{chunks: []string{"line 1\nline 2\nline 3\n", "line 4\nline 5\n", "line 6\n"}, limit: 21}
+	log.register()
+
+	service := NewConsoleService(client)
+	ctx := context.Background()
+
+	result, err := service.LogSince(ctx, "lab1", "n1", 0, models.ConsoleCursor{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"line 1", "line 2", "line 3"}, result.Lines)
+
+	result, err = service.LogSince(ctx, "lab1", "n1", 0, result.Cursor)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"line 4", "line 5"}, result.Lines)
+
+	result, err = service.LogSince(ctx, "lab1", "n1", 0, result.Cursor)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"line 6"}, result.Lines)
+}
+
+func TestConsoleTail(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponderWithQuery("GET", consoleLogMockURL, "lines=2",
+		httpmock.NewStringResponder(200, `"line 3\nline 4\n"`))
+
+	service := NewConsoleService(client)
+	tail, err := service.Tail(context.Background(), "lab1", "n1", 0, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "line 3\nline 4\n", tail)
+}
+
+func TestConsoleStream(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	log := &fakeConsoleLog{chunks: []string{"old\n", "", "new 1\nnew", " 2\n", "new 3\n"}}
+	log.register()
+
+	service := NewConsoleService(client)
+	opts := models.WaitOptions{Interval: time.Millisecond, Timeout: time.Second}
+
+	var lines []string
+	for line, err := range service.Stream(context.Background(), "lab1", "n1", 0, opts) {
+		assert.NoError(t, err)
+		lines = append(lines, line)
+		if len(lines) == 3 {
+			break
+		}
+	}
+	assert.Equal(t, []string{"new 1", "new 2", "new 3"}, lines)
+}
+
+func TestConsoleStream_Rollover(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	// the buffer is full from the start, the number of lines stays the same
+	log := &fakeConsoleLog{chunks: []string{"old 01\nold 02\nold 03\n", "new 01\n", "", "new 02\nnew 03\n", "new 04\n"}, limit: 21}
+	log.register()
+
+	service := NewConsoleService(client)
+	opts := models.WaitOptions{Interval: time.Millisecond, Timeout: time.Second}
+
+	var lines []string
+	for line, err := range service.Stream(context.Background(), "lab1", "n1", 0, opts) {
+		assert.NoError(t, err)
+		lines = append(lines, line)
+		if len(lines) == 4 {
+			break
+		}
+	}
+	assert.Equal(t, []string{"new 01", "new 02", "new 03", "new 04"}, lines)
+}
+
+func TestConsoleStream_End(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	log := &fakeConsoleLog{chunks: []string{"old\n", "new\n"}}
+	log.register()
+
+	service := NewConsoleService(client)
+
+	// the timeout ends the stream without error
+	var lines []string
+	opts := models.WaitOptions{Interval: time.Millisecond, Timeout: 20 * time.Millisecond}
+	for line, err := range service.Stream(context.Background(), "lab1", "n1", 0, opts) {
+		assert.NoError(t, err)
+		lines = append(lines, line)
+	}
+	assert.Equal(t, []string{"new"}, lines)
+
+	// a failing poll is reported
+	httpmock.RegisterResponder("GET", consoleLogMockURL,
+		httpmock.NewStringResponder(404, `{"description": "Node not found"}`))
+	var errs []error
+	for _, err := range service.Stream(context.Background(), "lab1", "n1", 0, opts) {
+		errs = append(errs, err)
+	}
+	if assert.Len(t, errs, 1) {
+		assert.Contains(t, errs[0].Error(), "console log of node n1")
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/rschmied/gocmlclient/internal/api"
 	"github.com/rschmied/gocmlclient/internal/httputil"
+	"github.com/rschmied/gocmlclient/pkg/errors"
 	"github.com/rschmied/gocmlclient/pkg/models"
 )
 
@@ -16,6 +17,10 @@ var _ InterfaceServiceInterface = (*InterfaceService)(nil)
 // InterfaceServiceInterface defines methods needed by other services
 type InterfaceServiceInterface interface {
 	Create(ctx context.Context, labID, nodeID models.UUID, slot int) (models.Interface, error)
+	CreateUpTo(ctx context.Context, labID, nodeID models.UUID, slot int) (models.InterfaceList, error)
+	Delete(ctx context.Context, labID, id models.UUID) error
+	Start(ctx context.Context, labID, id models.UUID) error
+	Stop(ctx context.Context, labID, id models.UUID) error
 	GetByID(ctx context.Context, labID, id models.UUID) (models.Interface, error)
 	GetInterfacesForNode(ctx context.Context, labID, id models.UUID) (models.InterfaceList, error)
 }
@@ -32,6 +37,16 @@ func NewInterfaceService(apiClient *api.Client) *InterfaceService {
 	}
 }
 
+// interfacesURL builds base URL for interfaces in a lab
+func interfacesURL(labID models.UUID) string {
+	return fmt.Sprintf("%s/%s/interfaces", labsAPI, labID)
+}
+
+// interfaceURL builds URL for a specific interface
+func interfaceURL(labID, id models.UUID) string {
+	return fmt.Sprintf("%s/%s", interfacesURL(labID), id)
+}
+
 // GetInterfacesForNode returns all interfaces for a specific node.
 func (s *InterfaceService) GetInterfacesForNode(ctx context.Context, labID, id models.UUID) (models.InterfaceList, error) {
 	// with the data=true option, we get not only the list of IDs but the
@@ -68,7 +83,7 @@ func sortInterfacesBySlot(i, j int, interfaceList models.InterfaceList) bool {
 
 // GetByID returns the interface identified by its `ID` (iface.ID).
 func (s *InterfaceService) GetByID(ctx context.Context, labID, id models.UUID) (models.Interface, error) {
-	api := fmt.Sprintf("labs/%s/interfaces/%s", labID, id)
+	api := interfaceURL(labID, id)
 	var iface models.Interface
 	queryParams := httputil.NewQueryBuilder().
 		WithOperational().
@@ -78,21 +93,46 @@ func (s *InterfaceService) GetByID(ctx context.Context, labID, id models.UUID) (
 }
 
 // Create creates an interface in the given lab and node.  If the slot is >= 0,
-// the request creates all unallocated slots up to and including that slot.
-// Conversely, if the slot is < 0 (e.g. -1), the next free slot is used.
+// the request creates all unallocated slots up to and including that slot
+// and the interface of that slot is returned, use CreateUpTo to get all of
+// them. Conversely, if the slot is < 0 (e.g. -1), the next free slot is used.
 func (s *InterfaceService) Create(ctx context.Context, labID, nodeID models.UUID, slot int) (models.Interface, error) {
-	var slotPtr *int
-
 	if slot >= 0 {
-		slotPtr = &slot
+		result, err := s.CreateUpTo(ctx, labID, nodeID, slot)
+		if err != nil {
+			return models.Interface{}, err
+		}
+		return *result[len(result)-1], nil
 	}
 
 	newIface := struct {
 		Node models.UUID `json:"node"`
-		Slot *int        `json:"slot,omitempty"`
 	}{
 		Node: nodeID,
-		Slot: slotPtr,
+	}
+
+	var result models.Interface
+	err := s.apiClient.PostJSON(ctx, interfacesURL(labID), nil, newIface, &result)
+	if err != nil {
+		return models.Interface{}, err
+	}
+	return result, nil
+}
+
+// CreateUpTo creates all unallocated slots of the node up to and including
+// `slot` and returns all created interfaces, sorted by slot. It is an error
+// if the slot is allocated already and nothing has been created.
+func (s *InterfaceService) CreateUpTo(ctx context.Context, labID, nodeID models.UUID, slot int) (models.InterfaceList, error) {
+	if slot < 0 {
+		return nil, errors.Wrapf(errors.ErrInvalidInput, "create interfaces: slot %d", slot)
+	}
+
+	newIface := struct {
+		Node models.UUID `json:"node"`
+		Slot int         `json:"slot"`
+	}{
+		Node: nodeID,
+		Slot: slot,
 	}
 
 	// This is quite awkward, not even sure if it's a good REST design practice:
@@ -102,25 +142,35 @@ func (s *InterfaceService) Create(ctx context.Context, labID, nodeID models.UUID
 	//
 	// A list is returned when slot is defined, even if it's just creating one
 	// interface
-
-	api := fmt.Sprintf("labs/%s/interfaces", labID)
-	if slotPtr == nil {
-		var result models.Interface
-		err := s.apiClient.PostJSON(ctx, api, nil, newIface, &result)
-		if err != nil {
-			return models.Interface{}, err
-		}
-		return result, err
-	}
-
-	// this is when a slot has been provided; the API provides now a list of
-	// interfaces
-	result := []models.Interface{}
-	err := s.apiClient.PostJSON(ctx, api, nil, newIface, &result)
+	result := models.InterfaceList{}
+	err := s.apiClient.PostJSON(ctx, interfacesURL(labID), nil, newIface, &result)
 	if err != nil {
-		return models.Interface{}, err
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, errors.Wrapf(errors.ErrElementNotFound, "create interfaces: slot %d of node %s is allocated", slot, nodeID)
 	}
 
-	lastIface := result[len(result)-1]
-	return lastIface, nil
+	sort.Slice(result, func(i, j int) bool {
+		return sortInterfacesBySlot(i, j, result)
+	})
+	return result, nil
+}
+
+// Delete removes the interface from its node. Connected interfaces can't be
+// removed, the link must be removed first.
+func (s *InterfaceService) Delete(ctx context.Context, labID, id models.UUID) error {
+	return s.apiClient.DeleteJSON(ctx, interfaceURL(labID, id), nil)
+}
+
+// Start starts (enables) the interface of a running node.
+func (s *InterfaceService) Start(ctx context.Context, labID, id models.UUID) error {
+	api := fmt.Sprintf("%s/state/start", interfaceURL(labID, id))
+	return s.apiClient.PutJSON(ctx, api, nil)
+}
+
+// Stop stops (disables) the interface of a running node.
+func (s *InterfaceService) Stop(ctx context.Context, labID, id models.UUID) error {
+	api := fmt.Sprintf("%s/state/stop", interfaceURL(labID, id))
+	return s.apiClient.PutJSON(ctx, api, nil)
 }
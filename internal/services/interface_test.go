@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/rschmied/gocmlclient/internal/testutil"
+	"github.com/rschmied/gocmlclient/pkg/errors"
 	"github.com/rschmied/gocmlclient/pkg/models"
 )
 
@@ -390,3 +391,77 @@ func TestSortInterfacesBySlot(t *testing.T) {
 func intPtr(i int) *int {
 	return &i
 }
+
+func TestInterfaceCreateUpTo(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - requires specific lab and node setup")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("POST", "https://mock/api/v0/labs/lab_id_1/interfaces",
+		httpmock.NewStringResponder(200, `[
+			{"id": "iface_id_4", "node": "node_id_1", "label": "eth4", "slot": 4},
+			{"id": "iface_id_2", "node": "node_id_1", "label": "eth2", "slot": 2},
+			{"id": "iface_id_3", "node": "node_id_1", "label": "eth3", "slot": 3}
+		]`))
+	httpmock.RegisterResponder("POST", "https://mock/api/v0/labs/lab_id_2/interfaces",
+		httpmock.NewStringResponder(200, `[]`))
+
+	service := NewInterfaceService(client)
+	ctx := context.Background()
+
+	ifaces, err := service.CreateUpTo(ctx, "lab_id_1", "node_id_1", 4)
+	assert.NoError(t, err)
+	if assert.Len(t, ifaces, 3) {
+		for i, iface := range ifaces {
+			assert.Equal(t, i+2, *iface.Slot)
+		}
+	}
+
+	iface, err := service.Create(ctx, "lab_id_1", "node_id_1", 4)
+	assert.NoError(t, err)
+	assert.Equal(t, models.UUID("iface_id_4"), iface.ID)
+
+	_, err = service.CreateUpTo(ctx, "lab_id_1", "node_id_1", -1)
+	assert.ErrorIs(t, err, errors.ErrInvalidInput)
+
+	// slot exists already, nothing is created
+	_, err = service.Create(ctx, "lab_id_2", "node_id_1", 0)
+	assert.ErrorIs(t, err, errors.ErrElementNotFound)
+	assert.Equal(t, 3, httpmock.GetTotalCallCount())
+}
+
+func TestInterfaceDeleteStartStop(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - requires specific lab and node setup")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("DELETE", "https://mock/api/v0/labs/lab_id_1/interfaces/iface_id_1",
+		httpmock.NewStringResponder(204, ""))
+	httpmock.RegisterResponder("DELETE", "https://mock/api/v0/labs/lab_id_1/interfaces/iface_id_2",
+		httpmock.NewStringResponder(400, `{"description": "Interface is connected"}`))
+	httpmock.RegisterResponder("PUT", "https://mock/api/v0/labs/lab_id_1/interfaces/iface_id_1/state/start",
+		httpmock.NewStringResponder(204, ""))
+	httpmock.RegisterResponder("PUT", "https://mock/api/v0/labs/lab_id_1/interfaces/iface_id_1/state/stop",
+		httpmock.NewStringResponder(204, ""))
+
+	service := NewInterfaceService(client)
+	ctx := context.Background()
+
+	assert.NoError(t, service.Start(ctx, "lab_id_1", "iface_id_1"))
+	assert.NoError(t, service.Stop(ctx, "lab_id_1", "iface_id_1"))
+	assert.NoError(t, service.Delete(ctx, "lab_id_1", "iface_id_1"))
+
+	err := service.Delete(ctx, "lab_id_1", "iface_id_2")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Interface is connected")
+
+	info := httpmock.GetCallCountInfo()
+	assert.Equal(t, 1, info["PUT https://mock/api/v0/labs/lab_id_1/interfaces/iface_id_1/state/start"])
+	assert.Equal(t, 1, info["PUT https://mock/api/v0/labs/lab_id_1/interfaces/iface_id_1/state/stop"])
+}
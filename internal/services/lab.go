@@ -4,6 +4,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
 
 	"golang.org/x/sync/errgroup"
@@ -18,6 +19,8 @@ import (
 const (
 	labsAPI      = "labs"
 	importAPI    = "import"
+	topologyAPI  = "topology"
+	downloadAPI  = "download"
 	convergedAPI = "check_if_converged"
 	populateAPI  = "populate_lab_tiles"
 	wipeAction   = "wipe"
@@ -38,19 +41,45 @@ type LabServiceInterface interface {
 	Update(ctx context.Context, labID models.UUID, lab models.LabUpdateRequest) (models.Lab, error)
 	Delete(ctx context.Context, id models.UUID) error
 	Import(ctx context.Context, topologyYAML string) (models.Lab, error)
+	ImportWithOptions(ctx context.Context, topology io.Reader, opts models.LabImportOptions) (models.Lab, models.LabImport, error)
+	Export(ctx context.Context, id models.UUID, opts models.LabExportOptions) (models.LabExport, error)
+	ValidateTopology(ctx context.Context, topology *models.LabTopology) (errors.ValidationErrors, error)
+	Clone(ctx context.Context, id models.UUID, opts models.LabCloneOptions) (models.LabClone, error)
 	Start(ctx context.Context, labID models.UUID) error
+	StartStaged(ctx context.Context, labID models.UUID, opts models.LabStartOptions) error
 	Stop(ctx context.Context, labID models.UUID) error
 	Wipe(ctx context.Context, labID models.UUID) error
+	Destroy(ctx context.Context, labID models.UUID, opts models.LabDestroyOptions) error
 	HasConverged(ctx context.Context, id models.UUID) (bool, error)
+	WaitConverged(ctx context.Context, id models.UUID, opts models.LabWaitOptions) error
+	Archive(ctx context.Context, id models.UUID, opts models.LabArchiveOptions) (*models.LabArchive, error)
+	ImportArchive(ctx context.Context, r io.Reader) (models.Lab, *models.LabArchive, error)
+	NodeResources(ctx context.Context, id models.UUID) (models.NodeResourcesMap, error)
 }
 
 // LabService provides lab-related operations
 type LabService struct {
-	apiClient *api.Client
-	User      UserServiceInterface
-	Link      LinkServiceInterface
-	Interface InterfaceServiceInterface
-	Node      NodeServiceInterface
+	apiClient       *api.Client
+	useNamedConfigs bool
+	User            UserServiceInterface
+	Link            LinkServiceInterface
+	Interface       InterfaceServiceInterface
+	Node            NodeServiceInterface
+
+	// optional, used for topology validation and node resources
+	NodeDefinition  NodeDefinitionServiceInterface
+	ImageDefinition ImageDefinitionServiceInterface
+
+	// optional, used when cloning labs
+	Annotation      AnnotationServiceInterface
+	SmartAnnotation SmartAnnotationServiceInterface
+
+	// optional, used for the console logs of nodes which did not boot and
+	// for lab archives
+	Console ConsoleServiceInterface
+
+	// optional, used for the packet captures of lab archives
+	PCAP PCAPServiceInterface
 }
 
 // NewLabService creates a new lab service
@@ -64,6 +93,12 @@ func NewLabService(apiClient *api.Client, iface InterfaceServiceInterface, link
 	}
 }
 
+// SetNamedConfigs controls whether exports request named configurations,
+// same as the node service does for node reads.
+func (s *LabService) SetNamedConfigs(v bool) {
+	s.useNamedConfigs = v
+}
+
 // labURL builds URL for a specific lab
 func labURL(id models.UUID) string {
 	return fmt.Sprintf("%s/%s", labsAPI, id)
@@ -209,26 +244,93 @@ func (s *LabService) Wipe(ctx context.Context, id models.UUID) error {
 	return nil
 }
 
-// Import imports a lab from YAML topology
+// Import imports a lab from YAML topology. Import warnings are logged, use
+// ImportWithOptions to get them returned.
 func (s *LabService) Import(ctx context.Context, topology string) (models.Lab, error) {
-	topoReader := strings.NewReader(topology)
+	lab, result, err := s.ImportWithOptions(ctx, strings.NewReader(topology), models.LabImportOptions{})
+	if len(result.Warnings) > 0 {
+		logging.Warn("Lab import completed with warnings", "warnings", result.Warnings)
+	}
+	return lab, err
+}
+
+// ImportWithOptions imports a lab from the YAML topology read from
+// `topology`. The import result, including the warnings of the controller, is
+// returned alongside the imported lab. It is also returned when the import
+// succeeded but fetching the lab failed, so that the caller knows the ID.
+// The topology is streamed to the controller, not buffered; hence an import
+// from a file or another stream is not retried on transient errors or when
+// the token expired (the token is refreshed for the next call).
+func (s *LabService) ImportWithOptions(ctx context.Context, topology io.Reader, opts models.LabImportOptions) (models.Lab, models.LabImport, error) {
+	qb := httputil.NewQueryBuilder()
+	if len(opts.Title) > 0 {
+		qb.Set("title", opts.Title)
+	}
 
-	var importResponse struct {
-		ID       models.UUID `json:"id"`
-		Warnings []string    `json:"warnings"`
+	var result models.LabImport
+	err := s.apiClient.PostJSON(ctx, importAPI, qb.Build(), topology, &result)
+	if err != nil {
+		return models.Lab{}, models.LabImport{}, errors.Wrap(err, "import lab")
 	}
 
-	err := s.apiClient.PostJSON(ctx, importAPI, nil, topoReader, &importResponse)
+	lab, err := s.GetByID(ctx, result.ID, !opts.Shallow)
 	if err != nil {
-		return models.Lab{}, errors.Wrap(err, "import lab")
+		return models.Lab{}, result, err
+	}
+	return lab, result, nil
+}
+
+// Export returns the topology of the lab identified by `id`. The result holds
+// the YAML document as downloaded from the controller, unchanged, and the
+// typed form parsed from it. Node configurations are included unless
+// excluded via `opts`; when included, the running configurations of booted
+// nodes can be extracted first.
+func (s *LabService) Export(ctx context.Context, id models.UUID, opts models.LabExportOptions) (models.LabExport, error) {
+	if opts.ExtractConfigurations && !opts.ExcludeConfigurations {
+		if s.Node == nil {
+			return models.LabExport{}, errors.Wrap(errors.ErrMissingRequired, "export lab: node service")
+		}
+		if _, err := s.Node.ExtractLabConfigurations(ctx, id, models.ConfigExtractOptions{}); err != nil {
+			return models.LabExport{}, errors.Wrapf(err, "export lab %s", id)
+		}
 	}
 
-	if len(importResponse.Warnings) > 0 {
-		logging.Warn("Lab import completed with warnings", "warnings", importResponse.Warnings)
+	var query map[string]string
+	if opts.ExcludeConfigurations {
+		query = map[string]string{"exclude_configurations": "true"}
 	}
 
-	// Fetch the imported lab with full data
-	return s.GetByID(ctx, importResponse.ID, true)
+	data, err := s.apiClient.GetBytes(ctx, fmt.Sprintf("%s/%s", labURL(id), downloadAPI), query)
+	if err != nil {
+		return models.LabExport{}, errors.Wrapf(err, "export lab %s", id)
+	}
+
+	topology, err := models.ParseLabTopology(data)
+	if err != nil {
+		return models.LabExport{}, errors.Wrapf(err, "decode topology of lab %s", id)
+	}
+	return models.LabExport{YAML: string(data), Topology: *topology}, nil
+}
+
+// ValidateTopology validates the topology against the node and image
+// definitions of the controller before it is imported. All problems found are
+// returned as validation errors, the returned error is only set when the
+// definitions can't be retrieved. Use LabTopology.Validate with cached
+// definitions to avoid the API calls.
+func (s *LabService) ValidateTopology(ctx context.Context, topology *models.LabTopology) (errors.ValidationErrors, error) {
+	if s.NodeDefinition == nil || s.ImageDefinition == nil {
+		return nil, errors.Wrap(errors.ErrMissingRequired, "validate topology: node and image definition services")
+	}
+
+	nodeDefs, err := s.NodeDefinition.NodeDefinitions(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get node definitions")
+	}
+	imageDefs, err := s.ImageDefinition.ImageDefinitions(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get image definitions")
+	}
+	return topology.Validate(nodeDefs, imageDefs), nil
 }
 
 // HasConverged checks if all nodes in the lab have converged (are in BOOTED state)
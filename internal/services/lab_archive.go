@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"maps"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+// Archive packs the lab identified by `id` into an archive: the exported
+// topology, the node configurations, the console logs of the nodes, the
+// link conditions, the annotations and the packet captures of the links.
+// Failing to export the topology is an error, other parts which can't be
+// retrieved are listed as warnings in the manifest. Console logs require
+// the console service, packet captures the PCAP service. Close the archive
+// to remove the temporary files of the packet captures.
+//
+//	archive, err := client.Lab.Archive(ctx, labID, models.LabArchiveOptions{})
+//	defer archive.Close()
+//	err = archive.Write(f)
+func (s *LabService) Archive(ctx context.Context, id models.UUID, opts models.LabArchiveOptions) (*models.LabArchive, error) {
+	if s.Node == nil || s.Link == nil {
+		return nil, errors.Wrap(errors.ErrMissingRequired, "archive lab: node and link services")
+	}
+
+	export, err := s.Export(ctx, id, models.LabExportOptions{ExtractConfigurations: opts.ExtractConfigurations})
+	if err != nil {
+		return nil, errors.Wrapf(err, "archive lab %s", id)
+	}
+	archive := models.NewLabArchive(id, export.Topology.Lab.Title)
+	archive.Add(models.LabArchiveEntry{Name: models.LabArchiveTopologyFile, Kind: models.LabArchiveTopology}, []byte(export.YAML))
+	archiveConfigurations(archive, export.Topology.Nodes)
+
+	nodes, err := s.Node.GetNodesForLab(ctx, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "archive lab %s", id)
+	}
+	if !opts.ExcludeConsoleLogs && s.Console != nil {
+		s.archiveConsoleLogs(ctx, archive, nodes)
+	}
+
+	links, err := s.Link.GetLinksForLab(ctx, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "archive lab %s", id)
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].Label < links[j].Label })
+	s.archiveLinkConditions(ctx, archive, links)
+	s.archiveAnnotations(ctx, archive)
+	if !opts.ExcludePCAPs && s.PCAP != nil {
+		s.archivePCAPs(ctx, archive, links)
+	}
+
+	return archive, nil
+}
+
+// ImportArchive reads an archive written by LabArchive.Write and imports
+// its topology as a new lab. The archive is returned with the lab so that
+// the other files can be inspected, Close it to remove the temporary files
+// of the packet captures and console logs.
+func (s *LabService) ImportArchive(ctx context.Context, r io.Reader) (models.Lab, *models.LabArchive, error) {
+	archive, err := models.ReadLabArchive(r)
+	if err != nil {
+		return models.Lab{}, nil, errors.Wrap(err, "import lab archive")
+	}
+	topology, err := archive.Topology()
+	if err != nil {
+		return models.Lab{}, archive, errors.Wrap(err, "import lab archive")
+	}
+	lab, err := s.Import(ctx, topology)
+	if err != nil {
+		return models.Lab{}, archive, err
+	}
+	return lab, archive, nil
+}
+
+// archiveConfigurations adds the node configurations of the topology as
+// configs/{label}.cfg, named configurations as configs/{label}/{name}.
+func archiveConfigurations(archive *models.LabArchive, nodes []models.NodeTopology) {
+	for _, node := range nodes {
+		label := models.ArchiveFileName(node.Label)
+		entry := models.LabArchiveEntry{Kind: models.LabArchiveConfiguration, Label: node.Label}
+		if len(node.Configurations) > 0 {
+			for _, config := range node.Configurations {
+				entry.Name = path.Join("configs", label, models.ArchiveFileName(config.Name))
+				archive.Add(entry, []byte(config.Content))
+			}
+			continue
+		}
+		if len(node.Configuration) > 0 {
+			entry.Name = path.Join("configs", label+".cfg")
+			archive.Add(entry, []byte(node.Configuration))
+		}
+	}
+}
+
+// archiveConsoleLogs adds the console logs of all serial devices of the
+// nodes which have been started as consoles/{label}-{device}.log. Nodes
+// without console information are assumed to have a single console.
+func (s *LabService) archiveConsoleLogs(ctx context.Context, archive *models.LabArchive, nodes models.NodeMap) {
+	list := slices.Collect(maps.Values(nodes))
+	sort.Slice(list, func(i, j int) bool { return list[i].Label < list[j].Label })
+
+	for _, node := range list {
+		if node.State == models.NodeStateDefined {
+			continue
+		}
+		devices := []int{0}
+		if consoles := serialConsoles(node); len(consoles) > 0 {
+			devices = devices[:0]
+			for _, console := range consoles {
+				devices = append(devices, console.DeviceNumber)
+			}
+			slices.Sort(devices)
+			devices = slices.Compact(devices)
+		}
+		for _, device := range devices {
+			log, err := s.Console.Log(ctx, archive.Manifest.LabID, node.ID, device)
+			if err != nil {
+				archive.Warn("console log %d of node %s: %s", device, node.Label, err)
+				continue
+			}
+			archive.Add(models.LabArchiveEntry{
+				Name:   path.Join("consoles", models.ArchiveFileName(node.Label)+"-"+strconv.Itoa(device)+".log"),
+				Kind:   models.LabArchiveConsoleLog,
+				NodeID: node.ID,
+				Label:  node.Label,
+				Device: device,
+			}, []byte(strings.Join(log.Lines, "\n")+"\n"))
+		}
+	}
+}
+
+// archiveLinkConditions adds the conditions of the conditioned links as
+// conditions/{label}.json.
+func (s *LabService) archiveLinkConditions(ctx context.Context, archive *models.LabArchive, links []models.Link) {
+	for _, link := range links {
+		condition, err := s.Link.GetCondition(ctx, archive.Manifest.LabID, link.ID)
+		if err != nil {
+			archive.Warn("condition of link %s: %s", linkArchiveLabel(link), err)
+			continue
+		}
+		if condition.LinkConditionConfiguration == (models.LinkConditionConfiguration{}) {
+			continue
+		}
+		data, err := json.MarshalIndent(condition, "", "  ")
+		if err != nil {
+			archive.Warn("condition of link %s: %s", linkArchiveLabel(link), err)
+			continue
+		}
+		archive.Add(models.LabArchiveEntry{
+			Name:   path.Join("conditions", models.ArchiveFileName(linkArchiveLabel(link))+".json"),
+			Kind:   models.LabArchiveLinkCondition,
+			LinkID: link.ID,
+			Label:  link.Label,
+		}, data)
+	}
+}
+
+// archiveAnnotations adds the annotations and smart annotations as they
+// are returned by the controller, if the services are available.
+func (s *LabService) archiveAnnotations(ctx context.Context, archive *models.LabArchive) {
+	labID := archive.Manifest.LabID
+	add := func(name string, kind models.LabArchiveKind, list any, err error) {
+		if err == nil {
+			var data []byte
+			data, err = json.MarshalIndent(list, "", "  ")
+			if err == nil {
+				archive.Add(models.LabArchiveEntry{Name: name, Kind: kind}, data)
+				return
+			}
+		}
+		archive.Warn("%s: %s", kind, err)
+	}
+	if s.Annotation != nil {
+		list, err := s.Annotation.List(ctx, labID)
+		add("annotations.json", models.LabArchiveAnnotations, list, err)
+	}
+	if s.SmartAnnotation != nil {
+		list, err := s.SmartAnnotation.List(ctx, labID)
+		add("smart_annotations.json", models.LabArchiveSmartAnnotations, list, err)
+	}
+}
+
+// archivePCAPs adds the packet captures of the links which have a capture
+// as pcaps/{label}.pcap. They are streamed to temporary files, captures can
+// be large.
+func (s *LabService) archivePCAPs(ctx context.Context, archive *models.LabArchive, links []models.Link) {
+	for _, link := range links {
+		if len(link.PCAPkey) == 0 {
+			continue
+		}
+		_, err := archive.AddFrom(models.LabArchiveEntry{
+			Name:   path.Join("pcaps", models.ArchiveFileName(linkArchiveLabel(link))+".pcap"),
+			Kind:   models.LabArchivePCAP,
+			LinkID: link.ID,
+			Label:  link.Label,
+		}, func(w io.Writer) error {
+			_, err := s.PCAP.Download(ctx, archive.Manifest.LabID, link.ID, w)
+			return err
+		})
+		if err != nil {
+			archive.Warn("capture of link %s: %s", linkArchiveLabel(link), err)
+		}
+	}
+}
+
+func linkArchiveLabel(link models.Link) string {
+	if len(link.Label) > 0 {
+		return link.Label
+	}
+	return string(link.ID)
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rschmied/gocmlclient/internal/testutil"
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+func TestLabArchive(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	base := "https://mock/api/v0/labs/src"
+	httpmock.RegisterResponder("GET", base+"/download",
+		httpmock.NewStringResponder(200, `lab:
+  title: Failed Run
+nodes:
+  - id: n0
+    label: r1
+    node_definition: iosv
+    x: 0
+    y: 0
+    configuration: hostname r1
+  - id: n1
+    label: srv/1
+    node_definition: alpine
+    x: 100
+    y: 0
+    configuration:
+      - name: boot.sh
+        content: echo hi
+      - name: node.cfg
+        content: hostname srv
+links:
+  - id: l0
+    n1: n0
+    n2: n1
+    i1: i0
+    i2: i0
+    label: r1-srv
+`))
+	httpmock.RegisterResponder("GET", base+"/nodes",
+		httpmock.NewStringResponder(200, `[
+			{"id":"s-n1","label":"r1","state":"BOOTED","serial_devices":[{"console_key":"k1","device_number":0},{"console_key":"k2","device_number":1}]},
+			{"id":"s-n2","label":"srv/1","state":"DEFINED_ON_CORE"}
+		]`))
+	httpmock.RegisterResponder("GET", base+"/nodes/s-n1/consoles/0/log",
+		httpmock.NewStringResponder(200, `"boot\r\nr1>"`))
+	httpmock.RegisterResponder("GET", base+"/nodes/s-n1/consoles/1/log",
+		httpmock.NewStringResponder(404, `{"description": "Console not found"}`))
+	httpmock.RegisterResponder("GET", base+"/links",
+		httpmock.NewStringResponder(200, `[
+			{"id":"s-l1","lab_id":"src","label":"r1-srv","link_capture_key":"cap1"},
+			{"id":"s-l2","lab_id":"src","label":"r1-ext"}
+		]`))
+	httpmock.RegisterResponder("GET", base+"/links/s-l1/condition",
+		httpmock.NewStringResponder(200, `{"enabled": true, "latency": 100}`))
+	httpmock.RegisterResponder("GET", base+"/links/s-l2/condition",
+		httpmock.NewStringResponder(200, `{}`))
+	httpmock.RegisterResponder("GET", base+"/links/s-l1/capture/key",
+		httpmock.NewStringResponder(200, `"cap1"`))
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/pcap/cap1",
+		httpmock.NewBytesResponder(200, []byte{0xd4, 0xc3, 0xb2, 0xa1}))
+	httpmock.RegisterResponder("GET", base+"/annotations",
+		httpmock.NewStringResponder(200, `[]`))
+	httpmock.RegisterResponder("GET", base+"/smart_annotations",
+		httpmock.NewStringResponder(500, `{"description": "internal error"}`))
+
+	service := newCloneTestService(client)
+	service.Console = NewConsoleService(client)
+	service.PCAP = NewPCAPService(client)
+	ctx := context.Background()
+
+	archive, err := service.Archive(ctx, "src", models.LabArchiveOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer archive.Close() //nolint:errcheck
+	assert.Equal(t, models.UUID("src"), archive.Manifest.LabID)
+	assert.Equal(t, "Failed Run", archive.Manifest.Title)
+
+	var names []string
+	for _, entry := range archive.Manifest.Entries {
+		names = append(names, entry.Name)
+	}
+	assert.Equal(t, []string{
+		"topology.yaml",
+		"configs/r1.cfg",
+		"configs/srv_1/boot.sh",
+		"configs/srv_1/node.cfg",
+		"consoles/r1-0.log",
+		"conditions/r1-srv.json",
+		"annotations.json",
+		"pcaps/r1-srv.pcap",
+	}, names)
+	assert.Len(t, archive.Manifest.Warnings, 2)
+
+	data, _ := archive.File("consoles/r1-0.log")
+	assert.Equal(t, "boot\nr1>\n", string(data))
+	data, _ = archive.File("conditions/r1-srv.json")
+	assert.Contains(t, string(data), `"latency": 100`)
+	pcaps := archive.Entries(models.LabArchivePCAP)
+	if assert.Len(t, pcaps, 1) {
+		assert.Equal(t, models.UUID("s-l1"), pcaps[0].LinkID)
+		assert.Equal(t, 4, pcaps[0].Size)
+	}
+
+	// round trip and re-import
+	var buf bytes.Buffer
+	assert.NoError(t, archive.Write(&buf))
+
+	registerCloneLab("dst", "Failed Run", "d")
+	var imported string
+	httpmock.RegisterResponder("POST", "https://mock/api/v0/import",
+		func(req *http.Request) (*http.Response, error) {
+			b, _ := io.ReadAll(req.Body)
+			imported = string(b)
+			return httpmock.NewStringResponse(200, `{"id": "dst"}`), nil
+		})
+
+	lab, read, err := service.ImportArchive(ctx, &buf)
+	assert.NoError(t, err)
+	assert.Equal(t, models.UUID("dst"), lab.ID)
+	assert.Contains(t, imported, "title: Failed Run")
+	assert.Equal(t, archive.Manifest, read.Manifest)
+}
+
+func TestLabArchive_Exclude(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	base := "https://mock/api/v0/labs/src"
+	httpmock.RegisterResponder("GET", base+"/download",
+		httpmock.NewStringResponder(200, `{"lab": {"title": "Lab"}, "nodes": [], "links": []}`))
+	httpmock.RegisterResponder("GET", base+"/nodes",
+		httpmock.NewStringResponder(200, `[{"id":"s-n1","label":"r1","state":"BOOTED"}]`))
+	httpmock.RegisterResponder("GET", base+"/links",
+		httpmock.NewStringResponder(200, `[{"id":"s-l1","lab_id":"src","label":"l1","link_capture_key":"cap1"}]`))
+	httpmock.RegisterResponder("GET", base+"/links/s-l1/condition",
+		httpmock.NewStringResponder(200, `{}`))
+
+	service := NewLabService(client, nil, NewLinkService(client), nil, NewNodeService(client, false))
+	service.Console = NewConsoleService(client)
+	service.PCAP = NewPCAPService(client)
+
+	archive, err := service.Archive(context.Background(), "src", models.LabArchiveOptions{ExcludeConsoleLogs: true, ExcludePCAPs: true})
+	assert.NoError(t, err)
+	assert.Len(t, archive.Manifest.Entries, 1)
+	assert.Empty(t, archive.Manifest.Warnings)
+}
+
+func TestLabArchive_Errors(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/missing/download",
+		httpmock.NewStringResponder(404, `{"description": "Lab not found"}`))
+
+	_, err := NewLabService(client, nil, nil, nil, nil).Archive(context.Background(), "missing", models.LabArchiveOptions{})
+	assert.ErrorIs(t, err, errors.ErrMissingRequired)
+
+	service := newCloneTestService(client)
+	_, err = service.Archive(context.Background(), "missing", models.LabArchiveOptions{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "archive lab missing")
+
+	_, _, err = service.ImportArchive(context.Background(), bytes.NewReader([]byte("not an archive")))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "import lab archive")
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+// Clone creates a copy of the lab identified by `id` on the same controller.
+// See CloneTo for details.
+func (s *LabService) Clone(ctx context.Context, id models.UUID, opts models.LabCloneOptions) (models.LabClone, error) {
+	return s.CloneTo(ctx, id, s, opts)
+}
+
+// CloneTo creates a copy of the lab identified by `id` using the `target`
+// lab service, which can belong to a client of another controller. The copy
+// is created by exporting the lab and importing the topology via the target.
+// Annotations, smart annotations and link conditions are not taken from the
+// export, they are copied via their services when requested in `opts`.
+//
+// The result maps node, interface and link IDs of the source lab to the IDs
+// of the copy. If a step after the import fails, the result still holds the
+// lab that has been created so that the caller can remove it.
+func (s *LabService) CloneTo(ctx context.Context, id models.UUID, target *LabService, opts models.LabCloneOptions) (models.LabClone, error) {
+	if (opts.CopyAnnotations && (s.Annotation == nil || target.Annotation == nil)) ||
+		(opts.CopySmartAnnotations && (s.SmartAnnotation == nil || target.SmartAnnotation == nil)) {
+		return models.LabClone{}, errors.Wrap(errors.ErrMissingRequired, "clone lab: annotation services")
+	}
+
+	source, err := s.GetByID(ctx, id, true)
+	if err != nil {
+		return models.LabClone{}, errors.Wrapf(err, "clone lab %s", id)
+	}
+
+	export, err := s.Export(ctx, id, models.LabExportOptions{ExcludeConfigurations: opts.ExcludeConfigurations})
+	if err != nil {
+		return models.LabClone{}, errors.Wrapf(err, "clone lab %s", id)
+	}
+	topology, err := stripTopology(export.YAML)
+	if err != nil {
+		return models.LabClone{}, errors.Wrapf(err, "clone lab %s", id)
+	}
+
+	title := opts.Title
+	if len(title) == 0 {
+		title = source.Title
+	}
+	lab, imported, err := target.ImportWithOptions(ctx, strings.NewReader(topology), models.LabImportOptions{
+		Title:   title,
+		Shallow: true,
+	})
+	result := models.LabClone{Lab: lab, Warnings: imported.Warnings}
+	if err != nil {
+		if len(imported.ID) > 0 {
+			result.Lab.ID = imported.ID
+		}
+		return result, errors.Wrapf(err, "clone lab %s", id)
+	}
+
+	if len(opts.Owner) > 0 {
+		if _, err := target.Update(ctx, lab.ID, models.LabUpdateRequest{Owner: opts.Owner}); err != nil {
+			return result, errors.Wrapf(err, "set owner of lab %s", lab.ID)
+		}
+	}
+
+	if opts.CopyAnnotations {
+		if err := s.cloneAnnotations(ctx, id, target, lab.ID); err != nil {
+			return result, err
+		}
+	}
+
+	if opts.CopySmartAnnotations {
+		if err := s.cloneSmartAnnotations(ctx, id, target, lab.ID); err != nil {
+			return result, err
+		}
+	}
+
+	lab, err = target.GetByID(ctx, lab.ID, true)
+	if err != nil {
+		return result, err
+	}
+	result.Lab = lab
+	result.Nodes, result.Interfaces, result.Links = cloneIDMaps(&source, &lab)
+
+	if opts.CopyLinkConditions {
+		if err := s.cloneLinkConditions(ctx, id, target, lab.ID, result.Links); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// stripTopology removes annotations, smart annotations and link conditions
+// from the YAML topology. Only these fields are removed from the document
+// tree, other fields (including the ones which are not part of the typed
+// topology model), their order and comments are retained.
+func stripTopology(topology string) (string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(topology), &doc); err != nil {
+		return "", errors.Wrap(err, "decode topology")
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return "", errors.Wrap(errors.ErrInvalidInput, "decode topology: not a mapping")
+	}
+
+	root := doc.Content[0]
+	removeYAMLKey(root, "annotations")
+	removeYAMLKey(root, "smart_annotations")
+	if links := yamlValue(root, "links"); links != nil && links.Kind == yaml.SequenceNode {
+		for _, link := range links.Content {
+			removeYAMLKey(link, "conditioning")
+		}
+	}
+
+	var b strings.Builder
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return "", errors.Wrap(err, "encode topology")
+	}
+	if err := enc.Close(); err != nil {
+		return "", errors.Wrap(err, "encode topology")
+	}
+	return b.String(), nil
+}
+
+// yamlValue returns the value of `key` in the YAML mapping, nil if the key
+// does not exist.
+func yamlValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// removeYAMLKey removes `key` and its value from the YAML mapping.
+func removeYAMLKey(mapping *yaml.Node, key string) {
+	if mapping.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+func (s *LabService) cloneAnnotations(ctx context.Context, srcID models.UUID, target *LabService, dstID models.UUID) error {
+	annotations, err := s.Annotation.List(ctx, srcID)
+	if err != nil {
+		return errors.Wrapf(err, "get annotations of lab %s", srcID)
+	}
+	for _, annotation := range annotations {
+		if _, err := target.Annotation.Create(ctx, dstID, annotation.CreateRequest()); err != nil {
+			return errors.Wrapf(err, "copy annotation %s", annotation.ID())
+		}
+	}
+	return nil
+}
+
+func (s *LabService) cloneSmartAnnotations(ctx context.Context, srcID models.UUID, target *LabService, dstID models.UUID) error {
+	srcList, err := s.SmartAnnotation.List(ctx, srcID)
+	if err != nil {
+		return errors.Wrapf(err, "get smart annotations of lab %s", srcID)
+	}
+	// smart annotations are created by the controller for every node tag, the
+	// copy only needs the settings of the source annotation with the same tag
+	dstList, err := target.SmartAnnotation.List(ctx, dstID)
+	if err != nil {
+		return errors.Wrapf(err, "get smart annotations of lab %s", dstID)
+	}
+	dstByTag := make(map[string]models.UUID, len(dstList))
+	for _, sa := range dstList {
+		if sa.Tag != nil {
+			dstByTag[*sa.Tag] = sa.ID
+		}
+	}
+	for _, sa := range srcList {
+		if sa.Tag == nil {
+			continue
+		}
+		dstSA, found := dstByTag[*sa.Tag]
+		if !found {
+			continue
+		}
+		if _, err := target.SmartAnnotation.Update(ctx, dstID, dstSA, sa.UpdateRequest()); err != nil {
+			return errors.Wrapf(err, "copy smart annotation %s", sa.ID)
+		}
+	}
+	return nil
+}
+
+func (s *LabService) cloneLinkConditions(ctx context.Context, srcID models.UUID, target *LabService, dstID models.UUID, links map[models.UUID]models.UUID) error {
+	for srcLink, dstLink := range links {
+		condition, err := s.Link.GetCondition(ctx, srcID, srcLink)
+		if err != nil {
+			return errors.Wrapf(err, "get condition of link %s", srcLink)
+		}
+		if condition.LinkConditionConfiguration == (models.LinkConditionConfiguration{}) {
+			continue
+		}
+		if _, err := target.Link.SetCondition(ctx, dstID, dstLink, &condition.LinkConditionConfiguration); err != nil {
+			return errors.Wrapf(err, "copy condition of link %s", srcLink)
+		}
+	}
+	return nil
+}
+
+// cloneIDMaps matches the elements of the copy with the elements of the
+// source lab. Nodes are matched by their label and position (which the
+// import retains), interfaces by their label within matched nodes and links
+// by their matched interfaces.
+func cloneIDMaps(source, clone *models.Lab) (nodes, ifaces, links map[models.UUID]models.UUID) {
+	nodes = make(map[models.UUID]models.UUID)
+	ifaces = make(map[models.UUID]models.UUID)
+	links = make(map[models.UUID]models.UUID)
+
+	nodeKey := func(n *models.Node) string {
+		return fmt.Sprintf("%s|%d|%d", n.Label, n.X, n.Y)
+	}
+	cloneNodes := make(map[string]*models.Node, len(clone.Nodes))
+	for _, node := range clone.Nodes {
+		cloneNodes[nodeKey(node)] = node
+	}
+
+	for _, node := range source.Nodes {
+		cloneNode, found := cloneNodes[nodeKey(node)]
+		if !found {
+			continue
+		}
+		nodes[node.ID] = cloneNode.ID
+
+		cloneIfaces := make(map[string]models.UUID, len(cloneNode.Interfaces))
+		for _, iface := range cloneNode.Interfaces {
+			cloneIfaces[iface.Label] = iface.ID
+		}
+		for _, iface := range node.Interfaces {
+			if cloneIface, found := cloneIfaces[iface.Label]; found {
+				ifaces[iface.ID] = cloneIface
+			}
+		}
+	}
+
+	cloneLinks := make(map[[2]models.UUID]models.UUID, len(clone.Links))
+	for _, link := range clone.Links {
+		cloneLinks[[2]models.UUID{link.SrcID, link.DstID}] = link.ID
+		cloneLinks[[2]models.UUID{link.DstID, link.SrcID}] = link.ID
+	}
+	for _, link := range source.Links {
+		if cloneLink, found := cloneLinks[[2]models.UUID{ifaces[link.SrcID], ifaces[link.DstID]}]; found {
+			links[link.ID] = cloneLink
+		}
+	}
+
+	return nodes, ifaces, links
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rschmied/gocmlclient/internal/api"
+	"github.com/rschmied/gocmlclient/internal/testutil"
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+func newCloneTestService(client *api.Client) *LabService {
+	nodeService := NewNodeService(client, false)
+	interfaceService := NewInterfaceService(client)
+	linkService := NewLinkService(client)
+	linkService.Interface = interfaceService
+	linkService.Node = nodeService
+	userService := NewUserService(client, NewGroupService(client))
+
+	service := NewLabService(client, interfaceService, linkService, userService, nodeService)
+	service.Annotation = NewAnnotationService(client)
+	service.SmartAnnotation = NewSmartAnnotationService(client)
+	return service
+}
+
+// registerCloneLab registers the responders for a deep fetch of a lab with
+// two nodes which are connected by one link.
+func registerCloneLab(id, title, prefix string) {
+	base := "https://mock/api/v0/labs/" + id
+	httpmock.RegisterResponder("GET", base,
+		httpmock.NewStringResponder(200, `{"id":"`+id+`","lab_title":"`+title+`","state":"DEFINED_ON_CORE"}`))
+	httpmock.RegisterResponder("GET", base+"/nodes",
+		httpmock.NewStringResponder(200, `[
+			{"id":"`+prefix+`-n1","label":"r1","x":0,"y":0,"node_definition":"iosv","state":"DEFINED_ON_CORE"},
+			{"id":"`+prefix+`-n2","label":"r2","x":100,"y":0,"node_definition":"iosv","state":"DEFINED_ON_CORE"}
+		]`))
+	httpmock.RegisterResponder("GET", base+"/nodes/"+prefix+"-n1/interfaces",
+		httpmock.NewStringResponder(200, `[{"id":"`+prefix+`-i1","label":"Gi0/0","slot":0,"type":"physical","node":"`+prefix+`-n1"}]`))
+	httpmock.RegisterResponder("GET", base+"/nodes/"+prefix+"-n2/interfaces",
+		httpmock.NewStringResponder(200, `[{"id":"`+prefix+`-i2","label":"Gi0/0","slot":0,"type":"physical","node":"`+prefix+`-n2"}]`))
+	httpmock.RegisterResponder("GET", base+"/links",
+		httpmock.NewStringResponder(200, `[{"id":"`+prefix+`-l1","lab_id":"`+id+`","interface_a":"`+prefix+`-i2","interface_b":"`+prefix+`-i1","node_a":"`+prefix+`-n2","node_b":"`+prefix+`-n1"}]`))
+	httpmock.RegisterResponder("GET", base+"/layer3_addresses",
+		httpmock.NewStringResponder(200, `{}`))
+}
+
+// cloneExport is the topology downloaded from the source lab
+const cloneExport = `annotations:
+  - type: text
+    x1: 1
+    y1: 2
+smart_annotations:
+  - tag: core
+nodes:
+  - id: n0
+    label: r1
+    node_definition: iosv
+    x: 0
+    y: 0
+    tags:
+      - core
+    pinned_compute_id: c1 # unknown to the typed model
+    interfaces:
+      - id: i0
+        label: Gi0/0
+        slot: 0
+        type: physical
+  - id: n1
+    label: r2
+    node_definition: iosv
+    x: 100
+    y: 0
+    tags:
+      - core
+    interfaces:
+      - id: i0
+        label: Gi0/0
+        slot: 0
+        type: physical
+links:
+  - id: l0
+    n1: n0
+    n2: n1
+    i1: i0
+    i2: i0
+    conditioning:
+      delay: 10
+    label: r1-r2
+lab:
+  title: Source Lab
+  description: ''
+  notes: ''
+  version: 0.3.0
+`
+
+// cloneImport is cloneExport without annotations and link conditions, the
+// rest is unchanged
+const cloneImport = `nodes:
+  - id: n0
+    label: r1
+    node_definition: iosv
+    x: 0
+    y: 0
+    tags:
+      - core
+    pinned_compute_id: c1 # unknown to the typed model
+    interfaces:
+      - id: i0
+        label: Gi0/0
+        slot: 0
+        type: physical
+  - id: n1
+    label: r2
+    node_definition: iosv
+    x: 100
+    y: 0
+    tags:
+      - core
+    interfaces:
+      - id: i0
+        label: Gi0/0
+        slot: 0
+        type: physical
+links:
+  - id: l0
+    n1: n0
+    n2: n1
+    i1: i0
+    i2: i0
+    label: r1-r2
+lab:
+  title: Source Lab
+  description: ''
+  notes: ''
+  version: 0.3.0
+`
+
+func TestLabClone(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	registerCloneLab("src", "Source Lab", "s")
+	registerCloneLab("dst", "Copy", "d")
+
+	httpmock.RegisterResponderWithQuery("GET", "https://mock/api/v0/labs/src/download",
+		map[string]string{"exclude_configurations": "true"},
+		httpmock.NewStringResponder(200, cloneExport))
+
+	var imported string
+	httpmock.RegisterResponderWithQuery("POST", "https://mock/api/v0/import",
+		map[string]string{"title": "Copy"},
+		func(req *http.Request) (*http.Response, error) {
+			b, _ := io.ReadAll(req.Body)
+			imported = string(b)
+			return httpmock.NewStringResponse(200, `{"id": "dst", "warnings": ["minor issue"]}`), nil
+		})
+	httpmock.RegisterResponder("PATCH", "https://mock/api/v0/labs/dst",
+		httpmock.NewStringResponder(200, `{"id":"dst","lab_title":"Copy","owner":"user-2"}`))
+
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/src/annotations",
+		httpmock.NewStringResponder(200, `[{"id":"a1","type":"text","border_color":"#000","border_style":"","color":"#fff","thickness":1,"x1":1,"y1":2,"z_index":0,"rotation":0,"text_bold":false,"text_content":"hi","text_font":"sans","text_italic":false,"text_size":12,"text_unit":"px"}]`))
+	httpmock.RegisterResponder("POST", "https://mock/api/v0/labs/dst/annotations",
+		httpmock.NewStringResponder(200, `{"id":"a2","type":"text","border_color":"#000","border_style":"","color":"#fff","thickness":1,"x1":1,"y1":2,"z_index":0,"rotation":0,"text_bold":false,"text_content":"hi","text_font":"sans","text_italic":false,"text_size":12,"text_unit":"px"}`))
+
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/src/smart_annotations",
+		httpmock.NewStringResponder(200, `[{"id":"sa1","tag":"core","is_on":false,"label":"Core","fill_color":"#ff0000"}]`))
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/dst/smart_annotations",
+		httpmock.NewStringResponder(200, `[{"id":"sa2","tag":"core","is_on":true,"label":"core"}]`))
+	httpmock.RegisterResponder("PATCH", "https://mock/api/v0/labs/dst/smart_annotations/sa2",
+		httpmock.NewStringResponder(200, `{"id":"sa2","tag":"core","is_on":false,"label":"Core","fill_color":"#ff0000"}`))
+
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/src/links/s-l1/condition",
+		httpmock.NewStringResponder(200, `{"enabled":true,"delay":10}`))
+	httpmock.RegisterResponder("PATCH", "https://mock/api/v0/labs/dst/links/d-l1/condition",
+		httpmock.NewStringResponder(200, `{"enabled":true,"delay":10}`))
+
+	service := newCloneTestService(client)
+	ctx := context.Background()
+
+	clone, err := service.Clone(ctx, "src", models.LabCloneOptions{
+		Title:                 "Copy",
+		Owner:                 "user-2",
+		ExcludeConfigurations: true,
+		CopyAnnotations:       true,
+		CopySmartAnnotations:  true,
+		CopyLinkConditions:    true,
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, models.UUID("dst"), clone.Lab.ID)
+	assert.Len(t, clone.Lab.Nodes, 2)
+	assert.Equal(t, []string{"minor issue"}, clone.Warnings)
+	assert.Equal(t, map[models.UUID]models.UUID{"s-n1": "d-n1", "s-n2": "d-n2"}, clone.Nodes)
+	assert.Equal(t, map[models.UUID]models.UUID{"s-i1": "d-i1", "s-i2": "d-i2"}, clone.Interfaces)
+	assert.Equal(t, map[models.UUID]models.UUID{"s-l1": "d-l1"}, clone.Links)
+
+	// annotations and link conditions are not part of the imported topology,
+	// fields unknown to the typed topology are retained
+	assert.Equal(t, cloneImport, imported)
+
+	info := httpmock.GetCallCountInfo()
+	assert.Equal(t, 1, info["PATCH https://mock/api/v0/labs/dst"])
+	assert.Equal(t, 1, info["POST https://mock/api/v0/labs/dst/annotations"])
+	assert.Equal(t, 1, info["PATCH https://mock/api/v0/labs/dst/smart_annotations/sa2"])
+	assert.Equal(t, 1, info["PATCH https://mock/api/v0/labs/dst/links/d-l1/condition"])
+}
+
+func TestLabClone_ImportError(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	registerCloneLab("src", "Source Lab", "s")
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/src/download",
+		httpmock.NewStringResponder(200, `{"lab": {"title": "Source Lab"}, "nodes": [], "links": []}`))
+	httpmock.RegisterResponder("POST", "https://mock/api/v0/import",
+		httpmock.NewStringResponder(400, `{"description": "invalid topology"}`))
+
+	service := newCloneTestService(client)
+	_, err := service.Clone(context.Background(), "src", models.LabCloneOptions{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "clone lab src")
+}
+
+func TestLabClone_MissingServices(t *testing.T) {
+	service := NewLabService(nil, nil, nil, nil, nil)
+	_, err := service.Clone(context.Background(), "src", models.LabCloneOptions{CopyAnnotations: true})
+	assert.ErrorIs(t, err, errors.ErrMissingRequired)
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+// Destroy tears down the lab identified by `id`: running nodes are stopped,
+// then the lab is wiped and deleted. After stop and wipe, the node states are
+// polled as controlled by `opts.Wait` until all nodes have reached the
+// expected state. Steps which are not needed for the current state are
+// skipped, a lab which does not exist (anymore) is not an error.
+//
+// Errors are returned as *models.LabDestroyError with the failed step and,
+// for the wait steps, the nodes which did not reach the expected state.
+func (s *LabService) Destroy(ctx context.Context, id models.UUID, opts models.LabDestroyOptions) error {
+	if s.Node == nil {
+		return errors.Wrap(errors.ErrMissingRequired, "destroy lab: node service")
+	}
+	fail := func(step models.LabDestroyStep, stuck models.NodeMap, err error) error {
+		return &models.LabDestroyError{LabID: id, Step: step, Stuck: stuck, Err: err}
+	}
+
+	lab := models.Lab{ID: id}
+	refresh := func(ctx context.Context) error {
+		nodes, err := s.Node.GetNodesForLab(ctx, id)
+		if err != nil {
+			return err
+		}
+		lab.Nodes = nodes
+		return nil
+	}
+
+	if err := refresh(ctx); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fail(models.LabDestroyStepFetch, nil, err)
+	}
+
+	if lab.Running() {
+		if err := s.Stop(ctx, id); err != nil {
+			return fail(models.LabDestroyStepStop, nil, err)
+		}
+		err := waitFor(ctx, opts.Wait, func(ctx context.Context) (bool, error) {
+			if err := refresh(ctx); err != nil {
+				return false, err
+			}
+			return !lab.Running(), nil
+		})
+		if err != nil {
+			return fail(models.LabDestroyStepWaitStopped, stuckNodes(lab.Nodes, models.NodeStateStopped, models.NodeStateDefined), err)
+		}
+	}
+
+	if len(lab.Nodes) > 0 && !lab.CanBeWiped() {
+		if err := s.Wipe(ctx, id); err != nil {
+			return fail(models.LabDestroyStepWipe, nil, err)
+		}
+		err := waitFor(ctx, opts.Wait, func(ctx context.Context) (bool, error) {
+			if err := refresh(ctx); err != nil {
+				return false, err
+			}
+			return lab.CanBeWiped(), nil
+		})
+		if err != nil {
+			return fail(models.LabDestroyStepWaitWiped, stuckNodes(lab.Nodes, models.NodeStateDefined), err)
+		}
+	}
+
+	if err := s.Delete(ctx, id); err != nil && !errors.IsNotFound(err) {
+		return fail(models.LabDestroyStepDelete, nil, err)
+	}
+	return nil
+}
+
+// stuckNodes returns the nodes which are in none of the given states.
+func stuckNodes(nodes models.NodeMap, states ...models.NodeState) models.NodeMap {
+	stuck := make(models.NodeMap)
+	for id, node := range nodes {
+		expected := false
+		for _, state := range states {
+			expected = expected || node.State == state
+		}
+		if !expected {
+			stuck[id] = node
+		}
+	}
+	return stuck
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rschmied/gocmlclient/internal/testutil"
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+var destroyWait = models.WaitOptions{Interval: time.Millisecond, Timeout: time.Second}
+
+// registerDestroyNodes registers a node list responder which returns the
+// given node states, one entry per poll. The last entry is repeated.
+func registerDestroyNodes(id string, states ...string) {
+	polls := 0
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/"+id+"/nodes",
+		func(req *http.Request) (*http.Response, error) {
+			state := states[min(polls, len(states)-1)]
+			polls++
+			return httpmock.NewStringResponse(200, `[
+				{"id":"n1","label":"r1","state":"`+state+`"},
+				{"id":"n2","label":"r2","state":"DEFINED_ON_CORE"}
+			]`), nil
+		})
+}
+
+func registerDestroyActions(id string) {
+	base := "https://mock/api/v0/labs/" + id
+	httpmock.RegisterResponder("PUT", base+"/stop", httpmock.NewStringResponder(204, ``))
+	httpmock.RegisterResponder("PUT", base+"/wipe", httpmock.NewStringResponder(204, ``))
+	httpmock.RegisterResponder("DELETE", base, httpmock.NewStringResponder(204, ``))
+}
+
+func TestLabDestroy(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	tests := []struct {
+		name   string
+		states []string
+		stop   int
+		wipe   int
+	}{
+		{"running", []string{"BOOTED", "BOOTED", "STOPPED", "STOPPED", "DEFINED_ON_CORE"}, 1, 1},
+		{"stopped", []string{"STOPPED", "STOPPED", "DEFINED_ON_CORE"}, 0, 1},
+		{"wiped", []string{"DEFINED_ON_CORE"}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, cleanup := testutil.NewAPIClient(t)
+			defer cleanup()
+
+			registerDestroyNodes("lab1", tt.states...)
+			registerDestroyActions("lab1")
+
+			service := NewLabService(client, nil, nil, nil, NewNodeService(client, false))
+			err := service.Destroy(context.Background(), "lab1", models.LabDestroyOptions{Wait: destroyWait})
+			assert.NoError(t, err)
+
+			calls := httpmock.GetCallCountInfo()
+			assert.Equal(t, tt.stop, calls["PUT https://mock/api/v0/labs/lab1/stop"])
+			assert.Equal(t, tt.wipe, calls["PUT https://mock/api/v0/labs/lab1/wipe"])
+			assert.Equal(t, 1, calls["DELETE https://mock/api/v0/labs/lab1"])
+		})
+	}
+}
+
+func TestLabDestroy_NotFound(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/gone/nodes",
+		httpmock.NewStringResponder(404, `{"description": "Lab not found"}`))
+
+	service := NewLabService(client, nil, nil, nil, NewNodeService(client, false))
+	assert.NoError(t, service.Destroy(context.Background(), "gone", models.LabDestroyOptions{}))
+}
+
+func TestLabDestroy_Stuck(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	registerDestroyNodes("lab1", "BOOTED")
+	registerDestroyActions("lab1")
+
+	service := NewLabService(client, nil, nil, nil, NewNodeService(client, false))
+	err := service.Destroy(context.Background(), "lab1", models.LabDestroyOptions{
+		Wait: models.WaitOptions{Interval: time.Millisecond, Timeout: 20 * time.Millisecond},
+	})
+	assert.ErrorIs(t, err, errors.ErrTimeout)
+
+	var derr *models.LabDestroyError
+	if assert.ErrorAs(t, err, &derr) {
+		assert.Equal(t, models.LabDestroyStepWaitStopped, derr.Step)
+		assert.Len(t, derr.Stuck, 1)
+		assert.Contains(t, derr.Stuck, models.UUID("n1"))
+		assert.Contains(t, err.Error(), "stuck nodes: r1 (BOOTED)")
+	}
+	assert.Equal(t, 0, httpmock.GetCallCountInfo()["DELETE https://mock/api/v0/labs/lab1"])
+}
+
+func TestLabDestroy_StepError(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	registerDestroyNodes("lab1", "STOPPED")
+	httpmock.RegisterResponder("PUT", "https://mock/api/v0/labs/lab1/wipe",
+		httpmock.NewStringResponder(500, `{"description": "boom"}`))
+
+	service := NewLabService(client, nil, nil, nil, NewNodeService(client, false))
+	err := service.Destroy(context.Background(), "lab1", models.LabDestroyOptions{Wait: destroyWait})
+
+	var derr *models.LabDestroyError
+	if assert.ErrorAs(t, err, &derr) {
+		assert.Equal(t, models.LabDestroyStepWipe, derr.Step)
+		assert.Empty(t, derr.Stuck)
+	}
+}
+
+func TestLabDestroy_MissingServices(t *testing.T) {
+	service := NewLabService(nil, nil, nil, nil, nil)
+	err := service.Destroy(context.Background(), "lab1", models.LabDestroyOptions{})
+	assert.ErrorIs(t, err, errors.ErrMissingRequired)
+}
+
+func TestWaitFor(t *testing.T) {
+	polls := 0
+	err := waitFor(context.Background(), models.WaitOptions{Interval: time.Millisecond, Backoff: 2, MaxInterval: 4 * time.Millisecond},
+		func(ctx context.Context) (bool, error) {
+			polls++
+			return polls == 4, nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, polls)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = waitFor(ctx, models.WaitOptions{}, func(ctx context.Context) (bool, error) { return false, nil })
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, errors.ErrTimeout)
+}
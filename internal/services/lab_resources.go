@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+// NodeResources returns the effective resources of all nodes of the lab
+// identified by `id`, keyed by node ID. Each value reports whether it is set
+// on the node or inherited from the image or node definition. Use
+// models.ResourceResolver with cached definitions to avoid fetching the
+// definitions for every lab.
+func (s *LabService) NodeResources(ctx context.Context, id models.UUID) (models.NodeResourcesMap, error) {
+	if s.Node == nil || s.NodeDefinition == nil || s.ImageDefinition == nil {
+		return nil, errors.Wrap(errors.ErrMissingRequired, "node resources: node, node and image definition services")
+	}
+
+	nodeDefs, err := s.NodeDefinition.NodeDefinitions(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get node definitions")
+	}
+	imageDefs, err := s.ImageDefinition.ImageDefinitions(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get image definitions")
+	}
+	nodes, err := s.Node.GetNodesForLab(ctx, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "node resources of lab %s", id)
+	}
+	return models.NewResourceResolver(nodeDefs, imageDefs).ResolveAll(nodes)
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rschmied/gocmlclient/internal/testutil"
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+func TestLabNodeResources(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/simplified_node_definitions",
+		httpmock.NewStringResponder(200, `[{
+			"id": "alpine",
+			"sim": {"linux_native": {"ram": 512, "cpus": 1, "cpu_limit": 100, "data_volume": 0, "boot_disk_size": 0}}
+		}]`))
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/image_definitions",
+		httpmock.NewStringResponder(200, `[{"id": "alpine-3-21", "node_definition_id": "alpine", "label": "Alpine", "ram": 1024}]`))
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab1/nodes",
+		httpmock.NewStringResponder(200, `[
+			{"id": "n1", "lab_id": "lab1", "label": "a1", "node_definition": "alpine", "image_definition": "alpine-3-21"},
+			{"id": "n2", "lab_id": "lab1", "label": "a2", "node_definition": "alpine", "cpus": 4, "ram": 256}
+		]`))
+
+	service := NewLabService(client, nil, nil, nil, NewNodeService(client, false))
+	ctx := context.Background()
+
+	_, err := service.NodeResources(ctx, "lab1")
+	assert.ErrorIs(t, err, errors.ErrMissingRequired)
+
+	service.NodeDefinition = NewNodeDefinitionService(client)
+	service.ImageDefinition = NewImageDefinitionService(client)
+	resources, err := service.NodeResources(ctx, "lab1")
+	assert.NoError(t, err)
+	if assert.Len(t, resources, 2) {
+		assert.Equal(t, models.ResourceValue{Value: 1024, Source: models.ResourceSourceImageDefinition}, resources["n1"].RAM)
+		assert.Equal(t, models.ResourceValue{Value: 1, Source: models.ResourceSourceNodeDefinition}, resources["n1"].CPUs)
+		assert.Equal(t, models.ResourceValue{Value: 256, Source: models.ResourceSourceNode}, resources["n2"].RAM)
+		assert.Equal(t, models.ResourceValue{Value: 4, Source: models.ResourceSourceNode}, resources["n2"].CPUs)
+		assert.False(t, resources["n2"].DataVolume.IsSet())
+	}
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+// labStage is a group of nodes which are started together.
+type labStage struct {
+	name  string
+	nodes []*models.Node
+}
+
+// StartStaged starts the lab identified by `id` stage by stage from the
+// client side, for controllers without node staging support or for custom
+// orderings. The nodes of a stage are started individually and the stage
+// is waited for until all of its nodes are BOOTED before the next stage is
+// started. Nodes which are already running are not started again.
+//
+// Each failed stage is reported as *models.LabStartStageError. Without
+// AbortOnFailure, the remaining stages are still started and all stage
+// errors are returned joined.
+func (s *LabService) StartStaged(ctx context.Context, id models.UUID, opts models.LabStartOptions) error {
+	if s.Node == nil {
+		return errors.Wrap(errors.ErrMissingRequired, "start lab: node service")
+	}
+
+	staging := opts.Staging
+	if staging == nil {
+		lab, err := s.GetByID(ctx, id, false)
+		if err != nil {
+			return errors.Wrapf(err, "start lab %s", id)
+		}
+		staging = lab.NodeStaging
+		if staging == nil {
+			staging = &models.NodeStaging{StartRemaining: true}
+		}
+	}
+
+	nodes, err := s.Node.GetNodesForLab(ctx, id)
+	if err != nil {
+		return errors.Wrapf(err, "start lab %s", id)
+	}
+
+	var errs []error
+	for _, stage := range labStages(nodes, opts.Tags, staging.StartRemaining) {
+		if err := s.startStage(ctx, id, stage, opts); err != nil {
+			errs = append(errs, err)
+			if staging.AbortOnFailure || ctx.Err() != nil {
+				break
+			}
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+func (s *LabService) startStage(ctx context.Context, labID models.UUID, stage labStage, opts models.LabStartOptions) error {
+	ids := make(map[models.UUID]bool, len(stage.nodes))
+	current := make(models.NodeMap, len(stage.nodes))
+	for _, node := range stage.nodes {
+		ids[node.ID] = true
+		current[node.ID] = node
+	}
+	fail := func(err error) error {
+		result := &models.LabStartStageError{Stage: stage.name, Err: err}
+		for _, node := range nodeProgress(current) {
+			if node.State != models.NodeStateBooted {
+				result.Nodes = append(result.Nodes, node)
+			}
+		}
+		return result
+	}
+
+	for _, node := range stage.nodes {
+		if node.State == models.NodeStateDefined || node.State == models.NodeStateStopped {
+			if err := s.Node.Start(ctx, labID, node.ID); err != nil {
+				return fail(errors.Wrapf(err, "start node %s", node.Label))
+			}
+		}
+	}
+
+	err := waitFor(ctx, opts.Wait, func(ctx context.Context) (bool, error) {
+		nodes, err := s.Node.GetNodesForLab(ctx, labID)
+		if err != nil {
+			return false, err
+		}
+		for nodeID, node := range nodes {
+			if ids[nodeID] {
+				current[nodeID] = node
+			}
+		}
+		if opts.Progress != nil {
+			opts.Progress(stage.name, nodeProgress(current))
+		}
+		lab := models.Lab{Nodes: current}
+		return lab.Booted(), nil
+	})
+	if err != nil {
+		return fail(err)
+	}
+	return nil
+}
+
+// labStages groups the nodes into stages, either by the given tags in order
+// or by priority, highest first. Nodes in no group form the last stage if
+// `remaining` is set and are not started otherwise.
+func labStages(nodes models.NodeMap, tags []string, remaining bool) []labStage {
+	var stages []labStage
+	var rest []*models.Node
+
+	if len(tags) > 0 {
+		stages = make([]labStage, len(tags))
+		for i, tag := range tags {
+			stages[i].name = "tag " + tag
+		}
+		for _, node := range nodes {
+			idx := slices.IndexFunc(tags, func(tag string) bool {
+				return slices.Contains(node.Tags, tag)
+			})
+			if idx < 0 {
+				rest = append(rest, node)
+				continue
+			}
+			stages[idx].nodes = append(stages[idx].nodes, node)
+		}
+	} else {
+		byPriority := make(map[int][]*models.Node)
+		for _, node := range nodes {
+			if node.Priority == nil {
+				rest = append(rest, node)
+				continue
+			}
+			byPriority[*node.Priority] = append(byPriority[*node.Priority], node)
+		}
+		priorities := make([]int, 0, len(byPriority))
+		for priority := range byPriority {
+			priorities = append(priorities, priority)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(priorities)))
+		for _, priority := range priorities {
+			stages = append(stages, labStage{
+				name:  fmt.Sprintf("priority %d", priority),
+				nodes: byPriority[priority],
+			})
+		}
+	}
+	if remaining {
+		stages = append(stages, labStage{name: "remaining", nodes: rest})
+	}
+
+	// skip empty stages, sort nodes by label for a stable start order
+	result := stages[:0]
+	for _, stage := range stages {
+		if len(stage.nodes) == 0 {
+			continue
+		}
+		sort.Slice(stage.nodes, func(i, j int) bool {
+			return stage.nodes[i].Label < stage.nodes[j].Label
+		})
+		result = append(result, stage)
+	}
+	return result
+}
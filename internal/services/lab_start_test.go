@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rschmied/gocmlclient/internal/testutil"
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+// fakeStagedLab simulates the nodes of a lab: a started node is BOOTED at
+// the next poll, unless it is listed in `stuck`.
+type fakeStagedLab struct {
+	mu      sync.Mutex
+	nodes   []string // id:label:priority:tag
+	states  map[string]string
+	stuck   map[string]bool
+	started []string
+}
+
+func (f *fakeStagedLab) register() {
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab1/nodes",
+		func(req *http.Request) (*http.Response, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			var items []string
+			for _, spec := range f.nodes {
+				parts := strings.Split(spec, ":")
+				id := parts[0]
+				state := f.states[id]
+				if state == "" {
+					state = "DEFINED_ON_CORE"
+				}
+				if state == "STARTED" && !f.stuck[id] {
+					f.states[id] = "BOOTED"
+				}
+				priority := ""
+				if len(parts[2]) > 0 {
+					priority = `,"priority":` + parts[2]
+				}
+				items = append(items, fmt.Sprintf(`{"id":%q,"label":%q,"state":%q,"tags":[%q]%s}`,
+					id, parts[1], state, parts[3], priority))
+			}
+			return httpmock.NewStringResponse(200, "["+strings.Join(items, ",")+"]"), nil
+		})
+	httpmock.RegisterResponder("PUT", `=~^https://mock/api/v0/labs/lab1/nodes/([^/]+)/state/start\z`,
+		func(req *http.Request) (*http.Response, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			id := httpmock.MustGetSubmatch(req, 1)
+			f.started = append(f.started, id)
+			f.states[id] = "STARTED"
+			return httpmock.NewStringResponse(204, ""), nil
+		})
+}
+
+func newFakeStagedLab() *fakeStagedLab {
+	return &fakeStagedLab{
+		nodes: []string{
+			"n1:rr1:10:rr",
+			"n2:core1:5:core",
+			"n3:edge1::edge",
+			"n4:rr2:10:rr",
+		},
+		states: map[string]string{},
+		stuck:  map[string]bool{},
+	}
+}
+
+var stagedWait = models.WaitOptions{Interval: time.Millisecond, Timeout: time.Second}
+
+func TestLabStartStaged_Priority(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	lab := newFakeStagedLab()
+	lab.register()
+
+	stages := map[string]bool{}
+	service := NewLabService(client, nil, nil, nil, NewNodeService(client, false))
+	err := service.StartStaged(context.Background(), "lab1", models.LabStartOptions{
+		Staging:  &models.NodeStaging{StartRemaining: true},
+		Wait:     stagedWait,
+		Progress: func(stage string, nodes []models.NodeProgress) { stages[stage] = true },
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"n1", "n4", "n2", "n3"}, lab.started)
+	assert.Equal(t, map[string]bool{"priority 10": true, "priority 5": true, "remaining": true}, stages)
+}
+
+func TestLabStartStaged_Tags(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	lab := newFakeStagedLab()
+	lab.states["n2"] = "BOOTED"
+	lab.register()
+	// staging settings are taken from the lab
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab1",
+		httpmock.NewStringResponder(200, `{"id":"lab1","node_staging":{"enabled":true,"start_remaining":false,"abort_on_failure":false}}`))
+
+	service := NewLabService(client, nil, nil, nil, NewNodeService(client, false))
+	err := service.StartStaged(context.Background(), "lab1", models.LabStartOptions{
+		Tags: []string{"edge", "core"},
+		Wait: stagedWait,
+	})
+	assert.NoError(t, err)
+	// core1 is running already, rr nodes are in no stage
+	assert.Equal(t, []string{"n3"}, lab.started)
+}
+
+func TestLabStartStaged_Failure(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	tests := []struct {
+		name    string
+		abort   bool
+		started []string
+	}{
+		{"abort", true, []string{"n1", "n4"}},
+		{"continue", false, []string{"n1", "n4", "n2", "n3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, cleanup := testutil.NewAPIClient(t)
+			defer cleanup()
+
+			lab := newFakeStagedLab()
+			lab.stuck["n4"] = true
+			lab.register()
+
+			service := NewLabService(client, nil, nil, nil, NewNodeService(client, false))
+			err := service.StartStaged(context.Background(), "lab1", models.LabStartOptions{
+				Staging: &models.NodeStaging{StartRemaining: true, AbortOnFailure: tt.abort},
+				Wait:    models.WaitOptions{Interval: time.Millisecond, Timeout: 20 * time.Millisecond},
+			})
+			assert.ErrorIs(t, err, errors.ErrTimeout)
+			assert.Equal(t, tt.started, lab.started)
+
+			var serr *models.LabStartStageError
+			if assert.ErrorAs(t, err, &serr) {
+				assert.Equal(t, "priority 10", serr.Stage)
+				assert.Equal(t, []models.NodeProgress{{ID: "n4", Label: "rr2", State: models.NodeStateStarted}}, serr.Nodes)
+			}
+		})
+	}
+}
+
+func TestLabStartStaged_MissingServices(t *testing.T) {
+	service := NewLabService(nil, nil, nil, nil, nil)
+	err := service.StartStaged(context.Background(), "lab1", models.LabStartOptions{})
+	assert.ErrorIs(t, err, errors.ErrMissingRequired)
+}
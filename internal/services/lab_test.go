@@ -587,6 +587,77 @@ func TestLabImport_Error(t *testing.T) {
 	assert.Contains(t, err.Error(), "import lab")
 }
 
+func TestLabImportWithOptions(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	var body string
+	httpmock.RegisterResponderWithQuery("POST", "https://mock/api/v0/import",
+		map[string]string{"title": "Renamed Lab"},
+		func(req *http.Request) (*http.Response, error) {
+			b, _ := io.ReadAll(req.Body)
+			body = string(b)
+			return httpmock.NewStringResponse(200, `{
+				"id": "imported-lab-123",
+				"warnings": ["node n0: image definition not found, using default"]
+			}`), nil
+		})
+
+	// shallow fetch only, no nodes/links/interfaces responders required
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/imported-lab-123",
+		httpmock.NewStringResponder(200, `{
+			"id": "imported-lab-123",
+			"lab_title": "Renamed Lab",
+			"state": "DEFINED_ON_CORE",
+			"owner": "owner-uuid",
+			"owner_username": "admin"
+		}`))
+
+	service := NewLabService(client, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	topology := "lab:\n  title: Original\nnodes: []\nlinks: []\n"
+	lab, result, err := service.ImportWithOptions(ctx, strings.NewReader(topology), models.LabImportOptions{
+		Title:   "Renamed Lab",
+		Shallow: true,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, topology, body)
+	assert.Equal(t, models.UUID("imported-lab-123"), result.ID)
+	assert.Equal(t, []string{"node n0: image definition not found, using default"}, result.Warnings)
+	assert.Equal(t, "Renamed Lab", lab.Title)
+	assert.NotNil(t, lab.Owner)
+	assert.Nil(t, lab.Nodes)
+}
+
+func TestLabImportWithOptions_FetchError(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("POST", "https://mock/api/v0/import",
+		httpmock.NewStringResponder(200, `{"id": "imported-lab-123", "warnings": null}`))
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/imported-lab-123",
+		httpmock.NewStringResponder(500, `{"description": "boom"}`))
+
+	service := NewLabService(client, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	_, result, err := service.ImportWithOptions(ctx, strings.NewReader("lab: {}"), models.LabImportOptions{Shallow: true})
+	assert.Error(t, err)
+	// the ID of the imported lab is still returned
+	assert.Equal(t, models.UUID("imported-lab-123"), result.ID)
+	assert.Nil(t, result.Warnings)
+}
+
 func TestLabHasConverged(t *testing.T) {
 	if testutil.IsLiveTesting() {
 		t.Skip("Skipping on live server - test expects specific mock data")
@@ -647,3 +718,226 @@ func TestLabHasConverged_Error(t *testing.T) {
 	_, err := service.HasConverged(ctx, "error-lab")
 	assert.Error(t, err)
 }
+
+func TestLabExport(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	// key order, large numbers and comments must survive the export
+	const document = `lab:
+  title: Exported Lab
+  version: 0.3.0
+  description: ""
+  notes: ""
+nodes:
+  - id: n0
+    label: alpine-0
+    node_definition: alpine
+    x: 10
+    y: 20
+    ram: 1000000
+    tags: []
+    # named configuration
+    configuration:
+      - name: boot.sh
+        content: hostname alpine-0
+    interfaces:
+      - id: i0
+        label: eth0
+        slot: 0
+        type: physical
+links: []
+annotations: []
+`
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab-123/download",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Empty(t, req.URL.RawQuery)
+			return httpmock.NewStringResponse(200, document), nil
+		})
+
+	service := NewLabService(client, nil, nil, nil, nil)
+	service.SetNamedConfigs(true)
+	ctx := context.Background()
+
+	export, err := service.Export(ctx, "lab-123", models.LabExportOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, document, export.YAML)
+
+	assert.Equal(t, "Exported Lab", export.Topology.Lab.Title)
+	assert.Len(t, export.Topology.Nodes, 1)
+	node := export.Topology.Nodes[0]
+	assert.Equal(t, "alpine-0", node.Label)
+	assert.Equal(t, []models.NodeConfig{{Name: "boot.sh", Content: "hostname alpine-0"}}, node.Configurations)
+	assert.Len(t, node.Interfaces, 1)
+}
+
+func TestLabExport_ExcludeConfigurations(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponderWithQuery("GET", "https://mock/api/v0/labs/lab-123/download",
+		map[string]string{"exclude_configurations": "true"},
+		httpmock.NewStringResponder(200, `lab:
+  title: Exported Lab
+nodes:
+  - id: n0
+    label: alpine-0
+    node_definition: alpine
+    x: 0
+    y: 0
+    tags: []
+    interfaces: []
+links: []
+`))
+
+	service := NewLabService(client, nil, nil, nil, nil)
+	service.SetNamedConfigs(true)
+	ctx := context.Background()
+
+	export, err := service.Export(ctx, "lab-123", models.LabExportOptions{ExcludeConfigurations: true})
+	assert.NoError(t, err)
+	assert.Len(t, export.Topology.Nodes, 1)
+	assert.Empty(t, export.Topology.Nodes[0].Configuration)
+	assert.Empty(t, export.Topology.Nodes[0].Configurations)
+	assert.NotContains(t, export.YAML, "configuration")
+}
+
+func TestLabExport_ExtractConfigurations(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab-123/nodes",
+		httpmock.NewStringResponder(200, `[{"id":"n1","label":"r1","state":"BOOTED"},{"id":"n2","label":"r2","state":"STOPPED"}]`))
+	httpmock.RegisterResponder("PUT", "https://mock/api/v0/labs/lab-123/nodes/n1/extract_configuration",
+		httpmock.NewStringResponder(200, `"Success"`))
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab-123/nodes/n1",
+		httpmock.NewStringResponder(200, `{"id":"n1","label":"r1","state":"BOOTED","configuration":"hostname r1"}`))
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab-123/download",
+		httpmock.NewStringResponder(200, `lab:
+  title: Exported Lab
+nodes:
+  - id: n0
+    label: r1
+    node_definition: iosv
+    x: 0
+    y: 0
+    configuration: hostname r1
+links: []
+`))
+
+	service := NewLabService(client, nil, nil, nil, NewNodeService(client, false))
+	export, err := service.Export(context.Background(), "lab-123", models.LabExportOptions{ExtractConfigurations: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "hostname r1", export.Topology.Nodes[0].Configuration)
+
+	calls := httpmock.GetCallCountInfo()
+	assert.Equal(t, 1, calls["PUT https://mock/api/v0/labs/lab-123/nodes/n1/extract_configuration"])
+	assert.Equal(t, 0, calls["PUT https://mock/api/v0/labs/lab-123/nodes/n2/extract_configuration"])
+}
+
+func TestLabExport_Error(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/missing/download",
+		httpmock.NewStringResponder(404, `{"description": "Lab not found"}`))
+
+	service := NewLabService(client, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	_, err := service.Export(ctx, "missing", models.LabExportOptions{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "export lab missing")
+}
+
+func TestLabValidateTopology(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/simplified_node_definitions",
+		httpmock.NewStringResponder(200, `[{
+			"id": "alpine",
+			"device": {"interfaces": {"physical": ["eth0", "eth1"]}},
+			"inherited": {"node": {"ram": true, "cpus": true, "cpu_limit": true, "data_volume": true, "boot_disk_size": true}}
+		}]`))
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/image_definitions",
+		httpmock.NewStringResponder(200, `[{"id": "alpine-3-21", "node_definition_id": "alpine", "label": "Alpine"}]`))
+
+	service := NewLabService(client, nil, nil, nil, nil)
+	service.NodeDefinition = NewNodeDefinitionService(client)
+	service.ImageDefinition = NewImageDefinitionService(client)
+	ctx := context.Background()
+
+	topo, err := models.ParseLabTopology([]byte(`
+lab:
+  title: validate
+nodes:
+  - id: n0
+    label: a0
+    node_definition: alpine
+    image_definition: alpine-3-21
+    interfaces:
+      - {id: i0, label: eth0, slot: 0, type: physical}
+      - {id: i1, label: eth5, slot: 5, type: physical}
+  - id: n1
+    label: a1
+    node_definition: ubuntu
+    interfaces: []
+links:
+  - {id: l0, n1: n0, i1: i0, n2: n1, i2: i0}
+`))
+	assert.NoError(t, err)
+
+	verrs, err := service.ValidateTopology(ctx, topo)
+	assert.NoError(t, err)
+	fields := []string{}
+	for _, verr := range verrs {
+		fields = append(fields, verr.Field)
+	}
+	assert.ElementsMatch(t, []string{"nodes[0].interfaces[1].slot", "nodes[1].node_definition", "links[0].i2"}, fields)
+}
+
+func TestLabValidateTopology_Error(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/simplified_node_definitions",
+		httpmock.NewStringResponder(500, `{"description": "boom"}`))
+
+	service := NewLabService(client, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	// services not configured
+	_, err := service.ValidateTopology(ctx, &models.LabTopology{})
+	assert.Error(t, err)
+
+	service.NodeDefinition = NewNodeDefinitionService(client)
+	service.ImageDefinition = NewImageDefinitionService(client)
+	_, err = service.ValidateTopology(ctx, &models.LabTopology{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "get node definitions")
+}
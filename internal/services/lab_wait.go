@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"sort"
+
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+// WaitConverged polls the lab identified by `id` until all of its nodes are
+// BOOTED and the controller reports the lab as converged. The node states
+// are reported via `opts.Progress` after each poll. If the wait fails, e.g.
+// when the timeout expires, a *models.LabConvergeError names the nodes that
+// did not boot, with the tail of their console logs if requested.
+func (s *LabService) WaitConverged(ctx context.Context, id models.UUID, opts models.LabWaitOptions) error {
+	if s.Node == nil {
+		return errors.Wrap(errors.ErrMissingRequired, "wait for lab: node service")
+	}
+
+	lab := models.Lab{ID: id}
+	err := waitFor(ctx, opts.Wait, func(ctx context.Context) (bool, error) {
+		nodes, err := s.Node.GetNodesForLab(ctx, id)
+		if err != nil {
+			return false, err
+		}
+		lab.Nodes = nodes
+		if opts.Progress != nil {
+			opts.Progress(nodeProgress(nodes))
+		}
+		if !lab.Booted() {
+			return false, nil
+		}
+		return s.HasConverged(ctx, id)
+	})
+	if err == nil {
+		return nil
+	}
+
+	result := &models.LabConvergeError{LabID: id, Err: err}
+	for _, node := range nodeProgress(lab.Nodes) {
+		if node.State != models.NodeStateBooted {
+			result.Nodes = append(result.Nodes, node)
+		}
+	}
+	if opts.ConsoleLogLines > 0 && s.Console != nil {
+		// the wait context may be done already, the logs are best effort
+		logCtx := context.WithoutCancel(ctx)
+		for _, node := range result.Nodes {
+			log, err := s.consoleLogTail(logCtx, id, lab.Nodes[node.ID], opts.ConsoleLogLines)
+			if err != nil || len(log) == 0 {
+				continue
+			}
+			if result.ConsoleLogs == nil {
+				result.ConsoleLogs = make(map[models.UUID]string)
+			}
+			result.ConsoleLogs[node.ID] = log
+		}
+	}
+	return result
+}
+
+// nodeProgress returns the boot state of the nodes, sorted by label.
+func nodeProgress(nodes models.NodeMap) []models.NodeProgress {
+	progress := make([]models.NodeProgress, 0, len(nodes))
+	for _, node := range nodes {
+		progress = append(progress, models.NodeProgress{
+			ID:           node.ID,
+			Label:        node.Label,
+			State:        node.State,
+			BootProgress: node.BootProgress,
+		})
+	}
+	sort.Slice(progress, func(i, j int) bool {
+		return progress[i].Label < progress[j].Label
+	})
+	return progress
+}
+
+// consoleLogTail returns the last lines of the log of the first serial
+// device of the node.
+func (s *LabService) consoleLogTail(ctx context.Context, labID models.UUID, node *models.Node, lines int) (string, error) {
+	device := 0
+	switch {
+	case len(node.SerialDevices) > 0:
+		device = node.SerialDevices[0].DeviceNumber
+	case node.Operational != nil && len(node.Operational.SerialConsoles) > 0:
+		device = node.Operational.SerialConsoles[0].DeviceNumber
+	}
+	return s.Console.Tail(ctx, labID, node.ID, device, lines)
+}
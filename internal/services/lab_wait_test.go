@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rschmied/gocmlclient/internal/testutil"
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+// registerWaitNodes registers a node list responder which returns the given
+// node lists, one per poll. The last one is repeated.
+func registerWaitNodes(id string, responses ...string) {
+	polls := 0
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/"+id+"/nodes",
+		func(req *http.Request) (*http.Response, error) {
+			body := responses[min(polls, len(responses)-1)]
+			polls++
+			return httpmock.NewStringResponse(200, body), nil
+		})
+}
+
+func TestLabWaitConverged(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	registerWaitNodes("lab1",
+		`[{"id":"n2","label":"r2","state":"STARTED","boot_progress":"Booting"},{"id":"n1","label":"r1","state":"STARTED","boot_progress":"Booting"}]`,
+		`[{"id":"n2","label":"r2","state":"STARTED","boot_progress":"Booting"},{"id":"n1","label":"r1","state":"BOOTED","boot_progress":"Booted"}]`,
+		`[{"id":"n2","label":"r2","state":"BOOTED","boot_progress":"Booted"},{"id":"n1","label":"r1","state":"BOOTED","boot_progress":"Booted"}]`,
+	)
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab1/check_if_converged",
+		httpmock.NewStringResponder(200, `true`))
+
+	var progress [][]models.NodeProgress
+	service := NewLabService(client, nil, nil, nil, NewNodeService(client, false))
+	err := service.WaitConverged(context.Background(), "lab1", models.LabWaitOptions{
+		Wait:     models.WaitOptions{Interval: time.Millisecond, Timeout: time.Second},
+		Progress: func(nodes []models.NodeProgress) { progress = append(progress, nodes) },
+	})
+	assert.NoError(t, err)
+
+	assert.Len(t, progress, 3)
+	assert.Equal(t, []models.NodeProgress{
+		{ID: "n1", Label: "r1", State: models.NodeStateBooted, BootProgress: models.BootProgressBooted},
+		{ID: "n2", Label: "r2", State: models.NodeStateStarted, BootProgress: models.BootProgressBooting},
+	}, progress[1])
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET https://mock/api/v0/labs/lab1/check_if_converged"])
+}
+
+func TestLabWaitConverged_Timeout(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	registerWaitNodes("lab1",
+		`[{"id":"n1","label":"r1","state":"BOOTED"},{"id":"n2","label":"r2","state":"STARTED","serial_devices":[{"console_key":"key","device_number":0}]}]`,
+	)
+	httpmock.RegisterResponderWithQuery("GET", "https://mock/api/v0/labs/lab1/nodes/n2/consoles/0/log", "lines=5",
+		httpmock.NewStringResponder(200, `"%BOOT-FAILED\nrommon 1 >"`))
+
+	service := NewLabService(client, nil, nil, nil, NewNodeService(client, false))
+	service.Console = NewConsoleService(client)
+	err := service.WaitConverged(context.Background(), "lab1", models.LabWaitOptions{
+		Wait:            models.WaitOptions{Interval: time.Millisecond, Timeout: 20 * time.Millisecond},
+		ConsoleLogLines: 5,
+	})
+	assert.ErrorIs(t, err, errors.ErrTimeout)
+
+	var cerr *models.LabConvergeError
+	if assert.ErrorAs(t, err, &cerr) {
+		assert.Equal(t, []models.NodeProgress{{ID: "n2", Label: "r2", State: models.NodeStateStarted}}, cerr.Nodes)
+		assert.Equal(t, map[models.UUID]string{"n2": "%BOOT-FAILED\nrommon 1 >"}, cerr.ConsoleLogs)
+		assert.Contains(t, err.Error(), "nodes not booted: r2 (STARTED)")
+	}
+}
+
+func TestLabWaitConverged_Error(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab1/nodes",
+		httpmock.NewStringResponder(500, `{"description": "boom"}`))
+
+	service := NewLabService(client, nil, nil, nil, NewNodeService(client, false))
+	err := service.WaitConverged(context.Background(), "lab1", models.LabWaitOptions{})
+
+	var cerr *models.LabConvergeError
+	assert.ErrorAs(t, err, &cerr)
+	assert.NotErrorIs(t, err, errors.ErrTimeout)
+	assert.Empty(t, cerr.Nodes)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/rschmied/gocmlclient/internal/api"
 	"github.com/rschmied/gocmlclient/internal/httputil"
+	"github.com/rschmied/gocmlclient/pkg/errors"
 	"github.com/rschmied/gocmlclient/pkg/models"
 )
 
@@ -22,7 +23,15 @@ var _ LinkServiceInterface = (*LinkService)(nil)
 // LinkServiceInterface defines methods needed by other services
 type LinkServiceInterface interface {
 	GetLinksForLab(ctx context.Context, labID models.UUID) ([]models.Link, error)
+	Create(ctx context.Context, link models.Link) (models.Link, error)
 	Delete(ctx context.Context, labID, linkID models.UUID) error
+	Update(ctx context.Context, link models.Link) (models.Link, error)
+	Start(ctx context.Context, labID, linkID models.UUID) error
+	Stop(ctx context.Context, labID, linkID models.UUID) error
+	WaitState(ctx context.Context, labID, linkID models.UUID, state string, opts models.WaitOptions) error
+	ApplyProfile(ctx context.Context, labID, linkID models.UUID, profile models.LinkProfile) (models.ConditionResponse, error)
+	ApplyProfileToLinks(ctx context.Context, labID models.UUID, selector models.LinkSelector, profile models.LinkProfile) ([]models.UUID, error)
+	RunScenario(ctx context.Context, labID models.UUID, linkIDs []models.UUID, scenario models.LinkScenario, opts models.LinkScenarioOptions) error
 	GetCondition(ctx context.Context, labID, linkID models.UUID) (models.ConditionResponse, error)
 	SetCondition(ctx context.Context, labID, linkID models.UUID, config *models.LinkConditionConfiguration) (models.ConditionResponse, error)
 	DeleteCondition(ctx context.Context, labID, linkID models.UUID) error
@@ -33,6 +42,9 @@ type LinkService struct {
 	apiClient *api.Client
 	Interface InterfaceServiceInterface
 	Node      NodeServiceInterface
+
+	// optional, used to create links by interface label
+	NodeDefinition NodeDefinitionServiceInterface
 }
 
 // NewLinkService creates a new link service
@@ -52,6 +64,11 @@ func linkURL(labID, linkID models.UUID) string {
 	return fmt.Sprintf("%s/%s", linksURL(labID), linkID)
 }
 
+// linkStateURL builds URL for link state operations
+func linkStateURL(labID, linkID models.UUID, action string) string {
+	return fmt.Sprintf("%s/state/%s", linkURL(labID, linkID), action)
+}
+
 // linkConditionURL builds URL for link condition operations
 func linkConditionURL(labID, linkID models.UUID) string {
 	return fmt.Sprintf("%s/%s", linkURL(labID, linkID), conditionAPI)
@@ -108,9 +125,19 @@ func (s *LinkService) GetByID(ctx context.Context, labID, linkID models.UUID) (m
 // variable has the updated link data.
 // Node: -1 for a slot means: use next free slot. Specific slots run from 0 to
 // the maximum slot number -1 per the node definition of the node type.
+//
+// Instead of a slot, the interface can be named by its label in
+// `SrcInterface` / `DstInterface`. Labels of interfaces which don't exist yet
+// are mapped to a slot via the physical interfaces of the node definition,
+// this requires the node definition service. A labeled interface which is
+// already connected is rejected with errors.ErrInvalidInput.
 func (s *LinkService) Create(ctx context.Context, link models.Link) (models.Link, error) {
 	api := linksURL(link.LabID)
 
+	if (len(link.SrcInterface) > 0 && len(link.SrcNode) == 0) || (len(link.DstInterface) > 0 && len(link.DstNode) == 0) {
+		return models.Link{}, errors.Wrap(errors.ErrMissingRequired, "create link: node of interface label")
+	}
+
 	if len(link.SrcNode) > 0 && len(link.DstNode) > 0 {
 		ifaceListA, err := s.Interface.GetInterfacesForNode(ctx, link.LabID, link.SrcNode)
 		if err != nil {
@@ -122,6 +149,17 @@ func (s *LinkService) Create(ctx context.Context, link models.Link) (models.Link
 			return models.Link{}, err
 		}
 
+		if len(link.SrcInterface) > 0 {
+			if link.SrcSlot, err = s.interfaceSlot(ctx, link.LabID, link.SrcNode, link.SrcInterface, ifaceListA); err != nil {
+				return models.Link{}, err
+			}
+		}
+		if len(link.DstInterface) > 0 {
+			if link.DstSlot, err = s.interfaceSlot(ctx, link.LabID, link.DstNode, link.DstInterface, ifaceListB); err != nil {
+				return models.Link{}, err
+			}
+		}
+
 		matches := func(slot int, iface *models.Interface) bool {
 			return iface.IsPhysical() && !iface.IsConnected && (slot < 0 || (iface.Slot != nil && *iface.Slot == slot))
 		}
@@ -182,12 +220,93 @@ func (s *LinkService) Create(ctx context.Context, link models.Link) (models.Link
 	return resultLink, nil
 }
 
+// interfaceSlot returns the slot of the physical interface labeled `label`
+// of the node, either from its existing interfaces or from its node
+// definition. An existing interface must not be connected.
+func (s *LinkService) interfaceSlot(ctx context.Context, labID, nodeID models.UUID, label string, ifaces models.InterfaceList) (int, error) {
+	for _, iface := range ifaces {
+		if iface.IsPhysical() && iface.Label == label && iface.Slot != nil {
+			if iface.IsConnected {
+				return -1, errors.Wrapf(errors.ErrInvalidInput, "create link: interface %q in use", label)
+			}
+			return *iface.Slot, nil
+		}
+	}
+
+	if s.Node == nil || s.NodeDefinition == nil {
+		return -1, errors.Wrapf(errors.ErrMissingRequired, "create link: node and node definition services for interface %q", label)
+	}
+	node, err := s.Node.GetByID(ctx, labID, nodeID)
+	if err != nil {
+		return -1, errors.Wrapf(err, "create link: interface %q", label)
+	}
+	nodeDefs, err := s.NodeDefinition.NodeDefinitions(ctx)
+	if err != nil {
+		return -1, errors.Wrap(err, "get node definitions")
+	}
+	nodeDef, found := nodeDefs[models.UUID(node.NodeDefinition)]
+	if !found {
+		return -1, errors.Wrapf(errors.ErrElementNotFound, "create link: node definition %q", node.NodeDefinition)
+	}
+	slot, found := nodeDef.PhysicalSlot(label)
+	if !found {
+		return -1, errors.Wrapf(errors.ErrElementNotFound, "create link: interface %q of node %s (%s)", label, node.Label, node.NodeDefinition)
+	}
+	return slot, nil
+}
+
 // Delete removes a link from a lab identified by the Lab ID and Link ID.
 func (s *LinkService) Delete(ctx context.Context, labID, linkID models.UUID) error {
 	api := linkURL(labID, linkID)
 	return s.apiClient.DeleteJSON(ctx, api, nil)
 }
 
+// Update updates the label of the link identified by the `ID` and `LabID`
+// of `link`, the other fields can't be changed. It returns the updated link.
+func (s *LinkService) Update(ctx context.Context, link models.Link) (models.Link, error) {
+	data := struct {
+		Label string `json:"label"`
+	}{link.Label}
+	if err := s.apiClient.PatchJSON(ctx, linkURL(link.LabID, link.ID), nil, data, nil); err != nil {
+		return models.Link{}, err
+	}
+	return s.GetByID(ctx, link.LabID, link.ID)
+}
+
+// Start starts the link, the equivalent of plugging in the cable. Both
+// nodes must be running.
+func (s *LinkService) Start(ctx context.Context, labID, linkID models.UUID) error {
+	return s.apiClient.PutJSON(ctx, linkStateURL(labID, linkID, "start"), nil)
+}
+
+// Stop stops the link, the equivalent of pulling the cable. The nodes keep
+// running.
+func (s *LinkService) Stop(ctx context.Context, labID, linkID models.UUID) error {
+	return s.apiClient.PutJSON(ctx, linkStateURL(labID, linkID, "stop"), nil)
+}
+
+// WaitState polls the link until it reaches `state` (e.g.
+// models.LinkStateStarted), as controlled by `opts`. If the wait fails, the
+// error includes the last state seen.
+//
+//	err := client.Link.Stop(ctx, labID, linkID)
+//	err = client.Link.WaitState(ctx, labID, linkID, models.LinkStateStopped, models.WaitOptions{Timeout: time.Minute})
+func (s *LinkService) WaitState(ctx context.Context, labID, linkID models.UUID, state string, opts models.WaitOptions) error {
+	current := ""
+	err := waitFor(ctx, opts, func(ctx context.Context) (bool, error) {
+		link, err := s.GetByID(ctx, labID, linkID)
+		if err != nil {
+			return false, err
+		}
+		current = link.State
+		return current == state, nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "wait for link %s to be %s (state %q)", linkID, state, current)
+	}
+	return nil
+}
+
 // GetCondition retrieves the current link conditioning configuration
 func (s *LinkService) GetCondition(ctx context.Context, labID, linkID models.UUID) (models.ConditionResponse, error) {
 	api := linkConditionURL(labID, linkID)
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"sort"
+
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+// ApplyProfile sets the condition of the profile on the link.
+//
+//	profiles := models.NewLinkProfileRegistry()
+//	lte, err := profiles.Get(models.LinkProfileLTE)
+//	_, err = client.Link.ApplyProfile(ctx, labID, linkID, lte)
+func (s *LinkService) ApplyProfile(ctx context.Context, labID, linkID models.UUID, profile models.LinkProfile) (models.ConditionResponse, error) {
+	condition, err := s.SetCondition(ctx, labID, linkID, profile.Configuration())
+	if err != nil {
+		return models.ConditionResponse{}, errors.Wrapf(err, "apply link profile %q to link %s", profile.Name, linkID)
+	}
+	return condition, nil
+}
+
+// ApplyProfileToLinks sets the condition of the profile on all links of the
+// lab which match the selector. A selector with a node query requires the
+// node service. A failure does not stop the other links, the IDs of the
+// conditioned links are returned in ID order together with the joined
+// errors of the failed ones.
+func (s *LinkService) ApplyProfileToLinks(ctx context.Context, labID models.UUID, selector models.LinkSelector, profile models.LinkProfile) ([]models.UUID, error) {
+	if selector.Node != nil && s.Node == nil {
+		return nil, errors.Wrap(errors.ErrMissingRequired, "apply link profile: node service")
+	}
+
+	links, err := s.GetLinksForLab(ctx, labID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "apply link profile %q", profile.Name)
+	}
+	var nodes models.NodeMap
+	if selector.Node != nil {
+		if nodes, err = s.Node.GetNodesForLab(ctx, labID); err != nil {
+			return nil, errors.Wrapf(err, "apply link profile %q", profile.Name)
+		}
+	}
+
+	sort.Slice(links, func(i, j int) bool { return links[i].ID < links[j].ID })
+	applied := []models.UUID{}
+	var errs []error
+	for idx := range links {
+		if !selector.Matches(&links[idx], nodes) {
+			continue
+		}
+		if _, err := s.ApplyProfile(ctx, labID, links[idx].ID, profile); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		applied = append(applied, links[idx].ID)
+	}
+	return applied, stderrors.Join(errs...)
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rschmied/gocmlclient/internal/testutil"
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+func TestLinkApplyProfile(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab1/links",
+		httpmock.NewStringResponder(200, `[
+			{"id": "l2", "lab_id": "lab1", "label": "wan-2", "node_a": "n3", "node_b": "n1"},
+			{"id": "l1", "lab_id": "lab1", "label": "wan-1", "node_a": "n1", "node_b": "n2"},
+			{"id": "l3", "lab_id": "lab1", "label": "lan-1", "node_a": "n2", "node_b": "n3"}
+		]`))
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab1/nodes",
+		httpmock.NewStringResponder(200, `[
+			{"id": "n1", "lab_id": "lab1", "label": "core-1", "tags": ["core"]},
+			{"id": "n2", "lab_id": "lab1", "label": "branch-1", "tags": ["branch"]},
+			{"id": "n3", "lab_id": "lab1", "label": "branch-2", "tags": ["branch"]}
+		]`))
+	bodies := map[string]string{}
+	httpmock.RegisterResponder("PATCH", `=~^https://mock/api/v0/labs/lab1/links/(\w+)/condition`,
+		func(req *http.Request) (*http.Response, error) {
+			id := httpmock.MustGetSubmatch(req, 1)
+			if id == "l3" {
+				return httpmock.NewStringResponse(500, `{"description": "boom"}`), nil
+			}
+			b, _ := io.ReadAll(req.Body)
+			bodies[id] = string(b)
+			return httpmock.NewStringResponse(200, string(b)), nil
+		})
+
+	profiles := models.NewLinkProfileRegistry()
+	lte, err := profiles.Get(models.LinkProfileLTE)
+	assert.NoError(t, err)
+
+	service := NewLinkService(client)
+	ctx := context.Background()
+
+	condition, err := service.ApplyProfile(ctx, "lab1", "l1", lte)
+	assert.NoError(t, err)
+	assert.True(t, condition.Enabled)
+	assert.Equal(t, 50, condition.Latency)
+
+	applied, err := service.ApplyProfileToLinks(ctx, "lab1", models.LinkSelector{Labels: []string{"wan-*"}}, lte)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.UUID{"l1", "l2"}, applied)
+	assert.JSONEq(t,
+		`{"bandwidth": 20000, "latency": 50, "jitter": 15, "loss": 0.5, "loss_corr": 25, "enabled": true}`,
+		bodies["l2"])
+
+	// node queries need the node service
+	selector := models.LinkSelector{Node: &models.NodeQuery{Tags: []string{"branch"}}}
+	_, err = service.ApplyProfileToLinks(ctx, "lab1", selector, lte)
+	assert.ErrorIs(t, err, errors.ErrMissingRequired)
+
+	service.Node = NewNodeService(client, false)
+	applied, err = service.ApplyProfileToLinks(ctx, "lab1", selector, lte)
+	assert.Equal(t, []models.UUID{"l1", "l2"}, applied)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `apply link profile "lte" to link l3`)
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+// RunScenario applies the steps of the scenario to the given links of the
+// lab at their offsets from the start of the run. It blocks until the last
+// step has been applied, a step fails or the context is done. In any case,
+// the original conditions of the links are restored and links stopped by the
+// scenario are started again before it returns. Links which are not started
+// at the beginning of the run are not touched by down and up steps.
+//
+//	err := client.Link.RunScenario(ctx, labID, []models.UUID{wan1, wan2}, scenario, models.LinkScenarioOptions{})
+func (s *LinkService) RunScenario(ctx context.Context, labID models.UUID, linkIDs []models.UUID, scenario models.LinkScenario, opts models.LinkScenarioOptions) (err error) {
+	if len(linkIDs) == 0 {
+		return errors.Wrapf(errors.ErrMissingRequired, "scenario %q: links", scenario.Name)
+	}
+	if err := scenario.Validate(); err != nil {
+		return err
+	}
+
+	run := &linkScenarioRun{
+		links:    s,
+		labID:    labID,
+		linkIDs:  linkIDs,
+		original: make(map[models.UUID]models.LinkConditionConfiguration, len(linkIDs)),
+		started:  make(map[models.UUID]bool, len(linkIDs)),
+		stopped:  make(map[models.UUID]bool),
+	}
+	for _, id := range linkIDs {
+		link, err := s.GetByID(ctx, labID, id)
+		if err != nil {
+			return errors.Wrapf(err, "scenario %q: get link %s", scenario.Name, id)
+		}
+		run.started[id] = link.State == models.LinkStateStarted
+		condition, err := s.GetCondition(ctx, labID, id)
+		if err != nil {
+			return errors.Wrapf(err, "scenario %q: get condition of link %s", scenario.Name, id)
+		}
+		run.original[id] = condition.LinkConditionConfiguration
+	}
+
+	defer func() {
+		// the context may be done already, restoring must happen anyway
+		if restoreErr := run.restore(context.WithoutCancel(ctx)); restoreErr != nil {
+			err = stderrors.Join(err, errors.Wrapf(restoreErr, "scenario %q: restore", scenario.Name))
+		}
+	}()
+
+	start := time.Now()
+	for _, step := range scenario.Timeline() {
+		timer := time.NewTimer(time.Until(start.Add(step.At)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Wrapf(ctx.Err(), "scenario %q: before %s", scenario.Name, step)
+		case <-timer.C:
+		}
+		if err := run.apply(ctx, step); err != nil {
+			return errors.Wrapf(err, "scenario %q: %s", scenario.Name, step)
+		}
+		if opts.Progress != nil {
+			opts.Progress(step)
+		}
+	}
+	return nil
+}
+
+type linkScenarioRun struct {
+	links       *LinkService
+	labID       models.UUID
+	linkIDs     []models.UUID
+	original    map[models.UUID]models.LinkConditionConfiguration
+	started     map[models.UUID]bool // links started before the run
+	stopped     map[models.UUID]bool // links stopped by the scenario
+	conditioned bool
+}
+
+func (r *linkScenarioRun) apply(ctx context.Context, step models.LinkScenarioStep) error {
+	switch step.Action {
+	case models.LinkScenarioCondition:
+		r.conditioned = true
+		for _, id := range r.linkIDs {
+			if _, err := r.links.SetCondition(ctx, r.labID, id, step.Condition); err != nil {
+				return errors.Wrapf(err, "link %s", id)
+			}
+		}
+	case models.LinkScenarioDown:
+		for _, id := range r.linkIDs {
+			if !r.started[id] {
+				continue
+			}
+			if err := r.links.Stop(ctx, r.labID, id); err != nil {
+				return errors.Wrapf(err, "link %s", id)
+			}
+			r.stopped[id] = true
+		}
+	case models.LinkScenarioUp:
+		return r.startStopped(ctx)
+	case models.LinkScenarioRestore:
+		return r.restore(ctx)
+	}
+	return nil
+}
+
+func (r *linkScenarioRun) startStopped(ctx context.Context) error {
+	for _, id := range r.linkIDs {
+		if !r.stopped[id] {
+			continue
+		}
+		if err := r.links.Start(ctx, r.labID, id); err != nil {
+			return errors.Wrapf(err, "link %s", id)
+		}
+		delete(r.stopped, id)
+	}
+	return nil
+}
+
+// restore puts back the original conditions, if they were changed, and
+// starts the stopped links. All links are attempted, the errors are joined.
+func (r *linkScenarioRun) restore(ctx context.Context) error {
+	var errs []error
+	if r.conditioned {
+		for _, id := range r.linkIDs {
+			var err error
+			if original := r.original[id]; original == (models.LinkConditionConfiguration{}) {
+				err = r.links.DeleteCondition(ctx, r.labID, id)
+			} else {
+				_, err = r.links.SetCondition(ctx, r.labID, id, &original)
+			}
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "link %s", id))
+			}
+		}
+		r.conditioned = len(errs) > 0
+	}
+	for _, id := range r.linkIDs {
+		if !r.stopped[id] {
+			continue
+		}
+		if err := r.links.Start(ctx, r.labID, id); err != nil {
+			errs = append(errs, errors.Wrapf(err, "link %s", id))
+			continue
+		}
+		delete(r.stopped, id)
+	}
+	return stderrors.Join(errs...)
+}
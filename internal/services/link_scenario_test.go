@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rschmied/gocmlclient/internal/testutil"
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+const scenarioMockURL = "https://mock/api/v0/labs/lab1/links/"
+
+// registerScenarioLinks registers the started links l1 with a latency of 5ms
+// and l2 without a condition, the requests are recorded as
+// "METHOD link/op body".
+func registerScenarioLinks() func() []string {
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	record := func(status int, body string) httpmock.Responder {
+		return func(req *http.Request) (*http.Response, error) {
+			var b []byte
+			if req.Body != nil {
+				b, _ = io.ReadAll(req.Body)
+			}
+			path := strings.TrimPrefix(req.URL.Path, "/api/v0/labs/lab1/links/")
+			mu.Lock()
+			requests = append(requests, strings.TrimSpace(req.Method+" "+path+" "+string(b)))
+			mu.Unlock()
+			return httpmock.NewStringResponse(status, body), nil
+		}
+	}
+	for _, id := range []string{"l1", "l2"} {
+		httpmock.RegisterResponder("GET", scenarioMockURL+id,
+			httpmock.NewStringResponder(200, `{"id": "`+id+`", "lab_id": "lab1", "state": "STARTED"}`))
+	}
+	httpmock.RegisterResponder("GET", scenarioMockURL+"l1/condition",
+		httpmock.NewStringResponder(200, `{"latency": 5, "enabled": true}`))
+	httpmock.RegisterResponder("GET", scenarioMockURL+"l2/condition",
+		httpmock.NewStringResponder(200, `{}`))
+	httpmock.RegisterResponder("PATCH", `=~^`+scenarioMockURL+`\w+/condition`, record(200, `{}`))
+	httpmock.RegisterResponder("DELETE", `=~^`+scenarioMockURL+`\w+/condition`, record(204, ``))
+	httpmock.RegisterResponder("PUT", `=~^`+scenarioMockURL+`\w+/state/\w+`, record(204, ``))
+
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), requests...)
+	}
+}
+
+func TestLinkRunScenario(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+	requests := registerScenarioLinks()
+
+	ms := time.Millisecond
+	scenario := models.LinkScenario{Name: "wan", Steps: []models.LinkScenarioStep{
+		models.DownAt(20 * ms),
+		models.ConditionAt(0, models.LinkConditionConfiguration{Latency: 20}),
+		models.ConditionAt(10*ms, models.LinkConditionConfiguration{Loss: 5}),
+		models.UpAt(30 * ms),
+	}}
+
+	var progress []string
+	service := NewLinkService(client)
+	start := time.Now()
+	err := service.RunScenario(context.Background(), "lab1", []models.UUID{"l1", "l2"}, scenario, models.LinkScenarioOptions{
+		Progress: func(step models.LinkScenarioStep) { progress = append(progress, step.String()) },
+	})
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), scenario.Duration())
+	assert.Equal(t, []string{"t=0s condition", "t=10ms condition", "t=20ms down", "t=30ms up"}, progress)
+
+	assert.Equal(t, []string{
+		`PATCH l1/condition {"latency":20,"enabled":true}`,
+		`PATCH l2/condition {"latency":20,"enabled":true}`,
+		`PATCH l1/condition {"loss":5,"enabled":true}`,
+		`PATCH l2/condition {"loss":5,"enabled":true}`,
+		`PUT l1/state/stop`,
+		`PUT l2/state/stop`,
+		`PUT l1/state/start`,
+		`PUT l2/state/start`,
+		// original conditions are restored at the end
+		`PATCH l1/condition {"latency":5,"enabled":true}`,
+		`DELETE l2/condition`,
+	}, requests())
+}
+
+func TestLinkRunScenario_Cancel(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+	requests := registerScenarioLinks()
+
+	scenario := models.LinkScenario{Name: "outage", Steps: []models.LinkScenarioStep{
+		models.DownAt(0),
+		models.RestoreAt(time.Hour),
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	service := NewLinkService(client)
+	err := service.RunScenario(ctx, "lab1", []models.UUID{"l1"}, scenario, models.LinkScenarioOptions{
+		Progress: func(models.LinkScenarioStep) { cancel() },
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Contains(t, err.Error(), `scenario "outage": before t=1h0m0s restore`)
+
+	// the stopped link is started again, conditions were not touched
+	assert.Equal(t, []string{`PUT l1/state/stop`, `PUT l1/state/start`}, requests())
+}
+
+func TestLinkRunScenario_StoppedLinks(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+	requests := registerScenarioLinks()
+	httpmock.RegisterResponder("GET", scenarioMockURL+"l2",
+		httpmock.NewStringResponder(200, `{"id": "l2", "lab_id": "lab1", "state": "STOPPED"}`))
+
+	scenario := models.LinkScenario{Name: "outage", Steps: []models.LinkScenarioStep{
+		models.DownAt(0),
+		models.UpAt(0),
+		models.DownAt(0),
+	}}
+
+	service := NewLinkService(client)
+	err := service.RunScenario(context.Background(), "lab1", []models.UUID{"l1", "l2"}, scenario, models.LinkScenarioOptions{})
+	assert.NoError(t, err)
+
+	// l2 was stopped before the run and stays stopped
+	assert.Equal(t, []string{
+		`PUT l1/state/stop`,
+		`PUT l1/state/start`,
+		`PUT l1/state/stop`,
+		`PUT l1/state/start`,
+	}, requests())
+}
+
+func TestLinkRunScenario_Errors(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+	requests := registerScenarioLinks()
+	httpmock.RegisterResponder("PUT", scenarioMockURL+"l2/state/stop",
+		httpmock.NewStringResponder(400, `{"description": "node is not running"}`))
+
+	service := NewLinkService(client)
+	ctx := context.Background()
+
+	err := service.RunScenario(ctx, "lab1", nil, models.LinkScenario{}, models.LinkScenarioOptions{})
+	assert.ErrorIs(t, err, errors.ErrMissingRequired)
+	err = service.RunScenario(ctx, "lab1", []models.UUID{"l1"}, models.LinkScenario{
+		Steps: []models.LinkScenarioStep{{Action: models.LinkScenarioCondition}},
+	}, models.LinkScenarioOptions{})
+	assert.ErrorIs(t, err, errors.ErrMissingRequired)
+
+	scenario := models.LinkScenario{Name: "flap", Steps: []models.LinkScenarioStep{
+		models.ConditionAt(0, models.LinkConditionConfiguration{Jitter: 10}),
+		models.DownAt(0),
+	}}
+	err = service.RunScenario(ctx, "lab1", []models.UUID{"l1", "l2"}, scenario, models.LinkScenarioOptions{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `scenario "flap": t=0s down: link l2`)
+	assert.Equal(t, []string{
+		`PATCH l1/condition {"jitter":10,"enabled":true}`,
+		`PATCH l2/condition {"jitter":10,"enabled":true}`,
+		`PUT l1/state/stop`,
+		`PATCH l1/condition {"latency":5,"enabled":true}`,
+		`DELETE l2/condition`,
+		`PUT l1/state/start`,
+	}, requests())
+}
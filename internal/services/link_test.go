@@ -2,13 +2,18 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"io"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/rschmied/gocmlclient/internal/api"
 	"github.com/rschmied/gocmlclient/internal/testutil"
+	"github.com/rschmied/gocmlclient/pkg/errors"
 	"github.com/rschmied/gocmlclient/pkg/models"
 )
 
@@ -539,3 +544,155 @@ func TestLinkGetByID_LabIDMismatch(t *testing.T) {
 	assert.Contains(t, err.Error(), "link lab ID mismatch")
 	assert.Contains(t, err.Error(), "expected lab-123, got different-lab")
 }
+
+func TestLinkStateAndUpdate(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - requires specific setup")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	const linkAPI = "https://mock/api/v0/labs/lab-123/links/link-456"
+	state, label := "STARTED", "l0"
+	polls := 0
+	httpmock.RegisterResponder("GET", linkAPI,
+		func(req *http.Request) (*http.Response, error) {
+			polls++
+			// the controller takes a poll to change the state
+			current := state
+			if polls%2 == 1 && state == models.LinkStateStopped {
+				current = models.LinkStateStarted
+			}
+			return httpmock.NewStringResponse(200,
+				`{"id": "link-456", "lab_id": "lab-123", "state": "`+current+`", "label": "`+label+`"}`), nil
+		})
+	httpmock.RegisterResponder("PUT", linkAPI+"/state/stop",
+		func(req *http.Request) (*http.Response, error) {
+			state = models.LinkStateStopped
+			return httpmock.NewStringResponse(204, ""), nil
+		})
+	httpmock.RegisterResponder("PUT", linkAPI+"/state/start",
+		func(req *http.Request) (*http.Response, error) {
+			state = models.LinkStateStarted
+			return httpmock.NewStringResponse(204, ""), nil
+		})
+	var patched string
+	httpmock.RegisterResponder("PATCH", linkAPI,
+		func(req *http.Request) (*http.Response, error) {
+			b, _ := io.ReadAll(req.Body)
+			patched = string(b)
+			label = "uplink"
+			return httpmock.NewStringResponse(200, `"link-456"`), nil
+		})
+
+	service := NewLinkService(client)
+	ctx := context.Background()
+	opts := models.WaitOptions{Interval: time.Millisecond}
+
+	assert.NoError(t, service.Stop(ctx, "lab-123", "link-456"))
+	assert.NoError(t, service.WaitState(ctx, "lab-123", "link-456", models.LinkStateStopped, opts))
+	assert.Equal(t, 2, polls)
+
+	assert.NoError(t, service.Start(ctx, "lab-123", "link-456"))
+	assert.NoError(t, service.WaitState(ctx, "lab-123", "link-456", models.LinkStateStarted, opts))
+
+	link, err := service.Update(ctx, models.Link{ID: "link-456", LabID: "lab-123", Label: "uplink", State: "ignored"})
+	assert.NoError(t, err)
+	assert.Equal(t, "uplink", link.Label)
+	assert.JSONEq(t, `{"label": "uplink"}`, patched)
+
+	// the link never stops
+	opts.Timeout = 20 * time.Millisecond
+	err = service.WaitState(ctx, "lab-123", "link-456", models.LinkStateDefined, opts)
+	assert.ErrorIs(t, err, errors.ErrTimeout)
+	assert.Contains(t, err.Error(), `wait for link link-456 to be DEFINED_ON_CORE (state "STARTED")`)
+}
+
+func TestLinkCreate_InterfaceLabel(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - requires specific setup")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab-123/nodes/src-node/interfaces",
+		httpmock.NewStringResponder(200, `[
+			{"id": "a0", "label": "GigabitEthernet0/0", "slot": 0, "type": "physical", "is_connected": true}
+		]`))
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab-123/nodes/dst-node/interfaces",
+		httpmock.NewStringResponder(200, `[
+			{"id": "b0", "label": "eth0", "slot": 0, "type": "physical", "is_connected": false},
+			{"id": "b1", "label": "eth1", "slot": 1, "type": "physical", "is_connected": false}
+		]`))
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab-123/nodes/src-node",
+		httpmock.NewStringResponder(200, `{"id": "src-node", "lab_id": "lab-123", "label": "r1", "node_definition": "iosv"}`))
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/simplified_node_definitions",
+		httpmock.NewStringResponder(200, `[{
+			"id": "iosv",
+			"device": {"interfaces": {"physical": ["GigabitEthernet0/0", "GigabitEthernet0/1", "GigabitEthernet0/2"]}}
+		}]`))
+	var slots []int
+	httpmock.RegisterResponder("POST", "https://mock/api/v0/labs/lab-123/interfaces",
+		func(req *http.Request) (*http.Response, error) {
+			var data struct {
+				Slot int `json:"slot"`
+			}
+			b, _ := io.ReadAll(req.Body)
+			_ = json.Unmarshal(b, &data)
+			slots = append(slots, data.Slot)
+			return httpmock.NewStringResponse(200, `[
+				{"id": "a1", "label": "GigabitEthernet0/1", "slot": 1, "type": "physical"},
+				{"id": "a2", "label": "GigabitEthernet0/2", "slot": 2, "type": "physical"}
+			]`), nil
+		})
+	var posted string
+	httpmock.RegisterResponder("POST", "https://mock/api/v0/labs/lab-123/links",
+		func(req *http.Request) (*http.Response, error) {
+			b, _ := io.ReadAll(req.Body)
+			posted = string(b)
+			return httpmock.NewStringResponse(200, `{"id": "link-1"}`), nil
+		})
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab-123/links/link-1",
+		httpmock.NewStringResponder(200, `{"id": "link-1", "lab_id": "lab-123", "interface_a": "a2", "interface_b": "b1"}`))
+
+	service := NewLinkService(client)
+	service.Interface = NewInterfaceService(client)
+	service.Node = NewNodeService(client, false)
+	ctx := context.Background()
+
+	link := models.Link{
+		LabID:        "lab-123",
+		SrcNode:      "src-node",
+		DstNode:      "dst-node",
+		SrcInterface: "GigabitEthernet0/2",
+		DstInterface: "eth1",
+	}
+
+	// the source interface does not exist, the node definition is needed
+	_, err := service.Create(ctx, link)
+	assert.ErrorIs(t, err, errors.ErrMissingRequired)
+
+	service.NodeDefinition = NewNodeDefinitionService(client)
+	newLink, err := service.Create(ctx, link)
+	assert.NoError(t, err)
+	assert.Equal(t, models.UUID("link-1"), newLink.ID)
+	assert.Equal(t, []int{2}, slots)
+	assert.JSONEq(t, `{"src_int": "a2", "dst_int": "b1"}`, posted)
+
+	link.SrcInterface = "GigabitEthernet0/9"
+	_, err = service.Create(ctx, link)
+	assert.ErrorIs(t, err, errors.ErrElementNotFound)
+	assert.Contains(t, err.Error(), `interface "GigabitEthernet0/9" of node r1 (iosv)`)
+
+	// the existing source interface is connected already
+	link.SrcInterface = "GigabitEthernet0/0"
+	_, err = service.Create(ctx, link)
+	assert.ErrorIs(t, err, errors.ErrInvalidInput)
+	assert.Contains(t, err.Error(), `interface "GigabitEthernet0/0" in use`)
+	assert.Equal(t, []int{2}, slots)
+
+	_, err = service.Create(ctx, models.Link{LabID: "lab-123", SrcInterface: "eth0", DstID: "b0"})
+	assert.ErrorIs(t, err, errors.ErrMissingRequired)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/rschmied/gocmlclient/internal/api"
 	"github.com/rschmied/gocmlclient/internal/httputil"
+	"github.com/rschmied/gocmlclient/pkg/errors"
 	"github.com/rschmied/gocmlclient/pkg/models"
 )
 
@@ -21,6 +22,21 @@ var _ NodeServiceInterface = (*NodeService)(nil)
 type NodeServiceInterface interface {
 	GetNodesForLab(ctx context.Context, labID models.UUID) (models.NodeMap, error)
 	GetByID(ctx context.Context, labID, id models.UUID) (models.Node, error)
+	Find(ctx context.Context, labID models.UUID, q models.NodeQuery) (models.NodeList, error)
+	Create(ctx context.Context, node models.Node) (models.Node, error)
+	Update(ctx context.Context, node models.Node) (models.Node, error)
+	Delete(ctx context.Context, labID, nodeID models.UUID) error
+	Start(ctx context.Context, labID, nodeID models.UUID) error
+	Stop(ctx context.Context, labID, nodeID models.UUID) error
+	Wipe(ctx context.Context, labID, nodeID models.UUID) error
+	StartNodes(ctx context.Context, labID models.UUID, nodeIDs []models.UUID, opts models.NodeBulkOptions) error
+	StopNodes(ctx context.Context, labID models.UUID, nodeIDs []models.UUID, opts models.NodeBulkOptions) error
+	WipeNodes(ctx context.Context, labID models.UUID, nodeIDs []models.UUID, opts models.NodeBulkOptions) error
+	DeleteNodes(ctx context.Context, labID models.UUID, nodeIDs []models.UUID, opts models.NodeBulkOptions) error
+	CreateNodes(ctx context.Context, nodes []models.Node, opts models.NodeBulkOptions) (models.NodeList, error)
+	Clone(ctx context.Context, labID, nodeID models.UUID, opts models.NodeCloneOptions) (models.NodeClone, error)
+	ExtractConfiguration(ctx context.Context, labID, nodeID models.UUID) (models.Node, error)
+	ExtractLabConfigurations(ctx context.Context, labID models.UUID, opts models.ConfigExtractOptions) (models.NodeMap, error)
 }
 
 // NodeService provides node-related operations
@@ -28,6 +44,12 @@ type NodeService struct {
 	apiClient       *api.Client
 	useNamedConfigs bool
 	excludeConfigs  *bool
+
+	// optional, used to copy the links of cloned nodes
+	Link LinkServiceInterface
+
+	// optional, used to validate node parameters before they are sent
+	NodeDefinition NodeDefinitionServiceInterface
 }
 
 // NewNodeService creates a new node service
@@ -81,6 +103,7 @@ type (
 		BootDiskSize    *int                     `json:"boot_disk_size,omitempty"`
 		Priority        *int                     `json:"priority,omitempty"`
 		PyATS           *models.PyAtsCredentials `json:"pyats,omitempty"`
+		Parameters      models.NodeParameters    `json:"parameters,omitempty"`
 		Tags            []string                 `json:"tags"`
 	}
 )
@@ -121,6 +144,7 @@ func newNodeAlias(node *models.Node, update bool) nodePatchPostAlias {
 		npp.DataVolume = node.DataVolume
 		npp.BootDiskSize = node.BootDiskSize
 		npp.ImageDefinition = node.ImageDefinition
+		npp.Parameters = node.NodeParameters()
 	}
 
 	// node definition can only be changed at create time (eg. POST)
@@ -172,6 +196,19 @@ func (s *NodeService) GetNodesForLab(ctx context.Context, labID models.UUID) (mo
 	return nodeMap, nil
 }
 
+// Find returns the nodes of the lab which match the query, sorted by label.
+// The nodes are fetched from the controller, use Lab.FindNodes or
+// NodeMap.Find for nodes which have been fetched already.
+//
+//	core, err := client.Node.Find(ctx, labID, models.NodeQuery{Tags: []string{"core"}})
+func (s *NodeService) Find(ctx context.Context, labID models.UUID, q models.NodeQuery) (models.NodeList, error) {
+	nodes, err := s.GetNodesForLab(ctx, labID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "find nodes in lab %s", labID)
+	}
+	return nodes.Find(q), nil
+}
+
 func (s *NodeService) setConfigData(ctx context.Context, node *models.Node, data any) error {
 	api := nodeURL(node.LabID, node.ID)
 
@@ -212,8 +249,19 @@ func (s *NodeService) SetNamedConfigs(ctx context.Context, node *models.Node, co
 }
 
 // Update updates the node specified by data in `node` (e.g. ID and LabID) with
-// the other data provided. It returns the updated node.
+// the other data provided. It returns the updated node. Parameters are
+// validated against the node definition if the node definition service is
+// set, see Create.
 func (s *NodeService) Update(ctx context.Context, node models.Node) (models.Node, error) {
+	if node.State == models.NodeStateDefined {
+		nodeDefs, err := s.nodeDefinitions(ctx, node)
+		if err == nil {
+			err = validateParameters(&node, nodeDefs)
+		}
+		if err != nil {
+			return models.Node{}, errors.Wrapf(err, "update node %s", node.Label)
+		}
+	}
 	api := nodeURL(node.LabID, node.ID)
 
 	postAlias := newNodeAlias(&node, true)
@@ -241,9 +289,25 @@ func (s *NodeService) Stop(ctx context.Context, labID, nodeID models.UUID) error
 
 // Create creates a new node on the controller based on the data provided
 // in `node`. Label, node definition and image definition must be provided.
+// If the node definition service is set, the parameters of the node are
+// validated against the parameters declared by its node definition and
+// returned as errors.ValidationErrors before anything is sent.
 func (s *NodeService) Create(ctx context.Context, node models.Node) (models.Node, error) {
+	nodeDefs, err := s.nodeDefinitions(ctx, node)
+	if err != nil {
+		return models.Node{}, errors.Wrapf(err, "create node %s", node.Label)
+	}
+	return s.create(ctx, node, nodeDefs)
+}
+
+// create creates the node, see Create. The parameters of the node are
+// validated against `nodeDefs`, see validateParameters.
+func (s *NodeService) create(ctx context.Context, node models.Node, nodeDefs models.NodeDefinitionMap) (models.Node, error) {
 	// TODO: inconsistent attributes lab_title vs title, ..
 	node.State = models.NodeStateDefined
+	if err := validateParameters(&node, nodeDefs); err != nil {
+		return models.Node{}, errors.Wrapf(err, "create node %s", node.Label)
+	}
 	postAlias := newNodeAlias(&node, false)
 
 	var newNode models.Node
@@ -293,7 +357,7 @@ func (s *NodeService) Create(ctx context.Context, node models.Node) (models.Node
 func (s *NodeService) GetByID(ctx context.Context, labID, id models.UUID) (models.Node, error) {
 	// SIMPLE-5052 -- results are different for simplified=true vs false for
 	// the inherited values. In the simplified case, all values are always
-	// null.
+	// null. Use models.ResourceResolver for the effective values.
 
 	var err error
 	var newNode models.Node
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+// StartNodes starts the given nodes of the lab concurrently, limited by
+// `opts.Parallelism`. A failure does not stop the other nodes, the failed
+// nodes are reported by a *models.NodeBulkError. The same applies to the
+// other bulk operations.
+//
+//	core, err := client.Node.Find(ctx, labID, models.NodeQuery{Tags: []string{"core"}})
+//	err = client.Node.StartNodes(ctx, labID, core.IDs(), models.NodeBulkOptions{})
+func (s *NodeService) StartNodes(ctx context.Context, labID models.UUID, nodeIDs []models.UUID, opts models.NodeBulkOptions) error {
+	return forEachNode(ctx, "start nodes", nodeIDs, opts, func(ctx context.Context, i int) error {
+		return s.Start(ctx, labID, nodeIDs[i])
+	})
+}
+
+// StopNodes stops the given nodes of the lab concurrently, see StartNodes.
+func (s *NodeService) StopNodes(ctx context.Context, labID models.UUID, nodeIDs []models.UUID, opts models.NodeBulkOptions) error {
+	return forEachNode(ctx, "stop nodes", nodeIDs, opts, func(ctx context.Context, i int) error {
+		return s.Stop(ctx, labID, nodeIDs[i])
+	})
+}
+
+// WipeNodes wipes the given nodes of the lab concurrently, see StartNodes.
+// The nodes must be stopped.
+func (s *NodeService) WipeNodes(ctx context.Context, labID models.UUID, nodeIDs []models.UUID, opts models.NodeBulkOptions) error {
+	return forEachNode(ctx, "wipe nodes", nodeIDs, opts, func(ctx context.Context, i int) error {
+		return s.Wipe(ctx, labID, nodeIDs[i])
+	})
+}
+
+// DeleteNodes deletes the given nodes of the lab concurrently, see
+// StartNodes. The nodes must be wiped.
+func (s *NodeService) DeleteNodes(ctx context.Context, labID models.UUID, nodeIDs []models.UUID, opts models.NodeBulkOptions) error {
+	return forEachNode(ctx, "delete nodes", nodeIDs, opts, func(ctx context.Context, i int) error {
+		return s.Delete(ctx, labID, nodeIDs[i])
+	})
+}
+
+// CreateNodes creates the given nodes concurrently, limited by
+// `opts.Parallelism`. The created nodes are returned in the order of
+// `nodes`, without the nodes which could not be created. These are reported
+// by a *models.NodeBulkError, keyed by their index in `nodes`. The node
+// definitions to validate parameters against are fetched once for all nodes.
+func (s *NodeService) CreateNodes(ctx context.Context, nodes []models.Node, opts models.NodeBulkOptions) (models.NodeList, error) {
+	nodeDefs, err := s.nodeDefinitions(ctx, nodes...)
+	if err != nil {
+		return models.NodeList{}, errors.Wrap(err, "create nodes")
+	}
+
+	created := make([]*models.Node, len(nodes))
+	errs := forEach(ctx, len(nodes), opts, func(ctx context.Context, i int) error {
+		node, err := s.create(ctx, nodes[i], nodeDefs)
+		if err != nil {
+			return errors.Wrapf(err, "node %q", nodes[i].Label)
+		}
+		created[i] = &node
+		return nil
+	})
+
+	result := models.NodeList{}
+	for _, node := range created {
+		if node != nil {
+			result = append(result, node)
+		}
+	}
+	if len(errs) > 0 {
+		return result, &models.NodeBulkError{Op: "create nodes", Total: len(nodes), InputErrors: errs}
+	}
+	return result, nil
+}
+
+// forEachNode runs `fn` with the index of each node in `nodeIDs`, see
+// forEach. The errors are returned as *models.NodeBulkError keyed by node
+// ID, nil if all calls succeeded.
+func forEachNode(ctx context.Context, op string, nodeIDs []models.UUID, opts models.NodeBulkOptions, fn func(ctx context.Context, i int) error) error {
+	errs := forEach(ctx, len(nodeIDs), opts, fn)
+	if len(errs) == 0 {
+		return nil
+	}
+	byID := make(map[models.UUID]error, len(errs))
+	for i, err := range errs {
+		byID[nodeIDs[i]] = err
+	}
+	return &models.NodeBulkError{Op: op, Total: len(nodeIDs), Errors: byID}
+}
+
+// forEach runs `fn` with the indices 0 to n-1 concurrently, limited by
+// `opts.Parallelism`. The errors are returned keyed by index.
+func forEach(ctx context.Context, n int, opts models.NodeBulkOptions, fn func(ctx context.Context, i int) error) map[int]error {
+	parallelism := opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = models.DefaultBulkParallelism
+	}
+
+	var (
+		mu   sync.Mutex
+		errs = make(map[int]error)
+	)
+	// errors are collected, they must not cancel the other nodes
+	var g errgroup.Group
+	g.SetLimit(parallelism)
+	for i := range n {
+		g.Go(func() error {
+			if err := fn(ctx, i); err != nil {
+				mu.Lock()
+				errs[i] = err
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return errs
+}
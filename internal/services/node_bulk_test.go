@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rschmied/gocmlclient/internal/testutil"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+func TestNodeBulk_Start(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	var (
+		mu       sync.Mutex
+		inFlight int
+		maxLoad  int
+	)
+	httpmock.RegisterResponder("PUT", `=~^https://mock/api/v0/labs/lab1/nodes/(\w+)/state/start`,
+		func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			inFlight++
+			maxLoad = max(maxLoad, inFlight)
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+
+			if httpmock.MustGetSubmatch(req, 1) == "n3" {
+				return httpmock.NewStringResponse(409, `{"description": "no image"}`), nil
+			}
+			return httpmock.NewStringResponse(204, ""), nil
+		})
+
+	service := NewNodeService(client, false)
+	ids := []models.UUID{"n1", "n2", "n3", "n4", "n5"}
+	err := service.StartNodes(context.Background(), "lab1", ids, models.NodeBulkOptions{Parallelism: 2})
+
+	var bulkErr *models.NodeBulkError
+	if assert.ErrorAs(t, err, &bulkErr) {
+		assert.Equal(t, []models.UUID{"n3"}, bulkErr.Failed())
+		assert.Equal(t, 5, bulkErr.Total)
+		assert.Contains(t, bulkErr.Errors["n3"].Error(), "no image")
+		assert.Contains(t, err.Error(), "start nodes: 1 of 5 nodes failed: n3: ")
+	}
+	assert.LessOrEqual(t, maxLoad, 2)
+	assert.Equal(t, 5, httpmock.GetTotalCallCount())
+}
+
+func TestNodeBulk_StopWipeDelete(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("PUT", `=~^https://mock/api/v0/labs/lab1/nodes/\w+/state/stop`,
+		httpmock.NewStringResponder(204, ""))
+	httpmock.RegisterResponder("PUT", `=~^https://mock/api/v0/labs/lab1/nodes/\w+/wipe_disks`,
+		httpmock.NewStringResponder(204, ""))
+	httpmock.RegisterResponder("DELETE", `=~^https://mock/api/v0/labs/lab1/nodes/\w+`,
+		httpmock.NewStringResponder(204, ""))
+
+	service := NewNodeService(client, false)
+	ctx := context.Background()
+	ids := []models.UUID{"n1", "n2"}
+
+	assert.NoError(t, service.StopNodes(ctx, "lab1", ids, models.NodeBulkOptions{}))
+	assert.NoError(t, service.WipeNodes(ctx, "lab1", ids, models.NodeBulkOptions{}))
+	assert.NoError(t, service.DeleteNodes(ctx, "lab1", ids, models.NodeBulkOptions{}))
+	assert.NoError(t, service.DeleteNodes(ctx, "lab1", nil, models.NodeBulkOptions{}))
+	assert.Equal(t, 6, httpmock.GetTotalCallCount())
+}
+
+func TestNodeBulk_Create(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("POST", "https://mock/api/v0/labs/lab1/nodes",
+		func(req *http.Request) (*http.Response, error) {
+			b, _ := io.ReadAll(req.Body)
+			var node struct {
+				Label string `json:"label"`
+			}
+			_ = json.Unmarshal(b, &node)
+			if node.Label == "bad" {
+				return httpmock.NewStringResponse(400, `{"description": "invalid node definition"}`), nil
+			}
+			return httpmock.NewStringResponse(200, `{"id": "id-`+node.Label+`"}`), nil
+		})
+	httpmock.RegisterResponder("PATCH", `=~^https://mock/api/v0/labs/lab1/nodes/id-\w+`,
+		httpmock.NewStringResponder(200, `"ok"`))
+	httpmock.RegisterResponder("GET", `=~^https://mock/api/v0/labs/lab1/nodes/id-(\w+)`,
+		func(req *http.Request) (*http.Response, error) {
+			label := httpmock.MustGetSubmatch(req, 1)
+			return httpmock.NewStringResponse(200,
+				`{"id": "id-`+label+`", "lab_id": "lab1", "label": "`+label+`", "node_definition": "iosv", "state": "DEFINED_ON_CORE"}`), nil
+		})
+
+	var nodes []models.Node
+	// failing nodes with the same label are reported separately
+	for _, label := range strings.Fields("r1 r2 bad r3 bad") {
+		nodes = append(nodes, models.Node{LabID: "lab1", Label: label, NodeDefinition: "iosv"})
+	}
+
+	service := NewNodeService(client, false)
+	created, err := service.CreateNodes(context.Background(), nodes, models.NodeBulkOptions{Parallelism: 3})
+	assert.Equal(t, []string{"r1", "r2", "r3"}, created.Labels())
+	assert.Equal(t, []models.UUID{"id-r1", "id-r2", "id-r3"}, created.IDs())
+
+	var bulkErr *models.NodeBulkError
+	if assert.ErrorAs(t, err, &bulkErr) {
+		assert.Empty(t, bulkErr.Failed())
+		assert.Equal(t, []int{2, 4}, bulkErr.FailedInputs())
+		assert.Equal(t, 5, bulkErr.Total)
+		assert.Contains(t, bulkErr.Error(), `create nodes: 2 of 5 nodes failed: #2: node "bad": `)
+		assert.Contains(t, bulkErr.Error(), `; #4: node "bad": `)
+	}
+}
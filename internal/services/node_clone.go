@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+// Clone copies the node identified by `nodeID` within its lab. The copy
+// gets the label and position from `opts` and the node definition, image,
+// resources, tags, parameters and configuration (named or not) of the
+// source node. The links of the source node are re-created for the copy if
+// requested, this requires the link service.
+//
+// If the copy can't be configured, it is removed again. If a link can't be
+// created, the copy and the links created so far are returned with the
+// error.
+func (s *NodeService) Clone(ctx context.Context, labID, nodeID models.UUID, opts models.NodeCloneOptions) (models.NodeClone, error) {
+	if len(opts.Label) == 0 {
+		return models.NodeClone{}, errors.Wrap(errors.ErrMissingRequired, "clone node: label")
+	}
+	if opts.CopyLinks && s.Link == nil {
+		return models.NodeClone{}, errors.Wrap(errors.ErrMissingRequired, "clone node: link service")
+	}
+
+	src, err := s.GetByID(ctx, labID, nodeID)
+	if err != nil {
+		return models.NodeClone{}, errors.Wrapf(err, "clone node %s", nodeID)
+	}
+
+	node := models.Node{
+		LabID:           labID,
+		Label:           opts.Label,
+		X:               opts.X,
+		Y:               opts.Y,
+		NodeDefinition:  src.NodeDefinition,
+		ImageDefinition: src.ImageDefinition,
+		CPUs:            src.CPUs,
+		RAM:             src.RAM,
+		CPUlimit:        src.CPUlimit,
+		DataVolume:      src.DataVolume,
+		BootDiskSize:    src.BootDiskSize,
+		HideLinks:       src.HideLinks,
+		Priority:        src.Priority,
+		Tags:            slices.Clone(src.Tags),
+		Parameters:      src.NodeParameters(),
+		Configurations:  slices.Clone(src.Configurations),
+	}
+	if config, ok := src.Configuration.(*string); ok && config != nil {
+		node.Configuration = *config
+	}
+
+	clone, err := s.Create(ctx, node)
+	if err != nil {
+		return models.NodeClone{}, errors.Wrapf(err, "clone node %s", src.Label)
+	}
+
+	result := models.NodeClone{Node: clone}
+	if !opts.CopyLinks {
+		return result, nil
+	}
+
+	links, err := s.Link.GetLinksForLab(ctx, labID)
+	if err != nil {
+		return result, errors.Wrapf(err, "clone links of node %s", src.Label)
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].ID < links[j].ID })
+	for _, link := range links {
+		var peer models.UUID
+		switch nodeID {
+		case link.SrcNode:
+			peer = link.DstNode
+		case link.DstNode:
+			peer = link.SrcNode
+		default:
+			continue
+		}
+		if peer == nodeID {
+			continue
+		}
+		newLink, err := s.Link.Create(ctx, models.Link{
+			LabID:   labID,
+			SrcNode: clone.ID,
+			DstNode: peer,
+			SrcSlot: -1,
+			DstSlot: -1,
+		})
+		if err != nil {
+			return result, errors.Wrapf(err, "clone link %s of node %s", link.ID, src.Label)
+		}
+		result.Links = append(result.Links, newLink)
+	}
+	return result, nil
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rschmied/gocmlclient/internal/api"
+	"github.com/rschmied/gocmlclient/internal/testutil"
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+const cloneMockURL = "https://mock/api/v0/labs/lab1/"
+
+func newNodeCloneTestService(client *api.Client, namedConfigs bool) *NodeService {
+	nodeService := NewNodeService(client, namedConfigs)
+	linkService := NewLinkService(client)
+	linkService.Interface = NewInterfaceService(client)
+	linkService.Node = nodeService
+	nodeService.Link = linkService
+	return nodeService
+}
+
+// registerNodeClone registers the responders for the creation of the copy
+// "n9", the posted node is returned via `posted`.
+func registerNodeClone(posted *map[string]any) {
+	httpmock.RegisterResponder("POST", cloneMockURL+"nodes",
+		func(req *http.Request) (*http.Response, error) {
+			b, _ := io.ReadAll(req.Body)
+			_ = json.Unmarshal(b, posted)
+			return httpmock.NewStringResponse(200, `{"id": "n9"}`), nil
+		})
+	httpmock.RegisterResponder("PATCH", cloneMockURL+"nodes/n9",
+		httpmock.NewStringResponder(200, `"n9"`))
+	httpmock.RegisterResponder("GET", cloneMockURL+"nodes/n9",
+		httpmock.NewStringResponder(200, `{"id": "n9", "lab_id": "lab1", "label": "spoke-9", "node_definition": "iosv", "x": 300, "y": 100, "tags": ["spoke"]}`))
+}
+
+func TestNodeClone(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("GET", cloneMockURL+"nodes/n1",
+		httpmock.NewStringResponder(200, `{
+			"id": "n1", "lab_id": "lab1", "label": "spoke-1", "node_definition": "iosv", "image_definition": "iosv-159",
+			"x": 0, "y": 100, "cpus": 1, "ram": 512, "tags": ["spoke"], "state": "BOOTED", "configuration": "hostname spoke-1"
+		}`))
+	var posted map[string]any
+	registerNodeClone(&posted)
+
+	httpmock.RegisterResponder("GET", cloneMockURL+"links",
+		httpmock.NewStringResponder(200, `[
+			{"id": "l1", "lab_id": "lab1", "node_a": "n1", "node_b": "hub"},
+			{"id": "l2", "lab_id": "lab1", "node_a": "ext", "node_b": "n1"},
+			{"id": "l3", "lab_id": "lab1", "node_a": "hub", "node_b": "ext"}
+		]`))
+	httpmock.RegisterResponder("GET", `=~^`+cloneMockURL+`nodes/\w+/interfaces`,
+		httpmock.NewStringResponder(200, `[{"id": "i0", "slot": 0, "type": "physical", "is_connected": true}]`))
+	interfaces := 0
+	httpmock.RegisterResponder("POST", cloneMockURL+"interfaces",
+		func(req *http.Request) (*http.Response, error) {
+			interfaces++
+			return httpmock.NewStringResponse(200, `{"id": "new-i"}`), nil
+		})
+	httpmock.RegisterResponder("POST", cloneMockURL+"links",
+		httpmock.NewStringResponder(200, `{"id": "new-l"}`))
+	httpmock.RegisterResponder("GET", cloneMockURL+"links/new-l",
+		httpmock.NewStringResponder(200, `{"id": "new-l", "lab_id": "lab1", "node_a": "n9", "node_b": "hub"}`))
+
+	service := newNodeCloneTestService(client, false)
+	clone, err := service.Clone(context.Background(), "lab1", "n1", models.NodeCloneOptions{
+		Label: "spoke-9", X: 300, Y: 100, CopyLinks: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, models.UUID("n9"), clone.Node.ID)
+	assert.Len(t, clone.Links, 2)
+
+	assert.Equal(t, "spoke-9", posted["label"])
+	assert.Equal(t, "iosv", posted["node_definition"])
+	assert.Equal(t, "iosv-159", posted["image_definition"])
+	assert.Equal(t, float64(512), posted["ram"])
+	assert.Equal(t, float64(300), posted["x"])
+	assert.Equal(t, []any{"spoke"}, posted["tags"])
+	assert.Equal(t, "hostname spoke-1", posted["configuration"])
+
+	// all interfaces are in use: one new interface per end and link
+	assert.Equal(t, 4, interfaces)
+	assert.Equal(t, 2, httpmock.GetCallCountInfo()["POST "+cloneMockURL+"links"])
+}
+
+func TestNodeClone_NamedConfigs(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("GET", cloneMockURL+"nodes/n1",
+		httpmock.NewStringResponder(200, `{
+			"id": "n1", "lab_id": "lab1", "label": "srv", "node_definition": "ubuntu", "x": 0, "y": 0,
+			"configuration": [{"name": "user-data", "content": "#cloud-config"}]
+		}`))
+	var posted map[string]any
+	registerNodeClone(&posted)
+
+	var patched []string
+	httpmock.RegisterResponder("PATCH", cloneMockURL+"nodes/n9",
+		func(req *http.Request) (*http.Response, error) {
+			b, _ := io.ReadAll(req.Body)
+			patched = append(patched, string(b))
+			return httpmock.NewStringResponse(200, `"n9"`), nil
+		})
+
+	service := newNodeCloneTestService(client, true)
+	clone, err := service.Clone(context.Background(), "lab1", "n1", models.NodeCloneOptions{Label: "srv-2"})
+	assert.NoError(t, err)
+	assert.Empty(t, clone.Links)
+	// named configurations are part of the create, no extra update
+	assert.Equal(t, []any{map[string]any{"name": "user-data", "content": "#cloud-config"}}, posted["configuration"])
+	if assert.Len(t, patched, 1) {
+		assert.Contains(t, patched[0], `"configuration":[{"name":"user-data","content":"#cloud-config"}]`)
+	}
+	assert.Equal(t, 0, httpmock.GetCallCountInfo()["GET "+cloneMockURL+"links"])
+}
+
+func TestNodeClone_Errors(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	service := NewNodeService(client, false)
+	ctx := context.Background()
+
+	_, err := service.Clone(ctx, "lab1", "n1", models.NodeCloneOptions{})
+	assert.ErrorIs(t, err, errors.ErrMissingRequired)
+	_, err = service.Clone(ctx, "lab1", "n1", models.NodeCloneOptions{Label: "copy", CopyLinks: true})
+	assert.ErrorIs(t, err, errors.ErrMissingRequired)
+
+	httpmock.RegisterResponder("GET", cloneMockURL+"nodes/missing",
+		httpmock.NewStringResponder(404, `{"description": "Node not found"}`))
+	_, err = service.Clone(ctx, "lab1", "missing", models.NodeCloneOptions{Label: "copy"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "clone node missing")
+}
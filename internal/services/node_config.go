@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+const extractConfigAction = "extract_configuration"
+
+// ExtractConfiguration asks the controller to pull the running
+// configuration out of the booted node and store it as the node's
+// configuration. The updated node is returned, with named configurations
+// if these are enabled for the service.
+func (s *NodeService) ExtractConfiguration(ctx context.Context, labID, nodeID models.UUID) (models.Node, error) {
+	api := fmt.Sprintf("%s/%s", nodeURL(labID, nodeID), extractConfigAction)
+	if err := s.apiClient.PutJSON(ctx, api, nil); err != nil {
+		return models.Node{}, errors.Wrapf(err, "extract configuration of node %s", nodeID)
+	}
+	return s.GetByID(ctx, labID, nodeID)
+}
+
+// ExtractLabConfigurations extracts the configurations of all booted nodes
+// of the lab concurrently, limited by `opts.Parallelism`. Nodes which are
+// not booted are skipped. The updated nodes are returned even if the
+// extraction failed for some of the nodes, the errors of these are
+// returned joined.
+func (s *NodeService) ExtractLabConfigurations(ctx context.Context, labID models.UUID, opts models.ConfigExtractOptions) (models.NodeMap, error) {
+	nodes, err := s.GetNodesForLab(ctx, labID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "extract configurations of lab %s", labID)
+	}
+
+	parallelism := opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = models.DefaultExtractParallelism
+	}
+
+	var (
+		mu     sync.Mutex
+		result = make(models.NodeMap)
+		errs   []error
+	)
+	// errors are collected, they must not cancel the other extractions
+	var g errgroup.Group
+	g.SetLimit(parallelism)
+	for _, node := range nodes {
+		if node.State != models.NodeStateBooted {
+			continue
+		}
+		g.Go(func() error {
+			updated, err := s.ExtractConfiguration(ctx, labID, node.ID)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "node %s", node.Label))
+				return nil
+			}
+			result[updated.ID] = &updated
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return result, stderrors.Join(errs...)
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rschmied/gocmlclient/internal/testutil"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+func TestNodeExtractConfiguration(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("PUT", "https://mock/api/v0/labs/lab1/nodes/n1/extract_configuration",
+		httpmock.NewStringResponder(200, `"Success"`))
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab1/nodes/n1",
+		httpmock.NewStringResponder(200, `{"id":"n1","label":"r1","state":"BOOTED","configuration":[{"name":"ios_config.txt","content":"hostname r1"}]}`))
+
+	service := NewNodeService(client, true)
+	node, err := service.ExtractConfiguration(context.Background(), "lab1", "n1")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.NodeConfig{{Name: "ios_config.txt", Content: "hostname r1"}}, node.Configurations)
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["PUT https://mock/api/v0/labs/lab1/nodes/n1/extract_configuration"])
+}
+
+func TestNodeExtractLabConfigurations(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab1/nodes",
+		httpmock.NewStringResponder(200, `[
+			{"id":"n1","label":"r1","state":"BOOTED"},
+			{"id":"n2","label":"r2","state":"BOOTED"},
+			{"id":"n3","label":"r3","state":"BOOTED"},
+			{"id":"n4","label":"r4","state":"STOPPED"}
+		]`))
+
+	var (
+		mu          sync.Mutex
+		running     int
+		maxParallel int
+	)
+	httpmock.RegisterResponder("PUT", `=~^https://mock/api/v0/labs/lab1/nodes/([^/]+)/extract_configuration\z`,
+		func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			running++
+			maxParallel = max(maxParallel, running)
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+
+			if httpmock.MustGetSubmatch(req, 1) == "n2" {
+				return httpmock.NewStringResponse(400, `{"description": "node does not support configuration extraction"}`), nil
+			}
+			return httpmock.NewStringResponse(200, `"Success"`), nil
+		})
+	httpmock.RegisterResponder("GET", `=~^https://mock/api/v0/labs/lab1/nodes/([^/]+)\z`,
+		func(req *http.Request) (*http.Response, error) {
+			id := httpmock.MustGetSubmatch(req, 1)
+			return httpmock.NewStringResponse(200, `{"id":"`+id+`","state":"BOOTED","configuration":"hostname `+id+`"}`), nil
+		})
+
+	service := NewNodeService(client, false)
+	nodes, err := service.ExtractLabConfigurations(context.Background(), "lab1", models.ConfigExtractOptions{Parallelism: 2})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "node r2")
+
+	assert.Len(t, nodes, 2)
+	for _, id := range []models.UUID{"n1", "n3"} {
+		if assert.Contains(t, nodes, id) {
+			cfg, ok := nodes[id].Configuration.(*string)
+			assert.True(t, ok)
+			assert.Equal(t, "hostname "+string(id), *cfg)
+		}
+	}
+	assert.LessOrEqual(t, maxParallel, 2)
+
+	calls := httpmock.GetCallCountInfo()
+	assert.Equal(t, 0, calls["PUT https://mock/api/v0/labs/lab1/nodes/n4/extract_configuration"])
+}
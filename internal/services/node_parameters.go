@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+// nodeDefinitions returns the node definitions needed to validate the
+// parameters of `nodes`. They are only fetched if the node definition
+// service is set and any of the nodes has parameters to check, otherwise
+// nil is returned.
+func (s *NodeService) nodeDefinitions(ctx context.Context, nodes ...models.Node) (models.NodeDefinitionMap, error) {
+	if s.NodeDefinition == nil {
+		return nil, nil
+	}
+	for idx := range nodes {
+		if checkParameters(&nodes[idx]) {
+			nodeDefs, err := s.NodeDefinition.NodeDefinitions(ctx)
+			if err != nil {
+				return nil, errors.Wrap(err, "get node definitions")
+			}
+			return nodeDefs, nil
+		}
+	}
+	return nil, nil
+}
+
+// validateParameters checks the parameters of the node against its node
+// definition in `nodeDefs` before they are sent. Without node definitions
+// (see nodeDefinitions) or without parameters nothing is checked. Nodes
+// without a node definition (e.g. partial updates) are not checked either.
+func validateParameters(node *models.Node, nodeDefs models.NodeDefinitionMap) error {
+	if nodeDefs == nil || !checkParameters(node) {
+		return nil
+	}
+	nodeDef, found := nodeDefs[models.UUID(node.NodeDefinition)]
+	if !found {
+		return errors.Wrapf(errors.ErrElementNotFound, "node definition %q", node.NodeDefinition)
+	}
+	return node.NodeParameters().Validate(nodeDef).Err()
+}
+
+// checkParameters returns true if the node has parameters which can be
+// checked against its node definition.
+func checkParameters(node *models.Node) bool {
+	return len(node.NodeParameters()) > 0 && len(node.NodeDefinition) > 0
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rschmied/gocmlclient/internal/testutil"
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+func TestNodeParameters(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/simplified_node_definitions",
+		httpmock.NewStringResponder(200, `[{
+			"id": "ubuntu",
+			"sim": {"parameters": {"smbios.bios.vendor": "", "smbios.system.product": ""}}
+		}]`))
+	var posted []map[string]any
+	record := func(status int, body string) httpmock.Responder {
+		return func(req *http.Request) (*http.Response, error) {
+			b, _ := io.ReadAll(req.Body)
+			var data map[string]any
+			_ = json.Unmarshal(b, &data)
+			posted = append(posted, data)
+			return httpmock.NewStringResponse(status, body), nil
+		}
+	}
+	httpmock.RegisterResponder("POST", "https://mock/api/v0/labs/lab1/nodes", record(200, `{"id": "n1"}`))
+	httpmock.RegisterResponder("PATCH", "https://mock/api/v0/labs/lab1/nodes/n1", record(200, `"n1"`))
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab1/nodes/n1",
+		httpmock.NewStringResponder(200, `{"id": "n1", "lab_id": "lab1", "label": "srv", "node_definition": "ubuntu",
+			"state": "DEFINED_ON_CORE", "parameters": {"smbios.bios.vendor": "Cisco"}}`))
+
+	service := NewNodeService(client, false)
+	service.NodeDefinition = NewNodeDefinitionService(client)
+	ctx := context.Background()
+
+	var params models.NodeParameters
+	params.SetSMBIOS(models.SMBIOS{BIOSVendor: "Cisco"})
+	node, err := service.Create(ctx, models.Node{LabID: "lab1", Label: "srv", NodeDefinition: "ubuntu", Parameters: params})
+	assert.NoError(t, err)
+	assert.Equal(t, "Cisco", node.NodeParameters().SMBIOS().BIOSVendor)
+	if assert.Len(t, posted, 2) {
+		assert.Equal(t, map[string]any{"smbios.bios.vendor": "Cisco"}, posted[0]["parameters"])
+		assert.Equal(t, map[string]any{"smbios.bios.vendor": "Cisco"}, posted[1]["parameters"])
+	}
+
+	// bad parameters are rejected before anything is sent
+	params = node.NodeParameters()
+	params.SetSMBIOS(models.SMBIOS{SystemSerial: "FCH1234"})
+	node.Parameters = params
+	_, err = service.Update(ctx, node)
+	var verrs errors.ValidationErrors
+	if assert.ErrorAs(t, err, &verrs) && assert.Len(t, verrs, 1) {
+		assert.Equal(t, "parameters.smbios.system.serial", verrs[0].Field)
+	}
+	assert.ErrorIs(t, err, errors.ErrInvalidInput)
+	assert.Contains(t, err.Error(), "update node srv")
+
+	_, err = service.Create(ctx, models.Node{LabID: "lab1", Label: "bad", NodeDefinition: "ubuntu", Parameters: params})
+	assert.ErrorIs(t, err, errors.ErrInvalidInput)
+	_, err = service.Create(ctx, models.Node{LabID: "lab1", Label: "bad", NodeDefinition: "unknown", Parameters: params})
+	assert.ErrorIs(t, err, errors.ErrElementNotFound)
+	assert.Len(t, posted, 2)
+
+	// the node definitions are fetched once for all nodes of a bulk create
+	nodeDefsAPI := "GET https://mock/api/v0/simplified_node_definitions"
+	fetched := httpmock.GetCallCountInfo()[nodeDefsAPI]
+	params = models.NodeParameters{}
+	params.SetSMBIOS(models.SMBIOS{BIOSVendor: "Cisco"})
+	nodes := []models.Node{
+		{LabID: "lab1", Label: "srv1", NodeDefinition: "ubuntu", Parameters: params},
+		{LabID: "lab1", Label: "srv2", NodeDefinition: "ubuntu", Parameters: params},
+		{LabID: "lab1", Label: "srv3", NodeDefinition: "ubuntu", Parameters: params},
+	}
+	created, err := service.CreateNodes(ctx, nodes, models.NodeBulkOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, created, 3)
+	assert.Equal(t, fetched+1, httpmock.GetCallCountInfo()[nodeDefsAPI])
+}
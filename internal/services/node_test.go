@@ -421,6 +421,39 @@ func TestNodeStateOperations(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestNodeFind(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := initNodeTest(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab-123/nodes",
+		httpmock.NewStringResponder(200, `[
+			{"id":"n1","label":"r1","node_definition":"iosv","tags":["core"],"state":"BOOTED"},
+			{"id":"n2","label":"r2","node_definition":"iosv","tags":["edge"],"state":"STOPPED"},
+			{"id":"n3","label":"srv","node_definition":"alpine","tags":["core"],"state":"DEFINED_ON_CORE"}
+		]`))
+
+	service := NewNodeService(client, false)
+	ctx := context.Background()
+
+	nodes, err := service.Find(ctx, "lab-123", models.NodeQuery{Tags: []string{"core"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.UUID{"n1", "n3"}, nodes.IDs())
+
+	nodes, err = service.Find(ctx, "lab-123", models.NodeQuery{NodeDefinitions: []string{"iosv"}, States: []models.NodeState{models.NodeStateStopped}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"r2"}, nodes.Labels())
+
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/missing/nodes",
+		httpmock.NewStringResponder(404, `{"description": "Lab not found"}`))
+	_, err = service.Find(ctx, "missing", models.NodeQuery{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "find nodes in lab missing")
+}
+
 func TestNodeSetConfig(t *testing.T) {
 	if testutil.IsLiveTesting() {
 		t.Skip("Skipping on live server - test expects specific mock data")
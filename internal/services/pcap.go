@@ -0,0 +1,126 @@
+// Package services, packet capture specific
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/rschmied/gocmlclient/internal/api"
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+const (
+	captureAPI = "capture"
+	pcapAPI    = "pcap"
+)
+
+// PCAPService provides packet captures on links. The controller keeps one
+// capture per link, it is identified by the link's capture key.
+type PCAPService struct {
+	apiClient *api.Client
+}
+
+// Ensure PCAPService implements interface
+var _ PCAPServiceInterface = (*PCAPService)(nil)
+
+// PCAPServiceInterface defines methods needed by other services/clients.
+type PCAPServiceInterface interface {
+	Start(ctx context.Context, labID, linkID models.UUID, config models.PCAPConfig) error
+	Stop(ctx context.Context, labID, linkID models.UUID) error
+	Status(ctx context.Context, labID, linkID models.UUID) (models.PCAPStatus, error)
+	Key(ctx context.Context, labID, linkID models.UUID) (models.UUID, error)
+	Packets(ctx context.Context, labID, linkID models.UUID) ([]models.PCAPPacket, error)
+	Download(ctx context.Context, labID, linkID models.UUID, w io.Writer) (int64, error)
+}
+
+// NewPCAPService creates a new packet capture service
+func NewPCAPService(apiClient *api.Client) *PCAPService {
+	return &PCAPService{
+		apiClient: apiClient,
+	}
+}
+
+// linkCaptureURL builds URL for link capture operations
+func linkCaptureURL(labID, linkID models.UUID, op string) string {
+	return fmt.Sprintf("%s/%s/%s", linkURL(labID, linkID), captureAPI, op)
+}
+
+// Start starts a packet capture on the link as defined by `config`. A
+// previous capture of the link is discarded. The link must be started.
+func (s *PCAPService) Start(ctx context.Context, labID, linkID models.UUID, config models.PCAPConfig) error {
+	if config.MaxPackets <= 0 && config.MaxTime <= 0 {
+		return errors.Wrap(errors.ErrMissingRequired, "start capture: packet count or duration")
+	}
+	if err := s.apiClient.PutJSON(ctx, linkCaptureURL(labID, linkID, "start"), config); err != nil {
+		return errors.Wrapf(err, "start capture on link %s", linkID)
+	}
+	return nil
+}
+
+// Stop stops the packet capture on the link, the captured packets are
+// kept.
+func (s *PCAPService) Stop(ctx context.Context, labID, linkID models.UUID) error {
+	if err := s.apiClient.PutJSON(ctx, linkCaptureURL(labID, linkID, "stop"), nil); err != nil {
+		return errors.Wrapf(err, "stop capture on link %s", linkID)
+	}
+	return nil
+}
+
+// Status returns the status of the packet capture on the link.
+func (s *PCAPService) Status(ctx context.Context, labID, linkID models.UUID) (models.PCAPStatus, error) {
+	var status models.PCAPStatus
+	if err := s.apiClient.GetJSON(ctx, linkCaptureURL(labID, linkID, "status"), nil, &status); err != nil {
+		return models.PCAPStatus{}, errors.Wrapf(err, "capture status of link %s", linkID)
+	}
+	return status, nil
+}
+
+// Key returns the capture key of the link, it identifies the captured
+// packets. The key is also available as Link.PCAPkey.
+func (s *PCAPService) Key(ctx context.Context, labID, linkID models.UUID) (models.UUID, error) {
+	var key models.UUID
+	if err := s.apiClient.GetJSON(ctx, linkCaptureURL(labID, linkID, "key"), nil, &key); err != nil {
+		return "", errors.Wrapf(err, "capture key of link %s", linkID)
+	}
+	if len(key) == 0 {
+		return "", errors.Wrapf(errors.ErrElementNotFound, "capture key of link %s", linkID)
+	}
+	return key, nil
+}
+
+// Packets returns the summaries of the packets captured on the link.
+func (s *PCAPService) Packets(ctx context.Context, labID, linkID models.UUID) ([]models.PCAPPacket, error) {
+	key, err := s.Key(ctx, labID, linkID)
+	if err != nil {
+		return nil, err
+	}
+	var packets []models.PCAPPacket
+	api := fmt.Sprintf("%s/%s/packets", pcapAPI, key)
+	if err := s.apiClient.GetJSON(ctx, api, nil, &packets); err != nil {
+		return nil, errors.Wrapf(err, "captured packets of link %s", linkID)
+	}
+	return packets, nil
+}
+
+// Download writes the packets captured on the link in pcap format to `w`
+// and returns the number of bytes written. This works while the capture is
+// running, too. The HTTP client timeout does not apply to the download, use
+// the context to limit it.
+func (s *PCAPService) Download(ctx context.Context, labID, linkID models.UUID, w io.Writer) (int64, error) {
+	key, err := s.Key(ctx, labID, linkID)
+	if err != nil {
+		return 0, err
+	}
+	body, err := s.apiClient.GetStream(ctx, fmt.Sprintf("%s/%s", pcapAPI, key), nil)
+	if err != nil {
+		return 0, errors.Wrapf(err, "download capture of link %s", linkID)
+	}
+	defer body.Close() //nolint:errcheck
+	n, err := io.Copy(w, body)
+	if err != nil {
+		return n, errors.Wrapf(err, "download capture of link %s", linkID)
+	}
+	return n, nil
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rschmied/gocmlclient/internal/testutil"
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+const captureMockURL = "https://mock/api/v0/labs/lab1/links/link1/capture/"
+
+func TestPCAPService_StartStop(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	var started models.PCAPConfig
+	httpmock.RegisterResponder("PUT", captureMockURL+"start",
+		func(req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			if err := json.Unmarshal(body, &started); err != nil {
+				return httpmock.NewStringResponse(400, `{"description": "bad request"}`), nil
+			}
+			return httpmock.NewStringResponse(200, `"capture-key"`), nil
+		})
+	httpmock.RegisterResponder("PUT", captureMockURL+"stop",
+		httpmock.NewStringResponder(204, ""))
+	httpmock.RegisterResponder("GET", captureMockURL+"status",
+		httpmock.NewStringResponder(200, `{
+			"config": {"maxpackets": 100, "maxtime": 30, "bpfilter": "icmp", "encap": "ethernet"},
+			"starttime": "2024-01-01T10:00:00+00:00",
+			"packetscaptured": 12,
+			"running": true
+		}`))
+
+	service := NewPCAPService(client)
+	ctx := context.Background()
+
+	config := models.PCAPConfig{MaxPackets: 100, MaxTime: 30, BPFilter: "icmp"}
+	assert.NoError(t, service.Start(ctx, "lab1", "link1", config))
+	assert.Equal(t, config, started)
+
+	status, err := service.Status(ctx, "lab1", "link1")
+	assert.NoError(t, err)
+	assert.True(t, status.Running)
+	assert.Equal(t, 12, status.Packets)
+	assert.Equal(t, "icmp", status.Config.BPFilter)
+
+	assert.NoError(t, service.Stop(ctx, "lab1", "link1"))
+
+	// at least one limit is required
+	err = service.Start(ctx, "lab1", "link1", models.PCAPConfig{BPFilter: "icmp"})
+	assert.ErrorIs(t, err, errors.ErrMissingRequired)
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["PUT "+captureMockURL+"start"])
+}
+
+func TestPCAPService_StartError(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("PUT", captureMockURL+"start",
+		httpmock.NewStringResponder(400, `{"description": "Link is not started"}`))
+
+	service := NewPCAPService(client)
+	err := service.Start(context.Background(), "lab1", "link1", models.PCAPConfig{MaxPackets: 10})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "start capture on link link1")
+}
+
+func TestPCAPService_Packets(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	httpmock.RegisterResponder("GET", captureMockURL+"key",
+		httpmock.NewStringResponder(200, `"capture-key"`))
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/pcap/capture-key/packets",
+		httpmock.NewStringResponder(200, `[
+			{"packet_number": 1, "timestamp": "0.000000", "source": "10.0.0.1", "destination": "10.0.0.2", "protocol": "ICMP", "length": 98, "info": "Echo (ping) request"},
+			{"packet_number": 2, "timestamp": "0.000512", "source": "10.0.0.2", "destination": "10.0.0.1", "protocol": "ICMP", "length": 98, "info": "Echo (ping) reply"}
+		]`))
+
+	service := NewPCAPService(client)
+	packets, err := service.Packets(context.Background(), "lab1", "link1")
+	assert.NoError(t, err)
+	if assert.Len(t, packets, 2) {
+		assert.Equal(t, models.PCAPPacket{
+			Number: 2, Timestamp: "0.000512", Source: "10.0.0.2", Destination: "10.0.0.1",
+			Protocol: "ICMP", Length: 98, Info: "Echo (ping) reply",
+		}, packets[1])
+	}
+}
+
+func TestPCAPService_Download(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+
+	pcap := []byte{0xd4, 0xc3, 0xb2, 0xa1, 0x02, 0x00, 0x04, 0x00}
+	httpmock.RegisterResponder("GET", captureMockURL+"key",
+		httpmock.NewStringResponder(200, `"capture-key"`))
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/pcap/capture-key",
+		httpmock.NewBytesResponder(200, pcap))
+
+	service := NewPCAPService(client)
+	var buf bytes.Buffer
+	n, err := service.Download(context.Background(), "lab1", "link1", &buf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(pcap)), n)
+	assert.Equal(t, pcap, buf.Bytes())
+
+	// no capture
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/pcap/capture-key",
+		httpmock.NewStringResponder(404, `{"description": "Capture not found"}`))
+	_, err = service.Download(context.Background(), "lab1", "link1", &buf)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "download capture of link link1")
+
+	httpmock.RegisterResponder("GET", captureMockURL+"key",
+		httpmock.NewStringResponder(200, `""`))
+	_, err = service.Download(context.Background(), "lab1", "link1", &buf)
+	assert.ErrorIs(t, err, errors.ErrElementNotFound)
+}
@@ -0,0 +1,828 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+// ReconcileService computes the changes needed to turn an existing lab into a
+// desired topology (the plan) and applies them.
+type ReconcileService struct {
+	Lab        LabServiceInterface
+	Node       NodeServiceInterface
+	Link       LinkServiceInterface
+	Interface  InterfaceServiceInterface
+	Annotation AnnotationServiceInterface
+}
+
+// NewReconcileService creates a new reconcile service
+func NewReconcileService(lab LabServiceInterface, node NodeServiceInterface, link LinkServiceInterface, iface InterfaceServiceInterface, annotation AnnotationServiceInterface) *ReconcileService {
+	return &ReconcileService{
+		Lab:        lab,
+		Node:       node,
+		Link:       link,
+		Interface:  iface,
+		Annotation: annotation,
+	}
+}
+
+// Reconcile computes the plan for the lab identified by `labID` and applies
+// it with the default wait options, see Apply. The plan is returned, also
+// when applying it fails.
+func (s *ReconcileService) Reconcile(ctx context.Context, labID models.UUID, desired *models.LabTopology) (models.ReconcilePlan, error) {
+	plan, err := s.Plan(ctx, labID, desired)
+	if err != nil || plan.Empty() {
+		return plan, err
+	}
+	return plan, s.Apply(ctx, &plan)
+}
+
+// Plan compares the lab identified by `labID` with the `desired` topology and
+// returns the actions needed to reconcile them. Nodes are matched by label,
+// interfaces by label within their node, links by their endpoints. Duplicate
+// node labels in the lab or the topology are rejected with
+// errors.ErrInvalidInput.
+//
+// Node attributes which are not set in the desired topology (e.g. RAM or the
+// configuration) are left as they are. A node is replaced when an attribute
+// can't be updated in place, e.g. the node definition or, unless the node is
+// in DEFINED_ON_CORE state, the resources and the configuration.
+func (s *ReconcileService) Plan(ctx context.Context, labID models.UUID, desired *models.LabTopology) (models.ReconcilePlan, error) {
+	lab, err := s.Lab.GetByID(ctx, labID, true)
+	if err != nil {
+		return models.ReconcilePlan{}, errors.Wrapf(err, "plan lab %s", labID)
+	}
+
+	p := reconcilePlanner{
+		lab:       &lab,
+		desired:   desired,
+		plan:      &models.ReconcilePlan{LabID: labID},
+		nodes:     make(map[string]*models.Node, len(lab.Nodes)),
+		recreate:  make(map[string]bool),
+		keptLinks: make(map[string]models.UUID),
+	}
+	if err := p.planNodes(); err != nil {
+		return models.ReconcilePlan{}, errors.Wrapf(err, "plan lab %s", labID)
+	}
+	if err := p.planLinks(); err != nil {
+		return models.ReconcilePlan{}, errors.Wrapf(err, "plan lab %s", labID)
+	}
+	p.planInterfaces()
+	if err := s.planLinkConditions(ctx, &p); err != nil {
+		return models.ReconcilePlan{}, errors.Wrapf(err, "plan lab %s", labID)
+	}
+	if err := s.planAnnotations(ctx, &p); err != nil {
+		return models.ReconcilePlan{}, errors.Wrapf(err, "plan lab %s", labID)
+	}
+	return *p.plan, nil
+}
+
+type reconcilePlanner struct {
+	lab     *models.Lab
+	desired *models.LabTopology
+	plan    *models.ReconcilePlan
+
+	// current nodes by label and labels of nodes which are (re)created
+	nodes    map[string]*models.Node
+	recreate map[string]bool
+	// desired links which exist, by link key
+	keptLinks map[string]models.UUID
+	// endpoints of the desired links, in topology order
+	desiredLinks []desiredLink
+}
+
+type desiredLink struct {
+	a, b         models.LinkEndpoint
+	conditioning *models.LinkConditionConfiguration
+}
+
+func (p *reconcilePlanner) planNodes() error {
+	// nodes are matched by label, hence labels must be unique
+	for _, node := range p.lab.Nodes {
+		if _, found := p.nodes[node.Label]; found {
+			return errors.Wrapf(errors.ErrInvalidInput, "duplicate node label %q in lab", node.Label)
+		}
+		p.nodes[node.Label] = node
+	}
+
+	wanted := make(map[string]bool, len(p.desired.Nodes))
+	for idx := range p.desired.Nodes {
+		want := &p.desired.Nodes[idx]
+		if wanted[want.Label] {
+			return errors.Wrapf(errors.ErrInvalidInput, "duplicate node label %q in topology", want.Label)
+		}
+		wanted[want.Label] = true
+
+		current, found := p.nodes[want.Label]
+		if !found {
+			p.recreate[want.Label] = true
+			p.plan.Nodes = append(p.plan.Nodes, models.NodePlan{
+				Action:  models.PlanActionCreate,
+				Label:   want.Label,
+				Desired: want,
+			})
+			continue
+		}
+
+		changes, err := nodeChanges(current, want)
+		if err != nil {
+			return errors.Wrapf(err, "compare node %s", want.Label)
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		action := models.PlanActionUpdate
+		if slices.ContainsFunc(changes, func(c models.PlanChange) bool { return c.ForcesReplace }) {
+			action = models.PlanActionReplace
+			p.recreate[want.Label] = true
+		}
+		p.plan.Nodes = append(p.plan.Nodes, models.NodePlan{
+			Action:  action,
+			Label:   want.Label,
+			ID:      current.ID,
+			State:   current.State,
+			Desired: want,
+			Changes: changes,
+		})
+	}
+
+	for label, node := range p.nodes {
+		if wanted[label] {
+			continue
+		}
+		p.plan.Nodes = append(p.plan.Nodes, models.NodePlan{
+			Action: models.PlanActionDelete,
+			Label:  label,
+			ID:     node.ID,
+			State:  node.State,
+		})
+	}
+
+	sort.SliceStable(p.plan.Nodes, func(i, j int) bool {
+		return p.plan.Nodes[i].Label < p.plan.Nodes[j].Label
+	})
+	return nil
+}
+
+// planInterfaces plans the physical interfaces of the kept nodes: missing
+// ones are created, the ones which are not in the desired topology are
+// deleted unless a kept link uses them. It requires the kept links, see
+// planLinks.
+func (p *reconcilePlanner) planInterfaces() {
+	kept := make(map[models.UUID]bool, len(p.keptLinks))
+	for _, linkID := range p.keptLinks {
+		kept[linkID] = true
+	}
+	inUse := make(map[models.UUID]bool)
+	for _, link := range p.lab.Links {
+		if kept[link.ID] {
+			inUse[link.SrcID] = true
+			inUse[link.DstID] = true
+		}
+	}
+
+	for idx := range p.desired.Nodes {
+		want := &p.desired.Nodes[idx]
+		current, found := p.nodes[want.Label]
+		if !found || p.recreate[want.Label] {
+			continue
+		}
+		labels := make(map[string]bool, len(current.Interfaces))
+		for _, iface := range current.Interfaces {
+			labels[iface.Label] = true
+		}
+		wanted := make(map[string]bool, len(want.Interfaces))
+		for _, iface := range want.Interfaces {
+			wanted[iface.Label] = true
+			if iface.Type != models.IfaceTypePhysical || iface.Slot == nil || labels[iface.Label] {
+				continue
+			}
+			p.plan.Interfaces = append(p.plan.Interfaces, models.InterfacePlan{
+				Action: models.PlanActionCreate,
+				Node:   want.Label,
+				Label:  iface.Label,
+				Slot:   *iface.Slot,
+			})
+		}
+		for _, iface := range current.Interfaces {
+			if !iface.IsPhysical() || iface.Slot == nil || wanted[iface.Label] || inUse[iface.ID] {
+				continue
+			}
+			p.plan.Interfaces = append(p.plan.Interfaces, models.InterfacePlan{
+				Action: models.PlanActionDelete,
+				Node:   want.Label,
+				Label:  iface.Label,
+				Slot:   *iface.Slot,
+				ID:     iface.ID,
+			})
+		}
+	}
+}
+
+func (p *reconcilePlanner) planLinks() error {
+	endpoints := make(map[models.UUID]models.LinkEndpoint)
+	for _, node := range p.lab.Nodes {
+		for _, iface := range node.Interfaces {
+			endpoints[iface.ID] = models.LinkEndpoint{Node: node.Label, Interface: iface.Label}
+		}
+	}
+	current := make(map[string]models.Link, len(p.lab.Links))
+	for _, link := range p.lab.Links {
+		current[linkKey(endpoints[link.SrcID], endpoints[link.DstID])] = link
+	}
+
+	for _, link := range p.desired.Links {
+		a, err := topologyEndpoint(p.desired, link.N1, link.I1)
+		if err != nil {
+			return errors.Wrapf(err, "link %s", link.ID)
+		}
+		b, err := topologyEndpoint(p.desired, link.N2, link.I2)
+		if err != nil {
+			return errors.Wrapf(err, "link %s", link.ID)
+		}
+		p.desiredLinks = append(p.desiredLinks, desiredLink{a, b, link.Conditioning})
+
+		key := linkKey(a, b)
+		if existing, found := current[key]; found && !p.recreate[a.Node] && !p.recreate[b.Node] {
+			p.keptLinks[key] = existing.ID
+			continue
+		}
+		p.plan.Links = append(p.plan.Links, models.LinkPlan{Action: models.PlanActionCreate, A: a, B: b})
+	}
+
+	for key, link := range current {
+		if _, kept := p.keptLinks[key]; kept {
+			continue
+		}
+		p.plan.Links = append(p.plan.Links, models.LinkPlan{
+			Action: models.PlanActionDelete,
+			ID:     link.ID,
+			A:      endpoints[link.SrcID],
+			B:      endpoints[link.DstID],
+		})
+	}
+
+	sort.SliceStable(p.plan.Links, func(i, j int) bool {
+		return linkKey(p.plan.Links[i].A, p.plan.Links[i].B) < linkKey(p.plan.Links[j].A, p.plan.Links[j].B)
+	})
+	return nil
+}
+
+func (s *ReconcileService) planLinkConditions(ctx context.Context, p *reconcilePlanner) error {
+	for _, link := range p.desiredLinks {
+		want := models.LinkConditionConfiguration{}
+		if link.conditioning != nil {
+			want = *link.conditioning
+		}
+
+		have := models.LinkConditionConfiguration{}
+		linkID, kept := p.keptLinks[linkKey(link.a, link.b)]
+		if kept {
+			condition, err := s.Link.GetCondition(ctx, p.plan.LabID, linkID)
+			if err != nil {
+				return errors.Wrapf(err, "get condition of link %s", linkID)
+			}
+			have = condition.LinkConditionConfiguration
+		}
+
+		changes, err := attributeChanges(have, want)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		plan := models.LinkConditionPlan{
+			Action:  models.PlanActionUpdate,
+			LinkID:  linkID,
+			A:       link.a,
+			B:       link.b,
+			Desired: link.conditioning,
+			Changes: changes,
+		}
+		if want == (models.LinkConditionConfiguration{}) {
+			plan.Action = models.PlanActionDelete
+			plan.Desired = nil
+		}
+		p.plan.LinkConditions = append(p.plan.LinkConditions, plan)
+	}
+	return nil
+}
+
+func (s *ReconcileService) planAnnotations(ctx context.Context, p *reconcilePlanner) error {
+	current, err := s.Annotation.List(ctx, p.plan.LabID)
+	if err != nil {
+		return errors.Wrap(err, "get annotations")
+	}
+
+	// annotations have no identity, they are compared by content
+	unmatched := make(map[string]int)
+	for _, annotation := range p.desired.Annotations {
+		key, err := annotationKey(annotation)
+		if err != nil {
+			return err
+		}
+		unmatched[key]++
+	}
+
+	for _, annotation := range current {
+		topo, err := annotation.Topology()
+		if err != nil {
+			return err
+		}
+		key, err := annotationKey(topo)
+		if err != nil {
+			return err
+		}
+		if unmatched[key] > 0 {
+			unmatched[key]--
+			continue
+		}
+		p.plan.Annotations = append(p.plan.Annotations, models.AnnotationPlan{
+			Action:     models.PlanActionDelete,
+			ID:         annotation.ID(),
+			Annotation: topo,
+		})
+	}
+
+	for _, annotation := range p.desired.Annotations {
+		key, _ := annotationKey(annotation)
+		if unmatched[key] == 0 {
+			continue
+		}
+		unmatched[key]--
+		p.plan.Annotations = append(p.plan.Annotations, models.AnnotationPlan{
+			Action:     models.PlanActionCreate,
+			Annotation: annotation,
+		})
+	}
+	return nil
+}
+
+// Apply applies the plan to the lab. Elements are removed first, then nodes
+// are created and updated, followed by interfaces, links, link conditions and
+// annotations. Nodes which are removed or replaced are stopped and wiped
+// before they are deleted, their state is polled as controlled by
+// `plan.Wait` until they have stopped and have been wiped. Apply stops at the
+// first error.
+func (s *ReconcileService) Apply(ctx context.Context, plan *models.ReconcilePlan) error {
+	nodes, err := s.Node.GetNodesForLab(ctx, plan.LabID)
+	if err != nil {
+		return errors.Wrapf(err, "get nodes for lab %s", plan.LabID)
+	}
+	a := reconcileApplier{
+		ReconcileService: s,
+		labID:            plan.LabID,
+		wait:             plan.Wait,
+		nodeIDs:          make(map[string]models.UUID, len(nodes)),
+		ifaces:           make(map[models.UUID]models.InterfaceList),
+	}
+	for _, node := range nodes {
+		a.nodeIDs[node.Label] = node.ID
+	}
+
+	for _, link := range plan.Links {
+		if link.Action != models.PlanActionDelete {
+			continue
+		}
+		if err := s.Link.Delete(ctx, a.labID, link.ID); err != nil {
+			return errors.Wrapf(err, "delete link %s", link.ID)
+		}
+	}
+
+	// interfaces can only be deleted once their links are gone
+	for _, iface := range plan.Interfaces {
+		if iface.Action != models.PlanActionDelete {
+			continue
+		}
+		if err := s.Interface.Delete(ctx, a.labID, iface.ID); err != nil {
+			return errors.Wrapf(err, "delete interface %s on node %s", iface.Label, iface.Node)
+		}
+	}
+
+	for _, annotation := range plan.Annotations {
+		if annotation.Action != models.PlanActionDelete {
+			continue
+		}
+		if err := s.Annotation.Delete(ctx, a.labID, annotation.ID); err != nil {
+			return errors.Wrapf(err, "delete annotation %s", annotation.ID)
+		}
+	}
+
+	for _, node := range plan.Nodes {
+		if node.Action != models.PlanActionDelete && node.Action != models.PlanActionReplace {
+			continue
+		}
+		if err := a.removeNode(ctx, node.ID, node.State); err != nil {
+			return errors.Wrapf(err, "remove node %s", node.Label)
+		}
+		delete(a.nodeIDs, node.Label)
+	}
+
+	for _, node := range plan.Nodes {
+		switch node.Action {
+		case models.PlanActionCreate, models.PlanActionReplace:
+			if err := a.createNode(ctx, node.Desired); err != nil {
+				return errors.Wrapf(err, "create node %s", node.Label)
+			}
+		case models.PlanActionUpdate:
+			if err := a.updateNode(ctx, node.ID, node.Desired); err != nil {
+				return errors.Wrapf(err, "update node %s", node.Label)
+			}
+		}
+	}
+
+	for _, iface := range plan.Interfaces {
+		if iface.Action != models.PlanActionCreate {
+			continue
+		}
+		nodeID, found := a.nodeIDs[iface.Node]
+		if !found {
+			return errors.Wrapf(errors.ErrElementNotFound, "node %s", iface.Node)
+		}
+		if err := a.ensureSlot(ctx, nodeID, iface.Slot); err != nil {
+			return errors.Wrapf(err, "create interface %s on node %s", iface.Label, iface.Node)
+		}
+	}
+
+	created := make(map[string]models.UUID)
+	for _, link := range plan.Links {
+		if link.Action != models.PlanActionCreate {
+			continue
+		}
+		linkID, err := a.createLink(ctx, link.A, link.B)
+		if err != nil {
+			return errors.Wrapf(err, "create link %s <-> %s", link.A, link.B)
+		}
+		created[linkKey(link.A, link.B)] = linkID
+	}
+
+	for _, condition := range plan.LinkConditions {
+		linkID := condition.LinkID
+		if len(linkID) == 0 {
+			linkID = created[linkKey(condition.A, condition.B)]
+		}
+		if condition.Action == models.PlanActionDelete {
+			err = s.Link.DeleteCondition(ctx, a.labID, linkID)
+		} else {
+			_, err = s.Link.SetCondition(ctx, a.labID, linkID, condition.Desired)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "set condition of link %s <-> %s", condition.A, condition.B)
+		}
+	}
+
+	for _, annotation := range plan.Annotations {
+		if annotation.Action != models.PlanActionCreate {
+			continue
+		}
+		create, err := annotation.Annotation.CreateRequest()
+		if err != nil {
+			return errors.Wrap(err, "create annotation")
+		}
+		if _, err := s.Annotation.Create(ctx, a.labID, create); err != nil {
+			return errors.Wrap(err, "create annotation")
+		}
+	}
+
+	return nil
+}
+
+type reconcileApplier struct {
+	*ReconcileService
+	labID models.UUID
+	wait  models.WaitOptions
+
+	// node IDs by label and cached interfaces by node ID
+	nodeIDs map[string]models.UUID
+	ifaces  map[models.UUID]models.InterfaceList
+}
+
+// removeNode stops, wipes and deletes the node. The controller rejects
+// wiping or deleting a node which is still running, hence the node state is
+// polled until the node has stopped and until it has been wiped.
+func (a *reconcileApplier) removeNode(ctx context.Context, nodeID models.UUID, state models.NodeState) error {
+	waitState := func(states ...models.NodeState) error {
+		return waitFor(ctx, a.wait, func(ctx context.Context) (bool, error) {
+			node, err := a.Node.GetByID(ctx, a.labID, nodeID)
+			if err != nil {
+				return false, err
+			}
+			return slices.Contains(states, node.State), nil
+		})
+	}
+
+	if state != models.NodeStateDefined {
+		if state != models.NodeStateStopped {
+			if err := a.Node.Stop(ctx, a.labID, nodeID); err != nil {
+				return err
+			}
+			if err := waitState(models.NodeStateStopped, models.NodeStateDefined); err != nil {
+				return errors.Wrap(err, "wait for stopped")
+			}
+		}
+		if err := a.Node.Wipe(ctx, a.labID, nodeID); err != nil {
+			return err
+		}
+		if err := waitState(models.NodeStateDefined); err != nil {
+			return errors.Wrap(err, "wait for wiped")
+		}
+	}
+	return a.Node.Delete(ctx, a.labID, nodeID)
+}
+
+func (a *reconcileApplier) createNode(ctx context.Context, desired *models.NodeTopology) error {
+	node, err := a.Node.Create(ctx, desiredNode(a.labID, desired, nil))
+	if err != nil {
+		return err
+	}
+	a.nodeIDs[desired.Label] = node.ID
+
+	// default interfaces are created with the node, add the missing ones
+	maxSlot := -1
+	for _, iface := range desired.Interfaces {
+		if iface.Type == models.IfaceTypePhysical && iface.Slot != nil && *iface.Slot > maxSlot {
+			maxSlot = *iface.Slot
+		}
+	}
+	if maxSlot < 0 {
+		return nil
+	}
+	return a.ensureSlot(ctx, node.ID, maxSlot)
+}
+
+func (a *reconcileApplier) updateNode(ctx context.Context, nodeID models.UUID, desired *models.NodeTopology) error {
+	current, err := a.Node.GetByID(ctx, a.labID, nodeID)
+	if err != nil {
+		return err
+	}
+	current = normalizedNode(&current)
+	_, err = a.Node.Update(ctx, desiredNode(a.labID, desired, &current))
+	return err
+}
+
+func (a *reconcileApplier) interfaces(ctx context.Context, nodeID models.UUID) (models.InterfaceList, error) {
+	if ifaces, found := a.ifaces[nodeID]; found {
+		return ifaces, nil
+	}
+	ifaces, err := a.Interface.GetInterfacesForNode(ctx, a.labID, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	a.ifaces[nodeID] = ifaces
+	return ifaces, nil
+}
+
+// ensureSlot creates the interfaces of the node up to and including `slot`
+// unless the slot already exists.
+func (a *reconcileApplier) ensureSlot(ctx context.Context, nodeID models.UUID, slot int) error {
+	ifaces, err := a.interfaces(ctx, nodeID)
+	if err != nil {
+		return err
+	}
+	for _, iface := range ifaces {
+		if iface.IsPhysical() && iface.Slot != nil && *iface.Slot == slot {
+			return nil
+		}
+	}
+	if _, err := a.Interface.Create(ctx, a.labID, nodeID, slot); err != nil {
+		return err
+	}
+	delete(a.ifaces, nodeID)
+	return nil
+}
+
+func (a *reconcileApplier) createLink(ctx context.Context, ep1, ep2 models.LinkEndpoint) (models.UUID, error) {
+	src, err := a.interfaceID(ctx, ep1)
+	if err != nil {
+		return "", err
+	}
+	dst, err := a.interfaceID(ctx, ep2)
+	if err != nil {
+		return "", err
+	}
+	link, err := a.Link.Create(ctx, models.Link{LabID: a.labID, SrcID: src, DstID: dst})
+	if err != nil {
+		return "", err
+	}
+	return link.ID, nil
+}
+
+func (a *reconcileApplier) interfaceID(ctx context.Context, ep models.LinkEndpoint) (models.UUID, error) {
+	nodeID, found := a.nodeIDs[ep.Node]
+	if !found {
+		return "", errors.Wrapf(errors.ErrElementNotFound, "node %s", ep.Node)
+	}
+	ifaces, err := a.interfaces(ctx, nodeID)
+	if err != nil {
+		return "", err
+	}
+	for _, iface := range ifaces {
+		if iface.Label == ep.Interface {
+			return iface.ID, nil
+		}
+	}
+	return "", errors.Wrapf(errors.ErrElementNotFound, "interface %s", ep)
+}
+
+// nodeChanges compares the current node with the desired node. The attributes
+// are compared in the form newNodeAlias produces them: all attributes as sent
+// on create are compared, the ones newNodeAlias omits for an update in the
+// node's current state force a replacement.
+func nodeChanges(current *models.Node, want *models.NodeTopology) ([]models.PlanChange, error) {
+	have := normalizedNode(current)
+	desired := desiredNode(have.LabID, want, &have)
+
+	haveAll, desiredAll := have, desired
+	haveAll.State = models.NodeStateDefined
+	desiredAll.State = models.NodeStateDefined
+	changes, err := attributeChanges(newNodeAlias(&haveAll, false), newNodeAlias(&desiredAll, false))
+	if err != nil || len(changes) == 0 {
+		return changes, err
+	}
+
+	havePatch, err := attributes(newNodeAlias(&have, true))
+	if err != nil {
+		return nil, err
+	}
+	desiredPatch, err := attributes(newNodeAlias(&desired, true))
+	if err != nil {
+		return nil, err
+	}
+	for idx := range changes {
+		field := changes[idx].Field
+		changes[idx].ForcesReplace = reflect.DeepEqual(havePatch[field], desiredPatch[field])
+	}
+	return changes, nil
+}
+
+// desiredNode builds the node for create and update requests from the
+// topology node. With a `current` node, attributes which are not set in the
+// topology are taken from the current node.
+func desiredNode(labID models.UUID, t *models.NodeTopology, current *models.Node) models.Node {
+	hideLinks := t.HideLinks
+	node := models.Node{
+		LabID:           labID,
+		Label:           t.Label,
+		X:               t.X,
+		Y:               t.Y,
+		NodeDefinition:  t.NodeDefinition,
+		ImageDefinition: t.ImageDefinition,
+		RAM:             t.RAM,
+		CPUlimit:        t.CPUlimit,
+		DataVolume:      t.DataVolume,
+		BootDiskSize:    t.BootDiskSize,
+		Priority:        t.Priority,
+		HideLinks:       &hideLinks,
+		Tags:            t.Tags,
+	}
+	if t.CPUs != nil {
+		node.CPUs = *t.CPUs
+	}
+	if len(t.Configurations) > 0 {
+		node.Configurations = t.Configurations
+	} else if len(t.Configuration) > 0 {
+		node.Configuration = t.Configuration
+	}
+	if len(t.Parameters) > 0 {
+		node.Parameters = maps.Clone(t.Parameters)
+	}
+
+	if current == nil {
+		return node
+	}
+
+	node.ID = current.ID
+	node.State = current.State
+	node.PyATS = current.PyATS
+	if t.CPUs == nil {
+		node.CPUs = current.CPUs
+	}
+	for _, attr := range []struct{ want, have **int }{
+		{&node.RAM, &current.RAM},
+		{&node.CPUlimit, &current.CPUlimit},
+		{&node.DataVolume, &current.DataVolume},
+		{&node.BootDiskSize, &current.BootDiskSize},
+		{&node.Priority, &current.Priority},
+	} {
+		if *attr.want == nil {
+			*attr.want = *attr.have
+		}
+	}
+	if node.ImageDefinition == nil {
+		node.ImageDefinition = current.ImageDefinition
+	}
+	if node.Configuration == nil && len(node.Configurations) == 0 {
+		node.Configuration = current.Configuration
+		node.Configurations = current.Configurations
+	}
+	if node.Parameters == nil {
+		node.Parameters = current.NodeParameters()
+	}
+	return node
+}
+
+// normalizedNode returns a copy of the node as read from the API in the form
+// used for create and update requests.
+func normalizedNode(n *models.Node) models.Node {
+	node := *n
+	if cfg, ok := node.Configuration.(*string); ok {
+		node.Configuration = nil
+		if cfg != nil {
+			node.Configuration = *cfg
+		}
+	}
+	if node.HideLinks == nil {
+		hideLinks := false
+		node.HideLinks = &hideLinks
+	}
+	return node
+}
+
+// attributes returns the JSON attributes of `v` as sent to the API.
+func attributes(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var attrs map[string]any
+	if err := json.Unmarshal(data, &attrs); err != nil {
+		return nil, err
+	}
+	return attrs, nil
+}
+
+// attributeChanges returns the changed JSON attributes, sorted by name.
+func attributeChanges(have, want any) ([]models.PlanChange, error) {
+	haveAttrs, err := attributes(have)
+	if err != nil {
+		return nil, err
+	}
+	wantAttrs, err := attributes(want)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(haveAttrs)+len(wantAttrs))
+	for field := range haveAttrs {
+		fields = append(fields, field)
+	}
+	for field := range wantAttrs {
+		if _, found := haveAttrs[field]; !found {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	var changes []models.PlanChange
+	for _, field := range fields {
+		if reflect.DeepEqual(haveAttrs[field], wantAttrs[field]) {
+			continue
+		}
+		changes = append(changes, models.PlanChange{Field: field, Old: haveAttrs[field], New: wantAttrs[field]})
+	}
+	return changes, nil
+}
+
+// topologyEndpoint resolves the topology node and interface IDs of a link
+// endpoint to their labels.
+func topologyEndpoint(t *models.LabTopology, nodeID, ifaceID string) (models.LinkEndpoint, error) {
+	node := t.NodeByID(nodeID)
+	if node == nil {
+		return models.LinkEndpoint{}, errors.Wrapf(errors.ErrElementNotFound, "node %s", nodeID)
+	}
+	iface := node.InterfaceByID(ifaceID)
+	if iface == nil {
+		return models.LinkEndpoint{}, errors.Wrapf(errors.ErrElementNotFound, "interface %s of node %s", ifaceID, nodeID)
+	}
+	return models.LinkEndpoint{Node: node.Label, Interface: iface.Label}, nil
+}
+
+// linkKey returns a key for the link which doesn't depend on the order of
+// the endpoints.
+func linkKey(a, b models.LinkEndpoint) string {
+	ka, kb := a.String(), b.String()
+	if kb < ka {
+		ka, kb = kb, ka
+	}
+	return ka + "|" + kb
+}
+
+func annotationKey(a models.AnnotationTopology) (string, error) {
+	create, err := a.CreateRequest()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(create)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,408 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rschmied/gocmlclient/internal/api"
+	"github.com/rschmied/gocmlclient/internal/testutil"
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+const reconcileDesired = `
+lab:
+  title: reconcile
+nodes:
+  - id: n0
+    label: r1
+    node_definition: iosv
+    x: 100
+    y: 0
+    tags: []
+    interfaces:
+      - {id: i0, label: eth0, slot: 0, type: physical}
+      - {id: i1, label: eth1, slot: 1, type: physical}
+      - {id: i2, label: eth2, slot: 2, type: physical}
+  - id: n1
+    label: r2
+    node_definition: iosv
+    x: 0
+    y: 100
+    ram: 1024
+    tags: []
+    interfaces:
+      - {id: i0, label: eth0, slot: 0, type: physical}
+  - id: n2
+    label: r3
+    node_definition: iosv
+    x: 100
+    y: 100
+    tags: []
+    interfaces:
+      - {id: i0, label: eth0, slot: 0, type: physical}
+  - id: n3
+    label: r4
+    node_definition: alpine
+    x: 200
+    y: 200
+    tags: []
+    interfaces:
+      - {id: i0, label: eth0, slot: 0, type: physical}
+links:
+  - {id: l0, n1: n0, i1: i0, n2: n1, i2: i0}
+  - {id: l1, n1: n0, i1: i1, n2: n2, i2: i0}
+  - {id: l2, n1: n0, i1: i2, n2: n3, i2: i0, conditioning: {latency: 10}}
+annotations:
+  - {type: text, border_color: "#000", border_style: "", color: "#fff", thickness: 1, x1: 5, y1: 5, z_index: 0, rotation: 0, text_content: hello, text_font: sans, text_size: 12, text_unit: pt}
+  - {type: ellipse, border_color: "#000", border_style: "", color: "#fff", thickness: 1, x1: 50, y1: 50, x2: 10, y2: 10, z_index: 0, rotation: 0}
+`
+
+func registerReconcileLab() {
+	base := "https://mock/api/v0/labs/lab-1"
+	httpmock.RegisterResponder("GET", base,
+		httpmock.NewStringResponder(200, `{"id":"lab-1","lab_title":"reconcile","state":"STARTED"}`))
+	httpmock.RegisterResponder("GET", base+"/nodes",
+		httpmock.NewStringResponder(200, `[
+			{"id":"n1","lab_id":"lab-1","label":"r1","x":0,"y":0,"node_definition":"iosv","ram":512,"state":"DEFINED_ON_CORE","tags":[]},
+			{"id":"n2","lab_id":"lab-1","label":"r2","x":0,"y":100,"node_definition":"iosv","ram":512,"state":"STARTED","tags":[]},
+			{"id":"n3","lab_id":"lab-1","label":"r3","x":100,"y":100,"node_definition":"iosv","ram":512,"state":"DEFINED_ON_CORE","tags":[]},
+			{"id":"n5","lab_id":"lab-1","label":"r5","x":300,"y":300,"node_definition":"iosv","state":"DEFINED_ON_CORE","tags":[]}
+		]`))
+	httpmock.RegisterResponder("GET", base+"/nodes/n1/interfaces",
+		httpmock.NewStringResponder(200, `[
+			{"id":"n1-i0","node":"n1","label":"eth0","slot":0,"type":"physical"},
+			{"id":"n1-i1","node":"n1","label":"eth1","slot":1,"type":"physical"}
+		]`))
+	httpmock.RegisterResponder("GET", base+"/nodes/n2/interfaces",
+		httpmock.NewStringResponder(200, `[{"id":"n2-i0","node":"n2","label":"eth0","slot":0,"type":"physical"}]`))
+	httpmock.RegisterResponder("GET", base+"/nodes/n3/interfaces",
+		httpmock.NewStringResponder(200, `[{"id":"n3-i0","node":"n3","label":"eth0","slot":0,"type":"physical"}]`))
+	httpmock.RegisterResponder("GET", base+"/nodes/n5/interfaces",
+		httpmock.NewStringResponder(200, `[]`))
+	httpmock.RegisterResponder("GET", base+"/links",
+		httpmock.NewStringResponder(200, `[
+			{"id":"l1","lab_id":"lab-1","interface_a":"n1-i0","interface_b":"n2-i0"},
+			{"id":"l2","lab_id":"lab-1","interface_a":"n3-i0","interface_b":"n1-i1"}
+		]`))
+	httpmock.RegisterResponder("GET", base+"/layer3_addresses",
+		httpmock.NewStringResponder(200, `{}`))
+	httpmock.RegisterResponder("GET", base+"/links/l2/condition",
+		httpmock.NewStringResponder(200, `{"enabled":true,"latency":5}`))
+	httpmock.RegisterResponder("GET", base+"/annotations",
+		httpmock.NewStringResponder(200, `[
+			{"id":"a1","type":"text","border_color":"#000","border_style":"","color":"#fff","thickness":1,"x1":5,"y1":5,"z_index":0,"rotation":0,"text_bold":false,"text_content":"hello","text_font":"sans","text_italic":false,"text_size":12,"text_unit":"pt"},
+			{"id":"a2","type":"rectangle","border_color":"#000","border_style":"","color":"#fff","thickness":1,"x1":1,"y1":2,"x2":3,"y2":4,"z_index":0,"rotation":0,"border_radius":1}
+		]`))
+}
+
+func newReconcileTestService(client *api.Client) *ReconcileService {
+	nodeService := NewNodeService(client, false)
+	interfaceService := NewInterfaceService(client)
+	linkService := NewLinkService(client)
+	linkService.Interface = interfaceService
+	linkService.Node = nodeService
+	userService := NewUserService(client, NewGroupService(client))
+	labService := NewLabService(client, interfaceService, linkService, userService, nodeService)
+	return NewReconcileService(labService, nodeService, linkService, interfaceService, NewAnnotationService(client))
+}
+
+func TestReconcilePlan(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+	registerReconcileLab()
+
+	desired, err := models.ParseLabTopology([]byte(reconcileDesired))
+	assert.NoError(t, err)
+
+	service := newReconcileTestService(client)
+	plan, err := service.Plan(context.Background(), "lab-1", desired)
+	assert.NoError(t, err)
+
+	assert.Len(t, plan.Nodes, 4)
+	r1, r2, r4, r5 := plan.Nodes[0], plan.Nodes[1], plan.Nodes[2], plan.Nodes[3]
+
+	// position can be changed in place, RAM on a started node can't
+	assert.Equal(t, models.PlanActionUpdate, r1.Action)
+	assert.Equal(t, []models.PlanChange{{Field: "x", Old: float64(0), New: float64(100)}}, r1.Changes)
+	assert.Equal(t, models.PlanActionReplace, r2.Action)
+	assert.Equal(t, []models.PlanChange{{Field: "ram", Old: float64(512), New: float64(1024), ForcesReplace: true}}, r2.Changes)
+	assert.Equal(t, models.PlanActionCreate, r4.Action)
+	assert.Equal(t, "r4", r4.Label)
+	assert.Equal(t, models.PlanActionDelete, r5.Action)
+	assert.Equal(t, models.UUID("n5"), r5.ID)
+
+	assert.Equal(t, []models.InterfacePlan{{Action: models.PlanActionCreate, Node: "r1", Label: "eth2", Slot: 2}}, plan.Interfaces)
+
+	assert.Equal(t, []models.LinkPlan{
+		{Action: models.PlanActionCreate, A: models.LinkEndpoint{Node: "r1", Interface: "eth0"}, B: models.LinkEndpoint{Node: "r2", Interface: "eth0"}},
+		{Action: models.PlanActionDelete, ID: "l1", A: models.LinkEndpoint{Node: "r1", Interface: "eth0"}, B: models.LinkEndpoint{Node: "r2", Interface: "eth0"}},
+		{Action: models.PlanActionCreate, A: models.LinkEndpoint{Node: "r1", Interface: "eth2"}, B: models.LinkEndpoint{Node: "r4", Interface: "eth0"}},
+	}, plan.Links)
+
+	assert.Len(t, plan.LinkConditions, 2)
+	assert.Equal(t, models.PlanActionDelete, plan.LinkConditions[0].Action)
+	assert.Equal(t, models.UUID("l2"), plan.LinkConditions[0].LinkID)
+	assert.Equal(t, models.PlanActionUpdate, plan.LinkConditions[1].Action)
+	assert.Empty(t, plan.LinkConditions[1].LinkID)
+	assert.Equal(t, 10, plan.LinkConditions[1].Desired.Latency)
+
+	assert.Len(t, plan.Annotations, 2)
+	assert.Equal(t, models.PlanActionCreate, plan.Annotations[1].Action)
+	assert.Equal(t, models.AnnotationTypeEllipse, plan.Annotations[1].Annotation.Type)
+	assert.Equal(t, models.AnnotationPlan{
+		Action: models.PlanActionDelete,
+		ID:     "a2",
+		Annotation: func() models.AnnotationTopology {
+			x2, y2, rot, radius := 3.0, 4.0, 0.0, 1.0
+			return models.AnnotationTopology{
+				Type: models.AnnotationTypeRectangle, BorderColor: "#000", Color: "#fff", Thickness: 1,
+				X1: 1, Y1: 2, X2: &x2, Y2: &y2, Rotation: &rot, BorderRadius: &radius,
+			}
+		}(),
+	}, plan.Annotations[0])
+
+	out := plan.String()
+	assert.Contains(t, out, "~ node \"r1\"\n      ~ x: 0 -> 100\n")
+	assert.Contains(t, out, "-/+ node \"r2\" (forces replacement)\n      ~ ram: 512 -> 1024 # forces replacement\n")
+	assert.Contains(t, out, "+ node \"r4\" (alpine)\n")
+	assert.Contains(t, out, "- node \"r5\"\n")
+	assert.Contains(t, out, "Plan: 6 to add, 3 to change, 4 to destroy.")
+}
+
+func TestReconcileApply(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+	registerReconcileLab()
+
+	base := "https://mock/api/v0/labs/lab-1"
+	var calls []string
+	record := func(status int, body string) httpmock.Responder {
+		return func(req *http.Request) (*http.Response, error) {
+			calls = append(calls, req.Method+" "+strings.TrimPrefix(req.URL.Path, "/api/v0/labs/lab-1/"))
+			return httpmock.NewStringResponse(status, body), nil
+		}
+	}
+
+	httpmock.RegisterResponder("DELETE", base+"/links/l1", record(204, ""))
+	// eth1 of r3 is not in the desired topology
+	httpmock.RegisterResponder("GET", base+"/nodes/n3/interfaces",
+		httpmock.NewStringResponder(200, `[
+			{"id":"n3-i0","node":"n3","label":"eth0","slot":0,"type":"physical"},
+			{"id":"n3-i1","node":"n3","label":"eth1","slot":1,"type":"physical"}
+		]`))
+	httpmock.RegisterResponder("DELETE", "https://mock/api/v0/labs/lab-1/interfaces/n3-i1", func(req *http.Request) (*http.Response, error) {
+		calls = append(calls, "DELETE interfaces/n3-i1")
+		return httpmock.NewStringResponse(204, ""), nil
+	})
+	httpmock.RegisterResponder("DELETE", base+"/annotations/a2", record(204, ""))
+	// the started node n2 takes a poll to stop and to be wiped
+	var n2States []string
+	httpmock.RegisterResponder("GET", base+"/nodes/n2", func(req *http.Request) (*http.Response, error) {
+		state := n2States[0]
+		n2States = n2States[1:]
+		calls = append(calls, "GET nodes/n2 "+state)
+		return httpmock.NewStringResponse(200, `{"id":"n2","lab_id":"lab-1","label":"r2","state":"`+state+`"}`), nil
+	})
+	httpmock.RegisterResponder("PUT", base+"/nodes/n2/state/stop", func(req *http.Request) (*http.Response, error) {
+		n2States = []string{"STARTED", "STOPPED"}
+		return record(204, "")(req)
+	})
+	httpmock.RegisterResponder("PUT", base+"/nodes/n2/wipe_disks", func(req *http.Request) (*http.Response, error) {
+		n2States = []string{"STOPPED", "DEFINED_ON_CORE"}
+		return record(204, "")(req)
+	})
+	httpmock.RegisterResponder("DELETE", base+"/nodes/n2", record(204, ""))
+	httpmock.RegisterResponder("DELETE", base+"/nodes/n5", record(204, ""))
+
+	// created nodes get an ID derived from their label
+	httpmock.RegisterResponder("POST", base+"/nodes", func(req *http.Request) (*http.Response, error) {
+		var node struct {
+			Label string `json:"label"`
+		}
+		_ = json.NewDecoder(req.Body).Decode(&node)
+		calls = append(calls, "POST nodes "+node.Label)
+		return httpmock.NewStringResponse(200, `{"id":"new-`+node.Label+`"}`), nil
+	})
+	for _, id := range []string{"new-r2", "new-r4"} {
+		httpmock.RegisterResponder("PATCH", base+"/nodes/"+id, httpmock.NewStringResponder(200, `"`+id+`"`))
+		httpmock.RegisterResponder("GET", base+"/nodes/"+id,
+			httpmock.NewStringResponder(200, `{"id":"`+id+`","lab_id":"lab-1","state":"DEFINED_ON_CORE"}`))
+		httpmock.RegisterResponder("GET", base+"/nodes/"+id+"/interfaces",
+			httpmock.NewStringResponder(200, `[{"id":"`+id+`-i0","node":"`+id+`","label":"eth0","slot":0,"type":"physical"}]`))
+	}
+
+	httpmock.RegisterResponder("GET", base+"/nodes/n1",
+		httpmock.NewStringResponder(200, `{"id":"n1","lab_id":"lab-1","label":"r1","x":0,"y":0,"node_definition":"iosv","ram":512,"state":"DEFINED_ON_CORE","tags":[]}`))
+	httpmock.RegisterResponder("PATCH", base+"/nodes/n1", func(req *http.Request) (*http.Response, error) {
+		var node map[string]any
+		_ = json.NewDecoder(req.Body).Decode(&node)
+		assert.Equal(t, float64(100), node["x"])
+		assert.Equal(t, float64(512), node["ram"])
+		calls = append(calls, "PATCH nodes/n1")
+		return httpmock.NewStringResponse(200, `"n1"`), nil
+	})
+
+	// after the interface has been created, eth2 shows up
+	httpmock.RegisterResponder("POST", base+"/interfaces", func(req *http.Request) (*http.Response, error) {
+		calls = append(calls, "POST interfaces")
+		httpmock.RegisterResponder("GET", base+"/nodes/n1/interfaces",
+			httpmock.NewStringResponder(200, `[
+				{"id":"n1-i0","node":"n1","label":"eth0","slot":0,"type":"physical"},
+				{"id":"n1-i1","node":"n1","label":"eth1","slot":1,"type":"physical"},
+				{"id":"n1-i2","node":"n1","label":"eth2","slot":2,"type":"physical"}
+			]`))
+		return httpmock.NewStringResponse(200, `[{"id":"n1-i2","node":"n1","label":"eth2","slot":2,"type":"physical"}]`), nil
+	})
+
+	httpmock.RegisterResponder("POST", base+"/links", func(req *http.Request) (*http.Response, error) {
+		var link struct {
+			Src string `json:"src_int"`
+			Dst string `json:"dst_int"`
+		}
+		_ = json.NewDecoder(req.Body).Decode(&link)
+		calls = append(calls, "POST links "+link.Src+"-"+link.Dst)
+		return httpmock.NewStringResponse(200, `{"id":"link-`+link.Src+`"}`), nil
+	})
+	for _, id := range []string{"link-n1-i0", "link-n1-i2"} {
+		httpmock.RegisterResponder("GET", base+"/links/"+id,
+			httpmock.NewStringResponder(200, `{"id":"`+id+`","lab_id":"lab-1"}`))
+	}
+	httpmock.RegisterResponder("DELETE", base+"/links/l2/condition", record(204, ""))
+	httpmock.RegisterResponder("PATCH", base+"/links/link-n1-i2/condition", record(200, `{"latency":10}`))
+	httpmock.RegisterResponder("POST", base+"/annotations", record(200, `{"id":"a3","type":"text"}`))
+
+	desired, err := models.ParseLabTopology([]byte(reconcileDesired))
+	assert.NoError(t, err)
+
+	service := newReconcileTestService(client)
+	plan, err := service.Plan(context.Background(), "lab-1", desired)
+	assert.NoError(t, err)
+	assert.False(t, plan.Empty())
+	assert.Equal(t, []models.InterfacePlan{
+		{Action: models.PlanActionCreate, Node: "r1", Label: "eth2", Slot: 2},
+		{Action: models.PlanActionDelete, Node: "r3", Label: "eth1", Slot: 1, ID: "n3-i1"},
+	}, plan.Interfaces)
+	plan.Wait = models.WaitOptions{Interval: time.Millisecond}
+	assert.NoError(t, service.Apply(context.Background(), &plan))
+
+	assert.Equal(t, []string{
+		"DELETE links/l1",
+		"DELETE interfaces/n3-i1",
+		"DELETE annotations/a2",
+		"PUT nodes/n2/state/stop",
+		"GET nodes/n2 STARTED",
+		"GET nodes/n2 STOPPED",
+		"PUT nodes/n2/wipe_disks",
+		"GET nodes/n2 STOPPED",
+		"GET nodes/n2 DEFINED_ON_CORE",
+		"DELETE nodes/n2",
+		"DELETE nodes/n5",
+		"PATCH nodes/n1",
+		"POST nodes r2",
+		"POST nodes r4",
+		"POST interfaces",
+		"POST links n1-i0-new-r2-i0",
+		"POST links n1-i2-new-r4-i0",
+		"DELETE links/l2/condition",
+		"PATCH links/link-n1-i2/condition",
+		"POST annotations",
+	}, calls)
+}
+
+func TestReconcilePlan_Parameters(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+	registerReconcileLab()
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab-1/nodes",
+		httpmock.NewStringResponder(200, `[
+			{"id":"n1","lab_id":"lab-1","label":"r1","x":100,"y":0,"node_definition":"iosv","state":"DEFINED_ON_CORE","tags":[],"parameters":{"smbios.bios.vendor":"acme"}},
+			{"id":"n2","lab_id":"lab-1","label":"r2","x":0,"y":100,"node_definition":"iosv","ram":1024,"state":"STARTED","tags":[],"parameters":{"smbios.bios.vendor":"acme"}},
+			{"id":"n3","lab_id":"lab-1","label":"r3","x":100,"y":100,"node_definition":"iosv","state":"DEFINED_ON_CORE","tags":[],"parameters":{"smbios.bios.vendor":"acme"}}
+		]`))
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab-1/links/l1/condition",
+		httpmock.NewStringResponder(200, `{}`))
+
+	desired, err := models.ParseLabTopology([]byte(reconcileDesired))
+	assert.NoError(t, err)
+	// r1 keeps its parameters, r2 has the same ones, r3 and r4 get new ones
+	desired.Nodes[1].Parameters = models.NodeParameters{models.ParamSMBIOSBIOSVendor: "acme"}
+	desired.Nodes[2].Parameters = models.NodeParameters{models.ParamSMBIOSBIOSVendor: "other"}
+	desired.Nodes[3].Parameters = models.NodeParameters{models.ParamSMBIOSBIOSVendor: "acme"}
+
+	service := newReconcileTestService(client)
+	plan, err := service.Plan(context.Background(), "lab-1", desired)
+	assert.NoError(t, err)
+
+	assert.Len(t, plan.Nodes, 2)
+	r3, r4 := plan.Nodes[0], plan.Nodes[1]
+	assert.Equal(t, models.PlanActionUpdate, r3.Action)
+	assert.Equal(t, []models.PlanChange{{
+		Field: "parameters",
+		Old:   map[string]any{models.ParamSMBIOSBIOSVendor: "acme"},
+		New:   map[string]any{models.ParamSMBIOSBIOSVendor: "other"},
+	}}, r3.Changes)
+	assert.Equal(t, models.PlanActionCreate, r4.Action)
+
+	// created nodes get the parameters of the topology
+	node := desiredNode("lab-1", r4.Desired, nil)
+	assert.Equal(t, models.NodeParameters{models.ParamSMBIOSBIOSVendor: "acme"}, node.NodeParameters())
+}
+
+func TestReconcilePlan_Error(t *testing.T) {
+	if testutil.IsLiveTesting() {
+		t.Skip("Skipping on live server - test expects specific mock data")
+	}
+
+	client, cleanup := testutil.NewAPIClient(t)
+	defer cleanup()
+	registerReconcileLab()
+
+	// link references an interface which is not in the topology
+	desired, err := models.ParseLabTopology([]byte(reconcileDesired))
+	assert.NoError(t, err)
+	desired.Links[0].I2 = "i9"
+
+	service := newReconcileTestService(client)
+	_, err = service.Plan(context.Background(), "lab-1", desired)
+	assert.ErrorIs(t, err, errors.ErrElementNotFound)
+	assert.Contains(t, err.Error(), "plan lab lab-1")
+
+	// nodes are matched by label, duplicates can't be matched
+	desired.Links[0].I2 = "i0"
+	desired.Nodes[3].Label = "r1"
+	_, err = service.Plan(context.Background(), "lab-1", desired)
+	assert.ErrorIs(t, err, errors.ErrInvalidInput)
+	assert.Contains(t, err.Error(), `duplicate node label "r1" in topology`)
+
+	desired.Nodes[3].Label = "r4"
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab-1/nodes",
+		httpmock.NewStringResponder(200, `[
+			{"id":"n1","lab_id":"lab-1","label":"r1","node_definition":"iosv","state":"DEFINED_ON_CORE","tags":[]},
+			{"id":"n2","lab_id":"lab-1","label":"r1","node_definition":"iosv","state":"DEFINED_ON_CORE","tags":[]}
+		]`))
+	httpmock.RegisterResponder("GET", "https://mock/api/v0/labs/lab-1/nodes/n2/interfaces",
+		httpmock.NewStringResponder(200, `[]`))
+	_, err = service.Plan(context.Background(), "lab-1", desired)
+	assert.ErrorIs(t, err, errors.ErrInvalidInput)
+	assert.Contains(t, err.Error(), `duplicate node label "r1" in lab`)
+}
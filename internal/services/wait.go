@@ -0,0 +1,65 @@
+// Package services, polling helper
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rschmied/gocmlclient/pkg/errors"
+	"github.com/rschmied/gocmlclient/pkg/models"
+)
+
+// waitFor calls check until it returns true, check fails, the context is
+// done or the timeout of the options expires. A timeout, either from the
+// options or from the context deadline, is reported as errors.ErrTimeout.
+func waitFor(ctx context.Context, opts models.WaitOptions, check func(ctx context.Context) (bool, error)) error {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = models.DefaultWaitInterval
+	}
+	maxInterval := opts.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = models.DefaultWaitMaxInterval
+	}
+	maxInterval = max(maxInterval, interval)
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	for {
+		if ctx.Err() != nil {
+			return waitError(ctx)
+		}
+		done, err := check(ctx)
+		if err != nil {
+			// a check aborted by the deadline is a timeout, too
+			if ctx.Err() != nil {
+				return waitError(ctx)
+			}
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return waitError(ctx)
+		case <-time.After(interval):
+		}
+		if opts.Backoff > 1 {
+			interval = min(time.Duration(float64(interval)*opts.Backoff), maxInterval)
+		}
+	}
+}
+
+func waitError(ctx context.Context) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%w: %w", errors.ErrTimeout, ctx.Err())
+	}
+	return ctx.Err()
+}
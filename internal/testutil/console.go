@@ -0,0 +1,139 @@
+package testutil
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// FakeConsole is the server side of a console session of the fake console
+// server. Read returns what the client typed, Write sends console output.
+type FakeConsole struct {
+	Key string
+
+	br  *bufio.Reader
+	w   io.Writer
+	wmu sync.Mutex
+
+	remaining uint64
+	mask      []byte
+	maskPos   int
+}
+
+// Read returns the payload of the client's messages.
+func (c *FakeConsole) Read(p []byte) (int, error) {
+	for c.remaining == 0 {
+		var head [2]byte
+		if _, err := io.ReadFull(c.br, head[:]); err != nil {
+			return 0, err
+		}
+		length := uint64(head[1] & 0x7f)
+		switch length {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+				return 0, err
+			}
+			length = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+				return 0, err
+			}
+			length = binary.BigEndian.Uint64(ext[:])
+		}
+		mask := make([]byte, 4)
+		if _, err := io.ReadFull(c.br, mask); err != nil {
+			return 0, err
+		}
+		switch head[0] & 0x0f {
+		case 0x8: // close
+			return 0, io.EOF
+		case 0x0, 0x1, 0x2:
+			c.remaining, c.mask, c.maskPos = length, mask, 0
+		default:
+			if _, err := io.CopyN(io.Discard, c.br, int64(length)); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if uint64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.br.Read(p)
+	for i := range n {
+		p[i] ^= c.mask[(c.maskPos+i)%4]
+	}
+	c.maskPos += n
+	c.remaining -= uint64(n)
+	return n, err
+}
+
+// Write sends `p` as one unmasked text message.
+func (c *FakeConsole) Write(p []byte) (int, error) {
+	return len(p), c.writeFrame(0x1, p)
+}
+
+// Ping sends a ping message, the client answers with a pong which is
+// skipped by Read.
+func (c *FakeConsole) Ping() error {
+	return c.writeFrame(0x9, []byte("ping"))
+}
+
+func (c *FakeConsole) writeFrame(opcode byte, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	frame := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	_, err := c.w.Write(append(frame, payload...))
+	return err
+}
+
+// NewFakeConsoleServer starts a websocket server which serves consoles at
+// /ws/pty/{key}, the way the controller does. The handler runs the console
+// for each connection, the connection is closed when it returns. The
+// server is closed when the test ends.
+func NewFakeConsoleServer(t *testing.T, handler func(console *FakeConsole)) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, found := strings.CutPrefix(r.URL.Path, "/ws/pty/")
+		if !found || !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			http.Error(w, `{"description": "not found"}`, http.StatusNotFound)
+			return
+		}
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("hijack: %v", err)
+			return
+		}
+		defer conn.Close() //nolint:errcheck
+
+		sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+			"Upgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+		if err := rw.Flush(); err != nil {
+			return
+		}
+
+		handler(&FakeConsole{Key: key, br: rw.Reader, w: conn})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
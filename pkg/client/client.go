@@ -40,6 +40,9 @@ type Client struct {
 	ExtConn         *services.ExtConnService
 	Annotation      *services.AnnotationService
 	SmartAnnotation *services.SmartAnnotationService
+	Reconcile       *services.ReconcileService
+	Console         *services.ConsoleService
+	PCAP            *services.PCAPService
 }
 
 // New creates a new CML client with the given options.
@@ -73,17 +76,31 @@ func New(baseURL string, opts ...Option) (*Client, error) {
 	linkService := services.NewLinkService(apiClient)
 	linkService.Interface = interfaceService
 	linkService.Node = nodeService
+	nodeService.Link = linkService
 
 	imageDefinitionService := services.NewImageDefinitionService(apiClient)
 	nodeDefinitionService := services.NewNodeDefinitionService(apiClient)
+	nodeService.NodeDefinition = nodeDefinitionService
+	linkService.NodeDefinition = nodeDefinitionService
 	extConnService := services.NewExtConnService(apiClient)
 	annotationService := services.NewAnnotationService(apiClient)
 	smartAnnotationService := services.NewSmartAnnotationService(apiClient)
+	consoleService := services.NewConsoleService(apiClient)
+	pcapService := services.NewPCAPService(apiClient)
+
+	labService := services.NewLabService(apiClient, interfaceService, linkService, userService, nodeService)
+	labService.SetNamedConfigs(cfg.namedConfigs)
+	labService.NodeDefinition = nodeDefinitionService
+	labService.ImageDefinition = imageDefinitionService
+	labService.Annotation = annotationService
+	labService.SmartAnnotation = smartAnnotationService
+	labService.Console = consoleService
+	labService.PCAP = pcapService
 
 	c := &Client{
 		config:          cfg,
 		apiClient:       apiClient,
-		Lab:             services.NewLabService(apiClient, interfaceService, linkService, userService, nodeService),
+		Lab:             labService,
 		Interface:       interfaceService,
 		Link:            linkService,
 		Node:            nodeService,
@@ -95,6 +112,9 @@ func New(baseURL string, opts ...Option) (*Client, error) {
 		ExtConn:         extConnService,
 		Annotation:      annotationService,
 		SmartAnnotation: smartAnnotationService,
+		Reconcile:       services.NewReconcileService(labService, nodeService, linkService, interfaceService, annotationService),
+		Console:         consoleService,
+		PCAP:            pcapService,
 	}
 
 	// If configured, force deterministic node configuration query behavior across
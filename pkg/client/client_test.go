@@ -1,15 +1,19 @@
 package client
 
 import (
+	"bufio"
 	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/rschmied/gocmlclient/internal/api"
+	"github.com/rschmied/gocmlclient/internal/testutil"
 	"github.com/rschmied/gocmlclient/pkg/models"
 )
 
@@ -403,3 +407,35 @@ func TestNew_WithInvalidRequestHeaderName_ReturnsError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, client)
 }
+
+func TestClient_ConsoleSession(t *testing.T) {
+	// the default HTTP client has a total timeout, the websocket upgrade must
+	// work regardless
+	server := testutil.NewFakeConsoleServer(t, func(console *testutil.FakeConsole) {
+		_, _ = io.WriteString(console, "router>")
+		line, err := bufio.NewReader(console).ReadString('\r')
+		if err != nil {
+			return
+		}
+		_, _ = io.WriteString(console, line+"\nrouter>")
+	})
+
+	c, err := New(server.URL, WithStaticToken("t"), SkipReadyCheck())
+	assert.NoError(t, err)
+
+	// the context bounds the handshake only
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	session, err := c.Console.OpenKey(ctx, "key-1")
+	cancel()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer session.Close() //nolint:errcheck
+
+	prompt := regexp.MustCompile(`router>`)
+	_, err = session.Expect(context.Background(), prompt, time.Second)
+	assert.NoError(t, err)
+	out, err := session.SendExpect(context.Background(), "show clock", prompt, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "show clock\r\nrouter>", out)
+}
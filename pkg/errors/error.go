@@ -85,6 +85,43 @@ func (e *ValidationError) Unwrap() error {
 	return e.Cause
 }
 
+// ValidationErrors is a list of validation errors, e.g. the result of
+// validating a complete document where all problems should be reported and
+// not just the first one.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	switch len(e) {
+	case 0:
+		return "no validation errors"
+	case 1:
+		return e[0].Error()
+	}
+	msgs := make([]string, 0, len(e))
+	for _, ve := range e {
+		msgs = append(msgs, ve.Error())
+	}
+	return fmt.Sprintf("%d validation errors: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the individual validation errors so that errors.Is and
+// errors.As work on the list.
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, ve := range e {
+		errs = append(errs, ve)
+	}
+	return errs
+}
+
+// Err returns the list as an error or nil if the list is empty.
+func (e ValidationErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
 // WrapTLSError wraps a TLS error with a clear sentinel value
 func WrapTLSError(err error) error {
 	return err
@@ -230,6 +230,26 @@ func TestValidationErrorUnwrap(t *testing.T) {
 	assert.True(t, errors.Is(valErr.Unwrap(), cause))
 }
 
+func TestValidationErrors(t *testing.T) {
+	var none ValidationErrors
+	assert.NoError(t, none.Err())
+	assert.Equal(t, "no validation errors", none.Error())
+
+	one := ValidationErrors{NewValidationError("name", "", "cannot be empty", ErrMissingRequired)}
+	assert.Equal(t, "validation failed for field \"name\": cannot be empty", one.Error())
+
+	two := append(one, NewValidationError("slot", 9, "out of range", ErrInvalidInput))
+	err := two.Err()
+	assert.Error(t, err)
+	assert.Equal(t, "2 validation errors: validation failed for field \"name\": cannot be empty; validation failed for field \"slot\": out of range", err.Error())
+	assert.True(t, errors.Is(err, ErrMissingRequired))
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+
+	var ve *ValidationError
+	assert.True(t, errors.As(err, &ve))
+	assert.Equal(t, "name", ve.Field)
+}
+
 func TestNewAPIError(t *testing.T) {
 	cause := errors.New("connection failed")
 	apiErr := NewAPIError("login", 401, "invalid credentials", cause)
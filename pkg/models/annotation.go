@@ -136,6 +136,42 @@ func (a Annotation) MarshalJSON() ([]byte, error) {
 	}
 }
 
+// ID returns the ID of the annotation, regardless of its type.
+func (a Annotation) ID() UUID {
+	switch {
+	case a.Text != nil:
+		return a.Text.ID
+	case a.Rectangle != nil:
+		return a.Rectangle.ID
+	case a.Ellipse != nil:
+		return a.Ellipse.ID
+	case a.Line != nil:
+		return a.Line.ID
+	}
+	return ""
+}
+
+// CreateRequest returns the create payload for an annotation with the same
+// content, e.g. to copy an annotation into another lab.
+func (a Annotation) CreateRequest() AnnotationCreate {
+	create := AnnotationCreate{Type: a.Type}
+	switch {
+	case a.Text != nil:
+		v := a.Text.TextAnnotation
+		create.Text = &v
+	case a.Rectangle != nil:
+		v := a.Rectangle.RectangleAnnotation
+		create.Rectangle = &v
+	case a.Ellipse != nil:
+		v := a.Ellipse.EllipseAnnotation
+		create.Ellipse = &v
+	case a.Line != nil:
+		v := a.Line.LineAnnotation
+		create.Line = &v
+	}
+	return create
+}
+
 // AnnotationCreate is the request payload for creating an annotation.
 // Exactly one of Text/Rectangle/Ellipse/Line should be set.
 type AnnotationCreate struct {
@@ -389,6 +425,26 @@ type SmartAnnotation struct {
 	ZIndex        *int         `json:"z_index,omitempty"`
 }
 
+// UpdateRequest returns the update payload carrying all the values of the
+// smart annotation, e.g. to copy it into another lab.
+func (a SmartAnnotation) UpdateRequest() SmartAnnotationUpdate {
+	return SmartAnnotationUpdate{
+		BorderColor:   a.BorderColor,
+		BorderStyle:   a.BorderStyle,
+		FillColor:     a.FillColor,
+		GroupDistance: a.GroupDistance,
+		IsOn:          a.IsOn,
+		Label:         a.Label,
+		Padding:       a.Padding,
+		Tag:           a.Tag,
+		TagOffsetX:    a.TagOffsetX,
+		TagOffsetY:    a.TagOffsetY,
+		TagSize:       a.TagSize,
+		Thickness:     a.Thickness,
+		ZIndex:        a.ZIndex,
+	}
+}
+
 // SmartAnnotationUpdate represents a smart annotation update payload.
 type SmartAnnotationUpdate struct {
 	BorderColor   *string      `json:"border_color,omitempty"`
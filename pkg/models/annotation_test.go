@@ -281,3 +281,49 @@ func TestAnnotation_Unmarshal_NonObjectJSON(t *testing.T) {
 	err := json.Unmarshal([]byte(`123`), &a)
 	assert.Error(t, err)
 }
+
+func TestAnnotation_IDAndCreateRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"text", `{"id":"t1","type":"text","border_color":"#000","border_style":"","color":"#fff","thickness":1,"x1":1,"y1":2,"z_index":0,"rotation":0,"text_bold":false,"text_content":"hi","text_font":"sans","text_italic":false,"text_size":12,"text_unit":"px"}`},
+		{"rectangle", `{"id":"r1","type":"rectangle","border_color":"#000","border_style":"","color":"#fff","thickness":1,"x1":1,"y1":2,"x2":3,"y2":4,"z_index":0,"rotation":0,"border_radius":1}`},
+		{"ellipse", `{"id":"e1","type":"ellipse","border_color":"#000","border_style":"","color":"#fff","thickness":1,"x1":1,"y1":2,"x2":3,"y2":4,"z_index":0,"rotation":0}`},
+		{"line", `{"id":"l1","type":"line","border_color":"#000","border_style":"","color":"#fff","thickness":1,"x1":1,"y1":2,"x2":3,"y2":4,"z_index":0,"line_start":"arrow","line_end":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a Annotation
+			assert.NoError(t, json.Unmarshal([]byte(tt.data), &a))
+			assert.Equal(t, UUID(tt.name[:1]+"1"), a.ID())
+
+			b, err := json.Marshal(a.CreateRequest())
+			assert.NoError(t, err)
+
+			var want, got map[string]any
+			assert.NoError(t, json.Unmarshal([]byte(tt.data), &want))
+			assert.NoError(t, json.Unmarshal(b, &got))
+			delete(want, "id")
+			assert.Equal(t, want, got)
+		})
+	}
+
+	assert.Equal(t, UUID(""), Annotation{}.ID())
+}
+
+func TestSmartAnnotation_UpdateRequest(t *testing.T) {
+	var sa SmartAnnotation
+	data := `{"id":"s1","tag":"core","is_on":true,"label":"Core","padding":35,"tag_offset_x":0,"tag_offset_y":0,"tag_size":14,"group_distance":400,"thickness":1,"border_style":"","fill_color":"#80808080","border_color":"#00000000","z_index":1}`
+	assert.NoError(t, json.Unmarshal([]byte(data), &sa))
+
+	b, err := json.Marshal(sa.UpdateRequest())
+	assert.NoError(t, err)
+
+	var want, got map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(data), &want))
+	assert.NoError(t, json.Unmarshal(b, &got))
+	delete(want, "id")
+	assert.Equal(t, want, got)
+}
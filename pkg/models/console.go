@@ -0,0 +1,67 @@
+// Package models provides the models for Cisco Modeling Labs
+// here: console related types
+package models
+
+import (
+	"slices"
+	"strings"
+)
+
+// consoleCursorLines is the number of lines a console cursor remembers to
+// find its position again.
+const consoleCursorLines = 10
+
+// ConsoleLog is (a part of) the buffered console log of a serial device of
+// a node, split into lines.
+type ConsoleLog struct {
+	Lines []string
+	// Cursor is the position after the last complete line in the buffer, to
+	// be used for the next read of new lines.
+	Cursor ConsoleCursor
+}
+
+// ConsoleCursor is a position in the console log of a serial device. The
+// controller buffers a limited amount of console output, old lines are
+// dropped when new ones arrive. Hence the position is kept by content: the
+// cursor remembers the last lines read and finds them again in the buffer.
+// The zero value is the start of the log.
+type ConsoleCursor struct {
+	lines int
+	tail  []string
+}
+
+// NewConsoleCursor returns the cursor after the given complete lines of a
+// console log.
+func NewConsoleCursor(lines []string) ConsoleCursor {
+	return ConsoleCursor{
+		lines: len(lines),
+		tail:  slices.Clone(lines[max(0, len(lines)-consoleCursorLines):]),
+	}
+}
+
+// Since returns the lines after the cursor among the given complete lines of
+// the console log and the cursor after them. The lines of the cursor are
+// looked for at or before the previous position, old lines are only ever
+// dropped from the start of the buffer. After a roll over only the last of
+// them may be left at the start of the buffer, the first one possibly cut.
+// If none of them is left, e.g. because more output than the buffer holds
+// arrived in the meantime or the buffer was cleared, all lines are returned.
+func (c ConsoleCursor) Since(lines []string) ([]string, ConsoleCursor) {
+	next := NewConsoleCursor(lines)
+	for end := min(c.lines, len(lines)); end > 0 && len(c.tail) > 0; end-- {
+		if c.endsAt(lines, end) {
+			return lines[end:], next
+		}
+	}
+	return lines, next
+}
+
+// endsAt returns true if the lines of the cursor end at `end`.
+func (c ConsoleCursor) endsAt(lines []string, end int) bool {
+	if end >= len(c.tail) {
+		return slices.Equal(lines[end-len(c.tail):end], c.tail)
+	}
+	// partial overlap at the start of the buffer
+	tail := c.tail[len(c.tail)-end:]
+	return slices.Equal(lines[1:end], tail[1:]) && strings.HasSuffix(tail[0], lines[0])
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsoleCursor(t *testing.T) {
+	lines := func(s ...string) []string { return s }
+
+	tests := []struct {
+		name   string
+		before []string
+		now    []string
+		want   []string
+	}{
+		{"start", nil, lines("a", "b"), lines("a", "b")},
+		{"no change", lines("a", "b"), lines("a", "b"), lines()},
+		{"appended", lines("a", "b"), lines("a", "b", "c"), lines("c")},
+		{"rolled over", lines("a", "b", "c"), lines("b", "c", "d", "e"), lines("d", "e")},
+		{"partial overlap", lines("a", "b", "c"), lines("c", "d", "e"), lines("d", "e")},
+		{"cut first line", lines("a", "bbbb", "cccc"), lines("bb", "cccc", "d"), lines("d")},
+		{"repeated lines", lines("x", "Router>", "Router>"), lines("x", "Router>", "Router>", "Router>"), lines("Router>")},
+		{"dropped", lines("a", "b"), lines("c", "d"), lines("c", "d")},
+		{"cleared", lines("a", "b", "c"), lines("d"), lines("d")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor := ConsoleCursor{}
+			if tt.before != nil {
+				cursor = NewConsoleCursor(tt.before)
+			}
+			got, next := cursor.Since(tt.now)
+			assert.Equal(t, append([]string{}, tt.want...), append([]string{}, got...))
+			assert.Equal(t, NewConsoleCursor(tt.now), next)
+		})
+	}
+
+	// only the last lines are remembered
+	many := make([]string, 3*consoleCursorLines)
+	for i := range many {
+		many[i] = string(rune('a' + i))
+	}
+	got, _ := NewConsoleCursor(many[:2*consoleCursorLines]).Since(many[consoleCursorLines:])
+	assert.Equal(t, many[2*consoleCursorLines:], got)
+}
@@ -0,0 +1,649 @@
+// Package models provides the models for Cisco Modeling Labs
+// here: structural diff of labs and topologies
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DiffKind describes how an element differs.
+type DiffKind string
+
+const (
+	// DiffAdded marks an element which only exists in the new topology.
+	DiffAdded DiffKind = "added"
+	// DiffRemoved marks an element which only exists in the old topology.
+	DiffRemoved DiffKind = "removed"
+	// DiffChanged marks an element which exists in both with changed fields.
+	DiffChanged DiffKind = "changed"
+)
+
+func (k DiffKind) symbol() string {
+	switch k {
+	case DiffAdded:
+		return "+"
+	case DiffRemoved:
+		return "-"
+	}
+	return "~"
+}
+
+// FieldDiff is a changed field. Nested fields are separated by dots (e.g.
+// "conditioning.latency"), named configurations are reported as
+// "configuration[name]". A nil value means the field is not set.
+type FieldDiff struct {
+	Field string
+	Old   any
+	New   any
+}
+
+// ElementDiff describes a node, interface, link or annotation which differs.
+// Name identifies the element by labels, e.g. `r1`, `r1/eth0` or
+// `"r1":"eth0" <-> "r2":"eth0"`.
+type ElementDiff struct {
+	Kind   DiffKind
+	Name   string
+	OldID  string
+	NewID  string
+	Fields []FieldDiff
+}
+
+// TopologyDiff is the structural difference between two topologies.
+type TopologyDiff struct {
+	Lab         []FieldDiff
+	Nodes       []ElementDiff
+	Interfaces  []ElementDiff
+	Links       []ElementDiff
+	Annotations []ElementDiff
+}
+
+// Empty returns true if the topologies are the same.
+func (d *TopologyDiff) Empty() bool {
+	return len(d.Lab) == 0 && len(d.Nodes) == 0 && len(d.Interfaces) == 0 &&
+		len(d.Links) == 0 && len(d.Annotations) == 0
+}
+
+// String returns the diff as text. Changed multi-line values such as
+// configurations are shown as line diff.
+func (d *TopologyDiff) String() string {
+	if d.Empty() {
+		return "No differences.\n"
+	}
+
+	var b strings.Builder
+	if len(d.Lab) > 0 {
+		b.WriteString("~ lab\n")
+		writeFieldDiffs(&b, d.Lab)
+	}
+	for _, group := range []struct {
+		element string
+		diffs   []ElementDiff
+	}{
+		{"node", d.Nodes},
+		{"interface", d.Interfaces},
+		{"link", d.Links},
+		{"annotation", d.Annotations},
+	} {
+		for _, diff := range group.diffs {
+			fmt.Fprintf(&b, "%s %s %s\n", diff.Kind.symbol(), group.element, diff.Name)
+			writeFieldDiffs(&b, diff.Fields)
+		}
+	}
+	return b.String()
+}
+
+func writeFieldDiffs(b *strings.Builder, fields []FieldDiff) {
+	for _, f := range fields {
+		oldStr, oldOK := f.Old.(string)
+		newStr, newOK := f.New.(string)
+		if (oldOK || f.Old == nil) && (newOK || f.New == nil) &&
+			(strings.Contains(oldStr, "\n") || strings.Contains(newStr, "\n")) {
+			fmt.Fprintf(b, "    ~ %s:\n", f.Field)
+			for _, line := range lineDiff(oldStr, newStr) {
+				fmt.Fprintf(b, "        %s\n", line)
+			}
+			continue
+		}
+		fmt.Fprintf(b, "    ~ %s: %s -> %s\n", f.Field, planValue(f.Old), planValue(f.New))
+	}
+}
+
+// maxLineDiffCells limits the size of the table lineDiff uses for the
+// longest common subsequence, about 8MB.
+const maxLineDiffCells = 1 << 20
+
+// lineDiff returns the removed ("- ") and added ("+ ") lines, based on the
+// longest common subsequence of both texts. The common prefix and suffix are
+// skipped first. If the remaining lines of both texts are too many to
+// compare, they are shown as all removed and added instead.
+func lineDiff(oldText, newText string) []string {
+	a := strings.Split(strings.TrimSuffix(oldText, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(newText, "\n"), "\n")
+	if len(oldText) == 0 {
+		a = nil
+	}
+	if len(newText) == 0 {
+		b = nil
+	}
+
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	var out []string
+	if (len(a)+1)*(len(b)+1) > maxLineDiffCells {
+		for _, line := range a {
+			out = append(out, "- "+line)
+		}
+		for _, line := range b {
+			out = append(out, "+ "+line)
+		}
+		return out
+	}
+
+	// lcs(i, j) is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	width := len(b) + 1
+	table := make([]int, (len(a)+1)*width)
+	lcs := func(i, j int) int { return table[i*width+j] }
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i*width+j] = lcs(i+1, j+1) + 1
+			} else {
+				table[i*width+j] = max(lcs(i+1, j), lcs(i, j+1))
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs(i+1, j) >= lcs(i, j+1)):
+			out = append(out, "- "+a[i])
+			i++
+		default:
+			out = append(out, "+ "+b[j])
+			j++
+		}
+	}
+	return out
+}
+
+// DiffLabs returns the structural difference between two labs, see
+// DiffTopologies. Annotations and link conditions are not part of Lab and
+// are not compared.
+func DiffLabs(oldLab, newLab *Lab) TopologyDiff {
+	oldTopo, newTopo := oldLab.Topology(), newLab.Topology()
+	return DiffTopologies(&oldTopo, &newTopo)
+}
+
+// DiffTopologies returns the structural difference between two topologies.
+// Nodes are matched by label first and by ID second, so that nodes of a lab
+// and its source topology (which use different IDs), nodes whose IDs shifted
+// after a deletion and renamed nodes are matched. Interfaces are matched the
+// same way within matched nodes, links by their endpoints or by ID. Annotations have
+// no identity, they are matched by content first and by order and type
+// second.
+func DiffTopologies(oldTopo, newTopo *LabTopology) TopologyDiff {
+	d := topologyDiffer{old: oldTopo, new: newTopo}
+	d.diffLab()
+	d.diffNodes()
+	d.diffLinks()
+	d.diffAnnotations()
+	return d.diff
+}
+
+type topologyDiffer struct {
+	old, new *LabTopology
+	diff     TopologyDiff
+
+	// matched node indices, old -> new
+	nodes map[int]int
+}
+
+func (d *topologyDiffer) diffLab() {
+	d.diff.Lab = fieldDiffs(
+		map[string]any{"title": d.old.Lab.Title, "description": d.old.Lab.Description, "notes": d.old.Lab.Notes},
+		map[string]any{"title": d.new.Lab.Title, "description": d.new.Lab.Description, "notes": d.new.Lab.Notes},
+	)
+}
+
+// matchByLabelAndID matches elements by label, the remaining ones by ID.
+// Labels come first as the controller reassigns topology IDs (n0, i0, ...)
+// after deletions, the same ID may denote a different element.
+func matchByLabelAndID(oldLen, newLen int, label, id func(old bool, idx int) string) map[int]int {
+	matches := make(map[int]int)
+	matched := make(map[int]bool)
+	for _, key := range []func(bool, int) string{label, id} {
+		index := make(map[string]int)
+		for j := 0; j < newLen; j++ {
+			if k := key(false, j); !matched[j] && len(k) > 0 {
+				index[k] = j
+			}
+		}
+		for i := 0; i < oldLen; i++ {
+			if _, found := matches[i]; found {
+				continue
+			}
+			if j, found := index[key(true, i)]; found && !matched[j] && len(key(true, i)) > 0 {
+				matches[i] = j
+				matched[j] = true
+			}
+		}
+	}
+	return matches
+}
+
+func (d *topologyDiffer) diffNodes() {
+	d.nodes = matchByLabelAndID(len(d.old.Nodes), len(d.new.Nodes),
+		func(old bool, idx int) string { return d.node(old, idx).Label },
+		func(old bool, idx int) string { return d.node(old, idx).ID },
+	)
+
+	matched := make(map[int]bool)
+	for i := range d.old.Nodes {
+		oldNode := &d.old.Nodes[i]
+		j, found := d.nodes[i]
+		if !found {
+			d.diff.Nodes = append(d.diff.Nodes, ElementDiff{Kind: DiffRemoved, Name: oldNode.Label, OldID: oldNode.ID})
+			for _, iface := range oldNode.Interfaces {
+				d.diff.Interfaces = append(d.diff.Interfaces, ElementDiff{
+					Kind: DiffRemoved, Name: oldNode.Label + "/" + iface.Label, OldID: iface.ID,
+				})
+			}
+			continue
+		}
+		matched[j] = true
+		newNode := &d.new.Nodes[j]
+
+		fields := fieldDiffs(nodeFields(oldNode), nodeFields(newNode))
+		if len(fields) > 0 {
+			d.diff.Nodes = append(d.diff.Nodes, ElementDiff{
+				Kind: DiffChanged, Name: newNode.Label, OldID: oldNode.ID, NewID: newNode.ID, Fields: fields,
+			})
+		}
+		d.diffInterfaces(oldNode, newNode)
+	}
+
+	for j := range d.new.Nodes {
+		if matched[j] {
+			continue
+		}
+		newNode := &d.new.Nodes[j]
+		d.diff.Nodes = append(d.diff.Nodes, ElementDiff{Kind: DiffAdded, Name: newNode.Label, NewID: newNode.ID})
+		for _, iface := range newNode.Interfaces {
+			d.diff.Interfaces = append(d.diff.Interfaces, ElementDiff{
+				Kind: DiffAdded, Name: newNode.Label + "/" + iface.Label, NewID: iface.ID,
+			})
+		}
+	}
+	sortElementDiffs(d.diff.Nodes)
+	sortElementDiffs(d.diff.Interfaces)
+}
+
+func (d *topologyDiffer) node(old bool, idx int) *NodeTopology {
+	if old {
+		return &d.old.Nodes[idx]
+	}
+	return &d.new.Nodes[idx]
+}
+
+func (d *topologyDiffer) diffInterfaces(oldNode, newNode *NodeTopology) {
+	iface := func(old bool, idx int) *InterfaceTopology {
+		if old {
+			return &oldNode.Interfaces[idx]
+		}
+		return &newNode.Interfaces[idx]
+	}
+	matches := matchByLabelAndID(len(oldNode.Interfaces), len(newNode.Interfaces),
+		func(old bool, idx int) string { return iface(old, idx).Label },
+		func(old bool, idx int) string { return iface(old, idx).ID },
+	)
+
+	matched := make(map[int]bool)
+	for i := range oldNode.Interfaces {
+		oldIface := &oldNode.Interfaces[i]
+		j, found := matches[i]
+		if !found {
+			d.diff.Interfaces = append(d.diff.Interfaces, ElementDiff{
+				Kind: DiffRemoved, Name: newNode.Label + "/" + oldIface.Label, OldID: oldIface.ID,
+			})
+			continue
+		}
+		matched[j] = true
+		newIface := &newNode.Interfaces[j]
+		fields := fieldDiffs(elementFields(oldIface, "id"), elementFields(newIface, "id"))
+		if len(fields) > 0 {
+			d.diff.Interfaces = append(d.diff.Interfaces, ElementDiff{
+				Kind: DiffChanged, Name: newNode.Label + "/" + newIface.Label, OldID: oldIface.ID, NewID: newIface.ID, Fields: fields,
+			})
+		}
+	}
+	for j := range newNode.Interfaces {
+		if !matched[j] {
+			newIface := &newNode.Interfaces[j]
+			d.diff.Interfaces = append(d.diff.Interfaces, ElementDiff{
+				Kind: DiffAdded, Name: newNode.Label + "/" + newIface.Label, NewID: newIface.ID,
+			})
+		}
+	}
+}
+
+// linkName returns the link endpoints by label. Old links use the labels of
+// the matching new nodes so that links of renamed nodes match.
+func (d *topologyDiffer) linkName(old bool, link *LinkTopology) string {
+	topo := d.new
+	if old {
+		topo = d.old
+	}
+	endpoint := func(nodeID, ifaceID string) LinkEndpoint {
+		for idx := range topo.Nodes {
+			node := &topo.Nodes[idx]
+			if node.ID != nodeID {
+				continue
+			}
+			ep := LinkEndpoint{Node: node.Label, Interface: ifaceID}
+			if iface := node.InterfaceByID(ifaceID); iface != nil {
+				ep.Interface = iface.Label
+			}
+			if j, found := d.nodes[idx]; old && found {
+				ep.Node = d.new.Nodes[j].Label
+			}
+			return ep
+		}
+		return LinkEndpoint{Node: nodeID, Interface: ifaceID}
+	}
+	a, b := endpoint(link.N1, link.I1), endpoint(link.N2, link.I2)
+	if b.String() < a.String() {
+		a, b = b, a
+	}
+	return a.String() + " <-> " + b.String()
+}
+
+func (d *topologyDiffer) diffLinks() {
+	link := func(old bool, idx int) *LinkTopology {
+		if old {
+			return &d.old.Links[idx]
+		}
+		return &d.new.Links[idx]
+	}
+	matches := matchByLabelAndID(len(d.old.Links), len(d.new.Links),
+		func(old bool, idx int) string { return d.linkName(old, link(old, idx)) },
+		func(old bool, idx int) string { return link(old, idx).ID },
+	)
+
+	matched := make(map[int]bool)
+	for i := range d.old.Links {
+		oldLink := &d.old.Links[i]
+		j, found := matches[i]
+		if !found {
+			d.diff.Links = append(d.diff.Links, ElementDiff{Kind: DiffRemoved, Name: d.linkName(true, oldLink), OldID: oldLink.ID})
+			continue
+		}
+		matched[j] = true
+		newLink := &d.new.Links[j]
+
+		oldFields := elementFields(oldLink, "id", "n1", "n2", "i1", "i2")
+		newFields := elementFields(newLink, "id", "n1", "n2", "i1", "i2")
+		oldName, newName := d.linkName(true, oldLink), d.linkName(false, newLink)
+		if oldName != newName {
+			oldFields["endpoints"] = oldName
+			newFields["endpoints"] = newName
+		}
+		if fields := fieldDiffs(oldFields, newFields); len(fields) > 0 {
+			d.diff.Links = append(d.diff.Links, ElementDiff{
+				Kind: DiffChanged, Name: newName, OldID: oldLink.ID, NewID: newLink.ID, Fields: fields,
+			})
+		}
+	}
+	for j := range d.new.Links {
+		if !matched[j] {
+			newLink := &d.new.Links[j]
+			d.diff.Links = append(d.diff.Links, ElementDiff{Kind: DiffAdded, Name: d.linkName(false, newLink), NewID: newLink.ID})
+		}
+	}
+	sortElementDiffs(d.diff.Links)
+}
+
+func (d *topologyDiffer) diffAnnotations() {
+	name := func(a *AnnotationTopology) string {
+		return fmt.Sprintf("%s at (%g, %g)", a.Type, a.X1, a.Y1)
+	}
+
+	// identical annotations first
+	matchedOld := make(map[int]bool)
+	matchedNew := make(map[int]bool)
+	for i := range d.old.Annotations {
+		for j := range d.new.Annotations {
+			if !matchedNew[j] && reflect.DeepEqual(d.old.Annotations[i], d.new.Annotations[j]) {
+				matchedOld[i], matchedNew[j] = true, true
+				break
+			}
+		}
+	}
+
+	// then the remaining ones by order and type
+	j := 0
+	for i := range d.old.Annotations {
+		if matchedOld[i] {
+			continue
+		}
+		oldAnnotation := &d.old.Annotations[i]
+		for j < len(d.new.Annotations) && matchedNew[j] {
+			j++
+		}
+		if j < len(d.new.Annotations) && d.new.Annotations[j].Type == oldAnnotation.Type {
+			newAnnotation := &d.new.Annotations[j]
+			matchedNew[j] = true
+			if fields := fieldDiffs(elementFields(oldAnnotation), elementFields(newAnnotation)); len(fields) > 0 {
+				d.diff.Annotations = append(d.diff.Annotations, ElementDiff{
+					Kind:   DiffChanged,
+					Name:   name(newAnnotation),
+					Fields: fields,
+				})
+			}
+			continue
+		}
+		d.diff.Annotations = append(d.diff.Annotations, ElementDiff{Kind: DiffRemoved, Name: name(oldAnnotation)})
+	}
+	for j := range d.new.Annotations {
+		if !matchedNew[j] {
+			d.diff.Annotations = append(d.diff.Annotations, ElementDiff{Kind: DiffAdded, Name: name(&d.new.Annotations[j])})
+		}
+	}
+}
+
+// nodeFields returns the comparable fields of a node. Configurations are
+// reported per name, a single configuration string has the field name
+// "configuration".
+func nodeFields(n *NodeTopology) map[string]any {
+	fields := elementFields(n, "id", "interfaces", "configuration")
+	if len(n.Configuration) > 0 {
+		fields["configuration"] = n.Configuration
+	}
+	for _, cfg := range n.Configurations {
+		fields["configuration["+cfg.Name+"]"] = cfg.Content
+	}
+	return fields
+}
+
+// elementFields returns the JSON fields of `v` flattened into dotted names,
+// without the given fields.
+func elementFields(v any, omit ...string) map[string]any {
+	fields := make(map[string]any)
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fields
+	}
+	var attrs map[string]any
+	if err := json.Unmarshal(data, &attrs); err != nil {
+		return fields
+	}
+	for _, name := range omit {
+		delete(attrs, name)
+	}
+	flattenFields("", attrs, fields)
+	return fields
+}
+
+func flattenFields(prefix string, attrs map[string]any, out map[string]any) {
+	for name, value := range attrs {
+		if nested, ok := value.(map[string]any); ok {
+			flattenFields(prefix+name+".", nested, out)
+			continue
+		}
+		out[prefix+name] = value
+	}
+}
+
+// fieldDiffs returns the differing fields, sorted by name. Empty values
+// (e.g. an empty tag list) are considered the same as a missing field.
+func fieldDiffs(oldFields, newFields map[string]any) []FieldDiff {
+	names := make(map[string]bool, len(oldFields)+len(newFields))
+	for name := range oldFields {
+		names[name] = true
+	}
+	for name := range newFields {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var diffs []FieldDiff
+	for _, name := range sorted {
+		oldValue, newValue := emptyToNil(oldFields[name]), emptyToNil(newFields[name])
+		if !reflect.DeepEqual(oldValue, newValue) {
+			diffs = append(diffs, FieldDiff{Field: name, Old: oldValue, New: newValue})
+		}
+	}
+	return diffs
+}
+
+func emptyToNil(v any) any {
+	switch value := v.(type) {
+	case []any:
+		if len(value) == 0 {
+			return nil
+		}
+	case string:
+		if len(value) == 0 {
+			return nil
+		}
+	case bool:
+		if !value {
+			return nil
+		}
+	}
+	return v
+}
+
+func sortElementDiffs(diffs []ElementDiff) {
+	order := map[DiffKind]int{DiffRemoved: 0, DiffChanged: 1, DiffAdded: 2}
+	sort.SliceStable(diffs, func(i, j int) bool {
+		if diffs[i].Name != diffs[j].Name {
+			return diffs[i].Name < diffs[j].Name
+		}
+		return order[diffs[i].Kind] < order[diffs[j].Kind]
+	})
+}
+
+// Topology returns the lab in the form of a topology document, e.g. to
+// compare it with a topology. The topology IDs are the controller UUIDs.
+// Annotations and link conditions are not part of Lab and are not included.
+func (l *Lab) Topology() LabTopology {
+	topo := LabTopology{
+		Lab: TopologyLab{
+			Title:       l.Title,
+			Description: l.Description,
+			Notes:       l.Notes,
+		},
+		Nodes: make([]NodeTopology, 0, len(l.Nodes)),
+		Links: make([]LinkTopology, 0, len(l.Links)),
+	}
+
+	for _, node := range l.Nodes {
+		nt := NodeTopology{
+			ID:              string(node.ID),
+			Label:           node.Label,
+			X:               node.X,
+			Y:               node.Y,
+			NodeDefinition:  node.NodeDefinition,
+			ImageDefinition: node.ImageDefinition,
+			RAM:             node.RAM,
+			CPUlimit:        node.CPUlimit,
+			DataVolume:      node.DataVolume,
+			BootDiskSize:    node.BootDiskSize,
+			Priority:        node.Priority,
+			Tags:            node.Tags,
+			Configurations:  node.Configurations,
+		}
+		if node.CPUs > 0 {
+			cpus := node.CPUs
+			nt.CPUs = &cpus
+		}
+		if node.HideLinks != nil {
+			nt.HideLinks = *node.HideLinks
+		}
+		nt.Parameters = node.NodeParameters()
+		switch cfg := node.Configuration.(type) {
+		case string:
+			nt.Configuration = cfg
+		case *string:
+			if cfg != nil {
+				nt.Configuration = *cfg
+			}
+		}
+		for _, iface := range node.Interfaces {
+			nt.Interfaces = append(nt.Interfaces, InterfaceTopology{
+				ID:         string(iface.ID),
+				Label:      iface.Label,
+				Slot:       iface.Slot,
+				Type:       iface.Type,
+				MACAddress: iface.MACAddress,
+			})
+		}
+		topo.Nodes = append(topo.Nodes, nt)
+	}
+	sort.Slice(topo.Nodes, func(i, j int) bool {
+		return topo.Nodes[i].Label < topo.Nodes[j].Label
+	})
+
+	// the node of a link endpoint isn't always part of the link data
+	ifaceNodes := make(map[UUID]UUID)
+	for _, node := range l.Nodes {
+		for _, iface := range node.Interfaces {
+			ifaceNodes[iface.ID] = node.ID
+		}
+	}
+	nodeOf := func(nodeID, ifaceID UUID) string {
+		if len(nodeID) == 0 {
+			nodeID = ifaceNodes[ifaceID]
+		}
+		return string(nodeID)
+	}
+	for _, link := range l.Links {
+		topo.Links = append(topo.Links, LinkTopology{
+			ID:    string(link.ID),
+			N1:    nodeOf(link.SrcNode, link.SrcID),
+			I1:    string(link.SrcID),
+			N2:    nodeOf(link.DstNode, link.DstID),
+			I2:    string(link.DstID),
+			Label: link.Label,
+		})
+	}
+	return topo
+}
@@ -0,0 +1,225 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const diffOld = `
+lab:
+  title: lab
+  description: ""
+  notes: ""
+nodes:
+  - id: n0
+    label: r1
+    node_definition: iosv
+    x: 0
+    y: 0
+    tags: []
+    configuration: |
+      hostname r1
+      interface Gi0/0
+       no shutdown
+    interfaces:
+      - {id: i0, label: Gi0/0, slot: 0, type: physical}
+      - {id: i1, label: Gi0/1, slot: 1, type: physical}
+  - id: n1
+    label: r2
+    node_definition: iosv
+    x: 100
+    y: 0
+    tags: []
+    interfaces:
+      - {id: i0, label: Gi0/0, slot: 0, type: physical}
+  - id: n2
+    label: r3
+    node_definition: iosv
+    x: 200
+    y: 0
+    tags: []
+    interfaces: []
+links:
+  - {id: l0, n1: n0, i1: i0, n2: n1, i2: i0}
+annotations:
+  - {type: text, border_color: "#000", border_style: "", color: "#fff", thickness: 1, x1: 0, y1: 0, z_index: 0, text_content: old}
+`
+
+const diffNew = `
+lab:
+  title: lab v2
+  description: ""
+  notes: ""
+nodes:
+  - id: n0
+    label: r1
+    node_definition: iosv
+    x: 50
+    y: 0
+    tags: [core]
+    configuration: |
+      hostname r1
+      interface Gi0/0
+       shutdown
+    interfaces:
+      - {id: i0, label: Gi0/0, slot: 0, type: physical}
+  - id: n1
+    label: edge
+    node_definition: iosv
+    x: 100
+    y: 0
+    tags: []
+    interfaces:
+      - {id: i0, label: Gi0/0, slot: 0, type: physical}
+  - id: n3
+    label: r4
+    node_definition: alpine
+    x: 300
+    y: 0
+    tags: []
+    interfaces: []
+links:
+  - {id: l0, n1: n0, i1: i0, n2: n1, i2: i0, conditioning: {latency: 10}}
+annotations:
+  - {type: text, border_color: "#000", border_style: "", color: "#fff", thickness: 1, x1: 0, y1: 0, z_index: 0, text_content: new}
+`
+
+func TestDiffTopologies(t *testing.T) {
+	oldTopo, err := ParseLabTopology([]byte(diffOld))
+	assert.NoError(t, err)
+	newTopo, err := ParseLabTopology([]byte(diffNew))
+	assert.NoError(t, err)
+
+	diff := DiffTopologies(oldTopo, newTopo)
+	assert.False(t, diff.Empty())
+
+	assert.Equal(t, []FieldDiff{{Field: "title", Old: "lab", New: "lab v2"}}, diff.Lab)
+
+	// r2 is renamed to edge, matched by ID
+	assert.Equal(t, []ElementDiff{
+		{Kind: DiffChanged, Name: "edge", OldID: "n1", NewID: "n1", Fields: []FieldDiff{{Field: "label", Old: "r2", New: "edge"}}},
+		{Kind: DiffChanged, Name: "r1", OldID: "n0", NewID: "n0", Fields: []FieldDiff{
+			{Field: "configuration", Old: "hostname r1\ninterface Gi0/0\n no shutdown\n", New: "hostname r1\ninterface Gi0/0\n shutdown\n"},
+			{Field: "tags", Old: nil, New: []any{"core"}},
+			{Field: "x", Old: float64(0), New: float64(50)},
+		}},
+		{Kind: DiffRemoved, Name: "r3", OldID: "n2"},
+		{Kind: DiffAdded, Name: "r4", NewID: "n3"},
+	}, diff.Nodes)
+
+	assert.Equal(t, []ElementDiff{{Kind: DiffRemoved, Name: "r1/Gi0/1", OldID: "i1"}}, diff.Interfaces)
+
+	assert.Equal(t, []ElementDiff{{
+		Kind:   DiffChanged,
+		Name:   `"edge":"Gi0/0" <-> "r1":"Gi0/0"`,
+		OldID:  "l0",
+		NewID:  "l0",
+		Fields: []FieldDiff{{Field: "conditioning.latency", Old: nil, New: float64(10)}},
+	}}, diff.Links)
+
+	assert.Equal(t, []ElementDiff{{
+		Kind:   DiffChanged,
+		Name:   "text at (0, 0)",
+		Fields: []FieldDiff{{Field: "text_content", Old: "old", New: "new"}},
+	}}, diff.Annotations)
+
+	assert.Contains(t, diff.String(), "~ node r1\n    ~ configuration:\n        -  no shutdown\n        +  shutdown\n")
+	assert.Contains(t, diff.String(), "~ link \"edge\":\"Gi0/0\" <-> \"r1\":\"Gi0/0\"\n    ~ conditioning.latency: null -> 10\n")
+
+	same := DiffTopologies(oldTopo, oldTopo)
+	assert.True(t, same.Empty())
+	assert.Equal(t, "No differences.\n", same.String())
+}
+
+func TestDiffTopologies_MatchByLabel(t *testing.T) {
+	// a lab uses controller UUIDs, its source topology uses local IDs
+	topo, err := ParseLabTopology([]byte(diffOld))
+	assert.NoError(t, err)
+	topo.Annotations = nil
+
+	slot0, slot1 := 0, 1
+	lab := Lab{
+		Title: "lab",
+		Nodes: NodeMap{
+			"uuid-1": {ID: "uuid-1", Label: "r1", NodeDefinition: "iosv", Configuration: "hostname r1\ninterface Gi0/0\n no shutdown\n",
+				Interfaces: InterfaceList{
+					{ID: "uuid-i1", Label: "Gi0/0", Slot: &slot0, Type: IfaceTypePhysical},
+					{ID: "uuid-i2", Label: "Gi0/1", Slot: &slot1, Type: IfaceTypePhysical},
+				}},
+			"uuid-2": {ID: "uuid-2", Label: "r2", NodeDefinition: "iosv", X: 100,
+				Interfaces: InterfaceList{{ID: "uuid-i3", Label: "Gi0/0", Slot: &slot0, Type: IfaceTypePhysical}}},
+			"uuid-3": {ID: "uuid-3", Label: "r3", NodeDefinition: "iosv", X: 200},
+		},
+		Links: LinkList{{ID: "uuid-l1", SrcID: "uuid-i3", DstID: "uuid-i1"}},
+	}
+
+	labTopo := lab.Topology()
+	diff := DiffTopologies(topo, &labTopo)
+	assert.True(t, diff.Empty(), diff.String())
+
+	other := lab
+	other.Nodes = NodeMap{"uuid-1": lab.Nodes["uuid-1"], "uuid-2": lab.Nodes["uuid-2"]}
+	diff = DiffLabs(&lab, &other)
+	assert.Equal(t, []ElementDiff{{Kind: DiffRemoved, Name: "r3", OldID: "uuid-3"}}, diff.Nodes)
+}
+
+func TestDiffTopologies_ShiftedIDs(t *testing.T) {
+	oldTopo, err := ParseLabTopology([]byte(`
+lab: {title: lab}
+nodes:
+  - {id: n0, label: r1, node_definition: iosv, x: 0, y: 0, interfaces: [{id: i0, label: Gi0/0, slot: 0, type: physical}]}
+  - {id: n1, label: r2, node_definition: iosv, x: 100, y: 0, interfaces: [{id: i0, label: Gi0/0, slot: 0, type: physical}, {id: i1, label: Gi0/1, slot: 1, type: physical}]}
+  - {id: n2, label: r3, node_definition: alpine, x: 200, y: 0, interfaces: [{id: i0, label: eth0, slot: 0, type: physical}]}
+links:
+  - {id: l0, n1: n0, i1: i0, n2: n1, i2: i0}
+  - {id: l1, n1: n1, i1: i1, n2: n2, i2: i0}
+`))
+	assert.NoError(t, err)
+
+	// r1 was deleted, the controller exports the remaining elements with
+	// reassigned IDs
+	newTopo, err := ParseLabTopology([]byte(`
+lab: {title: lab}
+nodes:
+  - {id: n0, label: r2, node_definition: iosv, x: 100, y: 0, interfaces: [{id: i0, label: Gi0/0, slot: 0, type: physical}, {id: i1, label: Gi0/1, slot: 1, type: physical}]}
+  - {id: n1, label: r3, node_definition: alpine, x: 200, y: 0, interfaces: [{id: i0, label: eth0, slot: 0, type: physical}]}
+links:
+  - {id: l0, n1: n0, i1: i1, n2: n1, i2: i0}
+`))
+	assert.NoError(t, err)
+
+	diff := DiffTopologies(oldTopo, newTopo)
+	assert.Equal(t, []ElementDiff{{Kind: DiffRemoved, Name: "r1", OldID: "n0"}}, diff.Nodes)
+	assert.Equal(t, []ElementDiff{{Kind: DiffRemoved, Name: "r1/Gi0/0", OldID: "i0"}}, diff.Interfaces)
+	assert.Equal(t, []ElementDiff{{Kind: DiffRemoved, Name: `"r1":"Gi0/0" <-> "r2":"Gi0/0"`, OldID: "l0"}}, diff.Links)
+}
+
+func TestLineDiff(t *testing.T) {
+	assert.Equal(t, []string{"- b", "+ c", "+ d"}, lineDiff("a\nb\n", "a\nc\nd"))
+	assert.Equal(t, []string{"+ a"}, lineDiff("", "a"))
+	assert.Equal(t, []string{"- a"}, lineDiff("a", ""))
+	assert.Empty(t, lineDiff("a\nb", "a\nb\n"))
+
+	// large texts: the common prefix and suffix are skipped, a change in the
+	// middle is found without a table for all lines
+	lines := make([]string, 5000)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i)
+	}
+	changed := slices.Clone(lines)
+	changed[2500] = "changed"
+	assert.Equal(t, []string{"- line 2500", "+ changed"},
+		lineDiff(strings.Join(lines, "\n"), strings.Join(changed, "\n")))
+
+	// too many differing lines are shown as replaced
+	reversed := slices.Clone(lines)
+	slices.Reverse(reversed)
+	diff := lineDiff(strings.Join(lines, "\n"), strings.Join(reversed, "\n"))
+	assert.Len(t, diff, 10000)
+	assert.Equal(t, "- line 0", diff[0])
+	assert.Equal(t, "+ line 4999", diff[5000])
+}
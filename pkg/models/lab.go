@@ -134,6 +134,15 @@ func (li *LabImport) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// LabImportOptions controls how a lab is imported.
+type LabImportOptions struct {
+	// Title, if set, overrides the title of the topology document.
+	Title string
+	// Shallow fetches only the lab itself after the import, without nodes,
+	// links and interfaces.
+	Shallow bool
+}
+
 // LabCreateRequest represents the data required to create a new lab.
 // Only certain fields from the full Lab model are accepted during creation.
 // This ensures API safety by preventing misuse of unsupported fields.
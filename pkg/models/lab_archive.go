@@ -0,0 +1,439 @@
+// Package models provides the models for Cisco Modeling Labs
+// here: lab archive related types
+package models
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LabArchiveVersion is the version of the archive layout written by this
+// package. Archives with a newer version can't be read.
+const LabArchiveVersion = 1
+
+// labArchiveSpoolSize is the size above which files read from an archive are
+// spooled to temporary files, packet captures and console logs are always
+// spooled.
+const labArchiveSpoolSize = 1 << 20
+
+const (
+	// LabArchiveManifestFile is the name of the manifest in the archive, it
+	// is always the first file.
+	LabArchiveManifestFile = "manifest.json"
+	// LabArchiveTopologyFile is the name of the topology in the archive.
+	LabArchiveTopologyFile = "topology.yaml"
+)
+
+// LabArchiveKind is the kind of content of a file in a lab archive.
+type LabArchiveKind string
+
+// Kinds of files in a lab archive.
+const (
+	LabArchiveTopology         LabArchiveKind = "topology"
+	LabArchiveConfiguration    LabArchiveKind = "configuration"
+	LabArchiveConsoleLog       LabArchiveKind = "console_log"
+	LabArchiveLinkCondition    LabArchiveKind = "link_condition"
+	LabArchiveAnnotations      LabArchiveKind = "annotations"
+	LabArchiveSmartAnnotations LabArchiveKind = "smart_annotations"
+	LabArchivePCAP             LabArchiveKind = "pcap"
+)
+
+// LabArchiveOptions control which parts of a lab are archived. The
+// topology, node configurations, link conditions and annotations are always
+// included.
+type LabArchiveOptions struct {
+	// ExtractConfigurations extracts the running configurations of all
+	// booted nodes before the topology is exported.
+	ExtractConfigurations bool
+	// ExcludeConsoleLogs omits the console logs of the nodes.
+	ExcludeConsoleLogs bool
+	// ExcludePCAPs omits the packet captures of the links.
+	ExcludePCAPs bool
+}
+
+// LabArchiveEntry describes a file in a lab archive.
+type LabArchiveEntry struct {
+	Name string         `json:"name"`
+	Kind LabArchiveKind `json:"kind"`
+	// NodeID or LinkID and Label identify the element the file belongs to,
+	// if any.
+	NodeID UUID   `json:"node_id,omitempty"`
+	LinkID UUID   `json:"link_id,omitempty"`
+	Label  string `json:"label,omitempty"`
+	// Device is the serial device of a console log.
+	Device int `json:"device,omitempty"`
+	Size   int `json:"size"`
+}
+
+// LabArchiveManifest describes the content of a lab archive.
+type LabArchiveManifest struct {
+	Version int               `json:"version"`
+	Created time.Time         `json:"created"`
+	LabID   UUID              `json:"lab_id"`
+	Title   string            `json:"title"`
+	Entries []LabArchiveEntry `json:"entries"`
+	// Warnings lists the parts which could not be archived, e.g. the
+	// console log of a node which was never started.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// LabArchive is a lab packed into a single gzip compressed tar file: a
+// manifest, the topology and the operational evidence of the lab. The
+// topology can be imported to recreate the lab. Large files, like packet
+// captures, are spooled to temporary files which are removed by Close, both
+// when writing and when reading an archive.
+type LabArchive struct {
+	Manifest LabArchiveManifest
+	files    map[string][]byte
+	spooled  map[string]string // file name -> temporary file
+}
+
+// NewLabArchive returns an empty archive for the lab.
+func NewLabArchive(labID UUID, title string) *LabArchive {
+	return &LabArchive{
+		Manifest: LabArchiveManifest{
+			Version: LabArchiveVersion,
+			Created: time.Now().UTC().Truncate(time.Second),
+			LabID:   labID,
+			Title:   title,
+		},
+		files:   make(map[string][]byte),
+		spooled: make(map[string]string),
+	}
+}
+
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// ArchiveFileName turns a label into a file name which is safe to use in an
+// archive, e.g. "core/r1 (a)" becomes "core_r1_a_".
+func ArchiveFileName(label string) string {
+	name := strings.Trim(unsafeNameChars.ReplaceAllString(label, "_"), ".")
+	if len(name) == 0 {
+		return "_"
+	}
+	return name
+}
+
+// Add adds a file to the archive. If a file with the name of the entry
+// exists already, a number is added to the name. The entry as added is
+// returned.
+func (a *LabArchive) Add(entry LabArchiveEntry, data []byte) LabArchiveEntry {
+	entry.Name = a.uniqueName(entry.Name)
+	entry.Size = len(data)
+	a.files[entry.Name] = data
+	a.Manifest.Entries = append(a.Manifest.Entries, entry)
+	return entry
+}
+
+// AddFrom adds a file to the archive like Add, the content is written by
+// `write`. It is streamed to a temporary file instead of being held in
+// memory. If `write` fails, nothing is added and its error is returned.
+func (a *LabArchive) AddFrom(entry LabArchiveEntry, write func(w io.Writer) error) (LabArchiveEntry, error) {
+	tmp, size, err := spool(write)
+	if err != nil {
+		return entry, err
+	}
+
+	entry.Name = a.uniqueName(entry.Name)
+	entry.Size = int(size)
+	a.spooled[entry.Name] = tmp
+	a.Manifest.Entries = append(a.Manifest.Entries, entry)
+	return entry, nil
+}
+
+// spool writes the content written by `write` to a temporary file and
+// returns its name and size. The file is removed if `write` fails.
+func spool(write func(w io.Writer) error) (string, int64, error) {
+	f, err := os.CreateTemp("", "lab-archive-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("lab archive: %w", err)
+	}
+	cw := &countingWriter{w: f}
+	err = write(cw)
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("lab archive: %w", closeErr)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", 0, err
+	}
+	return f.Name(), cw.n, nil
+}
+
+// Close removes the temporary files of the archive, the files added via
+// AddFrom or spooled by ReadLabArchive are gone afterwards.
+func (a *LabArchive) Close() error {
+	var errs []error
+	for name, tmp := range a.spooled {
+		if err := os.Remove(tmp); err != nil && !stderrors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("lab archive: %w", err))
+		}
+		delete(a.spooled, name)
+	}
+	return stderrors.Join(errs...)
+}
+
+func (a *LabArchive) uniqueName(name string) string {
+	ext := path.Ext(name)
+	unique := name
+	for i := 2; ; i++ {
+		_, inMemory := a.files[unique]
+		_, spooled := a.spooled[unique]
+		if !inMemory && !spooled && unique != LabArchiveManifestFile {
+			return unique
+		}
+		unique = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(name, ext), i, ext)
+	}
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Warn records a part which could not be archived.
+func (a *LabArchive) Warn(format string, args ...any) {
+	a.Manifest.Warnings = append(a.Manifest.Warnings, fmt.Sprintf(format, args...))
+}
+
+// File returns the content of the file `name`. Spooled files are read from
+// their temporary file, use Open to stream them instead.
+func (a *LabArchive) File(name string) ([]byte, bool) {
+	if tmp, found := a.spooled[name]; found {
+		data, err := os.ReadFile(tmp)
+		return data, err == nil
+	}
+	data, found := a.files[name]
+	return data, found
+}
+
+// Open returns a reader for the content of the file `name`, spooled files
+// are streamed from their temporary file. The reader must be closed.
+func (a *LabArchive) Open(name string) (io.ReadCloser, error) {
+	if tmp, found := a.spooled[name]; found {
+		f, err := os.Open(tmp)
+		if err != nil {
+			return nil, fmt.Errorf("lab archive: %s: %w", name, err)
+		}
+		return f, nil
+	}
+	data, found := a.files[name]
+	if !found {
+		return nil, fmt.Errorf("lab archive: %s: %w", name, fs.ErrNotExist)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Entries returns the entries of the given kind in archive order.
+func (a *LabArchive) Entries(kind LabArchiveKind) []LabArchiveEntry {
+	var entries []LabArchiveEntry
+	for _, entry := range a.Manifest.Entries {
+		if entry.Kind == kind {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Topology returns the topology YAML of the archive, as accepted by the lab
+// import.
+func (a *LabArchive) Topology() (string, error) {
+	data, found := a.files[LabArchiveTopologyFile]
+	if !found {
+		return "", fmt.Errorf("lab archive: no %s", LabArchiveTopologyFile)
+	}
+	return string(data), nil
+}
+
+// Write writes the archive as gzip compressed tar to `w`, the manifest
+// first followed by the files in the order they were added.
+func (a *LabArchive) Write(w io.Writer) error {
+	manifest, err := json.MarshalIndent(a.Manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("lab archive: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	write := func(name string, size int64, r io.Reader) error {
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    0o644,
+			Size:    size,
+			ModTime: a.Manifest.Created,
+			Format:  tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := io.Copy(tw, r)
+		return err
+	}
+	writeSpooled := func(entry LabArchiveEntry, tmp string) error {
+		f, err := os.Open(tmp)
+		if err != nil {
+			return err
+		}
+		defer f.Close() //nolint:errcheck
+		return write(entry.Name, int64(entry.Size), f)
+	}
+
+	if err := write(LabArchiveManifestFile, int64(len(manifest)), bytes.NewReader(manifest)); err != nil {
+		return fmt.Errorf("lab archive: %w", err)
+	}
+	for _, entry := range a.Manifest.Entries {
+		var err error
+		if tmp, found := a.spooled[entry.Name]; found {
+			err = writeSpooled(entry, tmp)
+		} else {
+			data := a.files[entry.Name]
+			err = write(entry.Name, int64(len(data)), bytes.NewReader(data))
+		}
+		if err != nil {
+			return fmt.Errorf("lab archive: %s: %w", entry.Name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("lab archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("lab archive: %w", err)
+	}
+	return nil
+}
+
+// ReadLabArchive reads an archive written by LabArchive.Write. All files
+// listed in the manifest must be present, files which are not listed are
+// ignored. Packet captures, console logs and other large files are spooled
+// to temporary files, Close the archive to remove them.
+func ReadLabArchive(r io.Reader) (_ *LabArchive, err error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("lab archive: %w", err)
+	}
+	defer gz.Close() //nolint:errcheck
+
+	archive := &LabArchive{files: make(map[string][]byte), spooled: make(map[string]string)}
+	defer func() {
+		if err != nil {
+			_ = archive.Close()
+		}
+	}()
+
+	// the manifest is the first file, the kinds of the files after it are
+	// known
+	var (
+		manifest bool
+		kinds    map[string]LabArchiveKind
+	)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("lab archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if hdr.Name == LabArchiveManifestFile {
+			if err := archive.readManifest(tr); err != nil {
+				return nil, err
+			}
+			manifest = true
+			kinds = make(map[string]LabArchiveKind, len(archive.Manifest.Entries))
+			for _, entry := range archive.Manifest.Entries {
+				kinds[entry.Name] = entry.Kind
+			}
+			continue
+		}
+		kind, listed := kinds[hdr.Name]
+		if manifest && !listed {
+			continue
+		}
+
+		if kind == LabArchivePCAP || kind == LabArchiveConsoleLog || hdr.Size > labArchiveSpoolSize {
+			tmp, _, err := spool(func(w io.Writer) error {
+				_, err := io.Copy(w, tr)
+				return err
+			})
+			if err != nil {
+				return nil, fmt.Errorf("lab archive: %s: %w", hdr.Name, err)
+			}
+			archive.spooled[hdr.Name] = tmp
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("lab archive: %s: %w", hdr.Name, err)
+		}
+		archive.files[hdr.Name] = data
+	}
+
+	if !manifest {
+		return nil, fmt.Errorf("lab archive: no %s", LabArchiveManifestFile)
+	}
+
+	var missing []string
+	listed := make(map[string]bool, len(archive.Manifest.Entries))
+	for _, entry := range archive.Manifest.Entries {
+		listed[entry.Name] = true
+		_, inMemory := archive.files[entry.Name]
+		_, spooled := archive.spooled[entry.Name]
+		if !inMemory && !spooled {
+			missing = append(missing, entry.Name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("lab archive: missing files: %s", strings.Join(missing, ", "))
+	}
+	// files before the manifest which are not listed in it
+	for name := range archive.files {
+		if !listed[name] {
+			delete(archive.files, name)
+		}
+	}
+	for name, tmp := range archive.spooled {
+		if !listed[name] {
+			_ = os.Remove(tmp)
+			delete(archive.spooled, name)
+		}
+	}
+	return archive, nil
+}
+
+// readManifest reads and checks the manifest of the archive.
+func (a *LabArchive) readManifest(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("lab archive: %s: %w", LabArchiveManifestFile, err)
+	}
+	if err := json.Unmarshal(data, &a.Manifest); err != nil {
+		return fmt.Errorf("lab archive: %s: %w", LabArchiveManifestFile, err)
+	}
+	if a.Manifest.Version > LabArchiveVersion {
+		return fmt.Errorf("lab archive: unsupported version %d", a.Manifest.Version)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveFileName(t *testing.T) {
+	assert.Equal(t, "core_r1_a_", ArchiveFileName("core/r1 (a)"))
+	assert.Equal(t, "r1-e0", ArchiveFileName("r1-e0"))
+	assert.Equal(t, "_", ArchiveFileName(".."))
+	assert.Equal(t, "_", ArchiveFileName(""))
+}
+
+func TestLabArchive_RoundTrip(t *testing.T) {
+	archive := NewLabArchive("lab1", "Lab")
+	archive.Add(LabArchiveEntry{Name: LabArchiveTopologyFile, Kind: LabArchiveTopology}, []byte("lab:\n  title: Lab\n"))
+	entry := archive.Add(LabArchiveEntry{Name: "configs/r1.cfg", Kind: LabArchiveConfiguration, Label: "r1"}, []byte("hostname r1"))
+	assert.Equal(t, "configs/r1.cfg", entry.Name)
+	entry = archive.Add(LabArchiveEntry{Name: "configs/r1.cfg", Kind: LabArchiveConfiguration, Label: "r1"}, []byte("hostname other"))
+	assert.Equal(t, "configs/r1-2.cfg", entry.Name)
+	assert.Equal(t, 14, entry.Size)
+	archive.Warn("console log %d of node %s: %s", 0, "r1", "not found")
+
+	var buf bytes.Buffer
+	assert.NoError(t, archive.Write(&buf))
+
+	read, err := ReadLabArchive(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, archive.Manifest, read.Manifest)
+	assert.Equal(t, []string{"console log 0 of node r1: not found"}, read.Manifest.Warnings)
+	topology, err := read.Topology()
+	assert.NoError(t, err)
+	assert.Equal(t, "lab:\n  title: Lab\n", topology)
+	data, found := read.File("configs/r1-2.cfg")
+	assert.True(t, found)
+	assert.Equal(t, "hostname other", string(data))
+	assert.Len(t, read.Entries(LabArchiveConfiguration), 2)
+}
+
+func TestLabArchive_AddFrom(t *testing.T) {
+	archive := NewLabArchive("lab1", "Lab")
+	archive.Add(LabArchiveEntry{Name: LabArchiveTopologyFile, Kind: LabArchiveTopology}, []byte("lab: {}\n"))
+	entry, err := archive.AddFrom(LabArchiveEntry{Name: "pcaps/l1.pcap", Kind: LabArchivePCAP}, func(w io.Writer) error {
+		for range 3 {
+			if _, err := io.WriteString(w, "packet"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 18, entry.Size)
+	tmp := archive.spooled["pcaps/l1.pcap"]
+	assert.FileExists(t, tmp)
+
+	// a failed write adds nothing
+	_, err = archive.AddFrom(LabArchiveEntry{Name: "pcaps/l2.pcap", Kind: LabArchivePCAP}, func(w io.Writer) error {
+		_, _ = io.WriteString(w, "partial")
+		return errors.New("boom")
+	})
+	assert.EqualError(t, err, "boom")
+	assert.Len(t, archive.Manifest.Entries, 2)
+
+	data, found := archive.File("pcaps/l1.pcap")
+	assert.True(t, found)
+	assert.Equal(t, "packetpacketpacket", string(data))
+
+	var buf bytes.Buffer
+	assert.NoError(t, archive.Write(&buf))
+	assert.NoError(t, archive.Close())
+	assert.NoFileExists(t, tmp)
+
+	read, err := ReadLabArchive(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	data, found = read.File("pcaps/l1.pcap")
+	assert.True(t, found)
+	assert.Equal(t, "packetpacketpacket", string(data))
+
+	// packet captures are spooled on read as well
+	tmp = read.spooled["pcaps/l1.pcap"]
+	assert.FileExists(t, tmp)
+	rc, err := read.Open("pcaps/l1.pcap")
+	if assert.NoError(t, err) {
+		data, _ = io.ReadAll(rc)
+		assert.Equal(t, "packetpacketpacket", string(data))
+		assert.NoError(t, rc.Close())
+	}
+	rc, err = read.Open(LabArchiveTopologyFile)
+	if assert.NoError(t, err) {
+		data, _ = io.ReadAll(rc)
+		assert.Equal(t, "lab: {}\n", string(data))
+		assert.NoError(t, rc.Close())
+	}
+	_, err = read.Open("pcaps/l9.pcap")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.NoError(t, read.Close())
+	assert.NoFileExists(t, tmp)
+}
+
+func writeTestArchive(t *testing.T, files map[string]string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+	return &buf
+}
+
+func TestReadLabArchive_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		err   string
+	}{
+		{"no manifest", map[string]string{"topology.yaml": "lab: {}"}, "no manifest.json"},
+		{"bad manifest", map[string]string{"manifest.json": "{"}, "manifest.json"},
+		{"newer version", map[string]string{"manifest.json": `{"version": 99}`}, "unsupported version 99"},
+		{
+			"missing file",
+			map[string]string{"manifest.json": `{"version": 1, "entries": [{"name": "topology.yaml"}, {"name": "pcaps/l1.pcap"}]}`},
+			"missing files: pcaps/l1.pcap, topology.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadLabArchive(writeTestArchive(t, tt.files))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.err)
+			}
+		})
+	}
+
+	_, err := ReadLabArchive(bytes.NewReader([]byte("plain text")))
+	assert.Error(t, err)
+
+	// files not in the manifest are ignored, a topology is required for the
+	// import
+	archive, err := ReadLabArchive(writeTestArchive(t, map[string]string{
+		"manifest.json": `{"version": 1, "entries": []}`,
+		"extra.txt":     "ignored",
+	}))
+	assert.NoError(t, err)
+	_, found := archive.File("extra.txt")
+	assert.False(t, found)
+	_, err = archive.Topology()
+	assert.Error(t, err)
+}
@@ -0,0 +1,30 @@
+// Package models provides the models for Cisco Modeling Labs
+// here: lab clone related types
+package models
+
+// LabCloneOptions controls how a lab is cloned.
+type LabCloneOptions struct {
+	// Title is the title of the copy. If empty, the title of the source lab
+	// is used.
+	Title string
+	// Owner, if set, makes the given user the owner of the copy.
+	Owner UUID
+	// ExcludeConfigurations omits the node configurations.
+	ExcludeConfigurations bool
+	// CopyAnnotations copies the classic annotations.
+	CopyAnnotations bool
+	// CopySmartAnnotations copies the smart annotation settings.
+	CopySmartAnnotations bool
+	// CopyLinkConditions copies the link conditioning of each link.
+	CopyLinkConditions bool
+}
+
+// LabClone is the result of a lab clone. The maps translate the IDs of the
+// source lab into the IDs of the copy.
+type LabClone struct {
+	Lab        Lab
+	Warnings   []string
+	Nodes      map[UUID]UUID
+	Interfaces map[UUID]UUID
+	Links      map[UUID]UUID
+}
@@ -0,0 +1,64 @@
+// Package models provides the models for Cisco Modeling Labs
+// here: lab teardown related types
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LabDestroyStep is a step of a lab teardown.
+type LabDestroyStep string
+
+const (
+	// LabDestroyStepFetch reads the lab and its nodes.
+	LabDestroyStepFetch LabDestroyStep = "fetch"
+	// LabDestroyStepStop stops the lab.
+	LabDestroyStepStop LabDestroyStep = "stop"
+	// LabDestroyStepWaitStopped waits until no node is running.
+	LabDestroyStepWaitStopped LabDestroyStep = "wait for stopped"
+	// LabDestroyStepWipe wipes the lab.
+	LabDestroyStepWipe LabDestroyStep = "wipe"
+	// LabDestroyStepWaitWiped waits until all nodes are wiped.
+	LabDestroyStepWaitWiped LabDestroyStep = "wait for wiped"
+	// LabDestroyStepDelete deletes the lab.
+	LabDestroyStepDelete LabDestroyStep = "delete"
+)
+
+// LabDestroyOptions controls a lab teardown.
+type LabDestroyOptions struct {
+	// Wait controls the polling while waiting for the nodes to stop and to
+	// be wiped. The timeout applies to each of the waits.
+	Wait WaitOptions
+}
+
+// LabDestroyError is returned when a lab teardown fails. Step is the failed
+// step, Stuck holds the nodes which did not reach the expected state when a
+// wait step failed.
+type LabDestroyError struct {
+	LabID UUID
+	Step  LabDestroyStep
+	Stuck NodeMap
+	Err   error
+}
+
+func (e *LabDestroyError) Error() string {
+	msg := fmt.Sprintf("destroy lab %s: %s failed", e.LabID, e.Step)
+	if len(e.Stuck) > 0 {
+		stuck := make([]string, 0, len(e.Stuck))
+		for _, node := range e.Stuck {
+			stuck = append(stuck, fmt.Sprintf("%s (%s)", node.Label, node.State))
+		}
+		sort.Strings(stuck)
+		msg += ", stuck nodes: " + strings.Join(stuck, ", ")
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *LabDestroyError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,60 @@
+// Package models provides the models for Cisco Modeling Labs
+// here: client-side staged lab start related types
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LabStartOptions controls a client-side staged lab start. Nodes are
+// grouped into stages, either by priority (highest first, the default) or
+// by tag, and one stage after the other is started and waited for.
+type LabStartOptions struct {
+	// Tags, if set, defines the stages by tag in the given order, e.g.
+	// `[]string{"route-reflector", "core"}`. A node with several of the
+	// tags belongs to the stage of the first one. If Tags is empty, nodes
+	// are grouped by Priority.
+	Tags []string
+	// Staging controls the handling of nodes without priority (or without
+	// any of the tags) and of stages which fail to boot, same as with the
+	// controller side staging: StartRemaining starts these nodes in a last
+	// stage, AbortOnFailure stops at the first stage which fails. Enabled
+	// is ignored. If nil, the staging settings of the lab are used, and if
+	// the lab has none, remaining nodes are started and failures don't
+	// abort.
+	Staging *NodeStaging
+	// Wait controls the polling while waiting for a stage to boot. The
+	// timeout applies to each stage.
+	Wait WaitOptions
+	// Progress, if set, is called after each poll with the name of the
+	// current stage and the state of its nodes.
+	Progress func(stage string, nodes []NodeProgress)
+}
+
+// LabStartStageError is returned for a stage which failed to start or to
+// boot. Nodes holds the nodes of the stage which did not reach BOOTED.
+type LabStartStageError struct {
+	Stage string
+	Nodes []NodeProgress
+	Err   error
+}
+
+func (e *LabStartStageError) Error() string {
+	msg := fmt.Sprintf("stage %s failed", e.Stage)
+	if len(e.Nodes) > 0 {
+		nodes := make([]string, 0, len(e.Nodes))
+		for _, node := range e.Nodes {
+			nodes = append(nodes, fmt.Sprintf("%s (%s)", node.Label, node.State))
+		}
+		msg += ", nodes not booted: " + strings.Join(nodes, ", ")
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *LabStartStageError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,59 @@
+// Package models provides the models for Cisco Modeling Labs
+// here: lab convergence related types
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NodeProgress is the boot state of a node while waiting for a lab to
+// converge.
+type NodeProgress struct {
+	ID           UUID
+	Label        string
+	State        NodeState
+	BootProgress BootProgress
+}
+
+// LabWaitOptions controls waiting for a lab to converge.
+type LabWaitOptions struct {
+	// Wait controls the polling interval, backoff and timeout.
+	Wait WaitOptions
+	// Progress, if set, is called after each poll with the state of all
+	// nodes of the lab, sorted by label.
+	Progress func(nodes []NodeProgress)
+	// ConsoleLogLines is the number of console log lines included in the
+	// error for each node that did not boot. Zero omits the logs, which are
+	// also omitted when the lab service has no console service.
+	ConsoleLogLines int
+}
+
+// LabConvergeError is returned when a lab did not converge. Nodes holds the
+// nodes which did not reach BOOTED, sorted by label. ConsoleLogs holds the
+// tail of the console log of these nodes by node ID, if requested.
+type LabConvergeError struct {
+	LabID       UUID
+	Nodes       []NodeProgress
+	ConsoleLogs map[UUID]string
+	Err         error
+}
+
+func (e *LabConvergeError) Error() string {
+	nodes := make([]string, 0, len(e.Nodes))
+	for _, node := range e.Nodes {
+		nodes = append(nodes, fmt.Sprintf("%s (%s)", node.Label, node.State))
+	}
+	msg := fmt.Sprintf("lab %s did not converge", e.LabID)
+	if len(nodes) > 0 {
+		msg += ", nodes not booted: " + strings.Join(nodes, ", ")
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *LabConvergeError) Unwrap() error {
+	return e.Err
+}
@@ -24,6 +24,12 @@ type Link struct {
 	DstNode UUID   `json:"node_b"`
 	SrcSlot int    `json:"slot_a"`
 	DstSlot int    `json:"slot_b"`
+
+	// SrcInterface and DstInterface name the interfaces of the nodes by
+	// label (e.g. "GigabitEthernet0/2") when creating a link, instead of
+	// the slots.
+	SrcInterface string `json:"-"`
+	DstInterface string `json:"-"`
 }
 
 // LinkList is a slice of Links.
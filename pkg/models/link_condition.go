@@ -4,21 +4,21 @@ package models
 
 // LinkConditionConfiguration defines the configurable parameters for link conditioning
 type LinkConditionConfiguration struct {
-	Bandwidth     int     `json:"bandwidth,omitempty"`      // Bandwidth in kbps (0-10000000)
-	Latency       int     `json:"latency,omitempty"`        // Delay in ms (0-10000)
-	DelayCorr     float64 `json:"delay_corr,omitempty"`     // Delay correlation in percent (0-100)
-	Limit         int     `json:"limit,omitempty"`          // Limit in ms (0-10000)
-	Loss          float64 `json:"loss,omitempty"`           // Loss in percent (0-100)
-	LossCorr      float64 `json:"loss_corr,omitempty"`      // Loss correlation in percent (0-100)
-	Gap           int     `json:"gap,omitempty"`            // Gap between packets in ms (0-10000)
-	Duplicate     float64 `json:"duplicate,omitempty"`      // Duplicate probability in percent (0-100)
-	DuplicateCorr float64 `json:"duplicate_corr,omitempty"` // Duplicate correlation in percent (0-100)
-	Jitter        int     `json:"jitter,omitempty"`         // Jitter in ms (0-10000)
-	ReorderProb   float64 `json:"reorder_prob,omitempty"`   // Reorder probability in percent (0-100)
-	ReorderCorr   float64 `json:"reorder_corr,omitempty"`   // Reorder correlation in percent (0-100)
-	CorruptProb   float64 `json:"corrupt_prob,omitempty"`   // Corruption probability in percent (0-100)
-	CorruptCorr   float64 `json:"corrupt_corr,omitempty"`   // Corruption correlation in percent (0-100)
-	Enabled       bool    `json:"enabled,omitempty"`        // Whether conditioning is enabled
+	Bandwidth     int     `json:"bandwidth,omitempty" yaml:"bandwidth,omitempty"`           // Bandwidth in kbps (0-10000000)
+	Latency       int     `json:"latency,omitempty" yaml:"latency,omitempty"`               // Delay in ms (0-10000)
+	DelayCorr     float64 `json:"delay_corr,omitempty" yaml:"delay_corr,omitempty"`         // Delay correlation in percent (0-100)
+	Limit         int     `json:"limit,omitempty" yaml:"limit,omitempty"`                   // Limit in ms (0-10000)
+	Loss          float64 `json:"loss,omitempty" yaml:"loss,omitempty"`                     // Loss in percent (0-100)
+	LossCorr      float64 `json:"loss_corr,omitempty" yaml:"loss_corr,omitempty"`           // Loss correlation in percent (0-100)
+	Gap           int     `json:"gap,omitempty" yaml:"gap,omitempty"`                       // Gap between packets in ms (0-10000)
+	Duplicate     float64 `json:"duplicate,omitempty" yaml:"duplicate,omitempty"`           // Duplicate probability in percent (0-100)
+	DuplicateCorr float64 `json:"duplicate_corr,omitempty" yaml:"duplicate_corr,omitempty"` // Duplicate correlation in percent (0-100)
+	Jitter        int     `json:"jitter,omitempty" yaml:"jitter,omitempty"`                 // Jitter in ms (0-10000)
+	ReorderProb   float64 `json:"reorder_prob,omitempty" yaml:"reorder_prob,omitempty"`     // Reorder probability in percent (0-100)
+	ReorderCorr   float64 `json:"reorder_corr,omitempty" yaml:"reorder_corr,omitempty"`     // Reorder correlation in percent (0-100)
+	CorruptProb   float64 `json:"corrupt_prob,omitempty" yaml:"corrupt_prob,omitempty"`     // Corruption probability in percent (0-100)
+	CorruptCorr   float64 `json:"corrupt_corr,omitempty" yaml:"corrupt_corr,omitempty"`     // Corruption correlation in percent (0-100)
+	Enabled       bool    `json:"enabled,omitempty" yaml:"enabled,omitempty"`               // Whether conditioning is enabled
 }
 
 // LinkConditionStricted defines operational link conditioning data (read-only, no Enabled field)
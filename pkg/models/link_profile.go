@@ -0,0 +1,202 @@
+// Package models provides the models for Cisco Modeling Labs
+// here: link conditioning profile related types
+package models
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	cmlerror "github.com/rschmied/gocmlclient/pkg/errors"
+)
+
+// Names of the built-in link conditioning profiles.
+const (
+	LinkProfileSatellite           = "satellite"
+	LinkProfile3G                  = "3g"
+	LinkProfileLTE                 = "lte"
+	LinkProfileLossyWiFi           = "wifi-lossy"
+	LinkProfileIntercontinentalWAN = "wan-intercontinental"
+)
+
+// LinkProfile is a named link condition.
+type LinkProfile struct {
+	Name        string                     `json:"name" yaml:"name"`
+	Description string                     `json:"description,omitempty" yaml:"description,omitempty"`
+	Condition   LinkConditionConfiguration `json:"condition" yaml:"condition"`
+}
+
+// Configuration returns the condition of the profile, enabled, e.g. as
+// input for the link service.
+func (p LinkProfile) Configuration() *LinkConditionConfiguration {
+	cfg := p.Condition
+	cfg.Enabled = true
+	return &cfg
+}
+
+// BuiltinLinkProfiles returns the built-in profiles, sorted by name. The
+// values are typical for the kind of link, not measurements of a specific
+// network.
+func BuiltinLinkProfiles() []LinkProfile {
+	return []LinkProfile{
+		{
+			Name:        LinkProfile3G,
+			Description: "3G mobile network",
+			Condition:   LinkConditionConfiguration{Bandwidth: 2000, Latency: 150, Jitter: 40, Loss: 1.5, LossCorr: 25},
+		},
+		{
+			Name:        LinkProfileLTE,
+			Description: "LTE mobile network",
+			Condition:   LinkConditionConfiguration{Bandwidth: 20000, Latency: 50, Jitter: 15, Loss: 0.5, LossCorr: 25},
+		},
+		{
+			Name:        LinkProfileSatellite,
+			Description: "geostationary satellite link",
+			Condition:   LinkConditionConfiguration{Bandwidth: 10000, Latency: 600, Jitter: 20, Loss: 1},
+		},
+		{
+			Name:        LinkProfileIntercontinentalWAN,
+			Description: "intercontinental WAN circuit",
+			Condition:   LinkConditionConfiguration{Bandwidth: 100000, Latency: 150, Jitter: 5, Loss: 0.1},
+		},
+		{
+			Name:        LinkProfileLossyWiFi,
+			Description: "congested Wi-Fi with interference",
+			Condition: LinkConditionConfiguration{
+				Bandwidth: 30000, Latency: 5, Jitter: 20, Loss: 5, LossCorr: 50, Duplicate: 0.5, ReorderProb: 1,
+			},
+		},
+	}
+}
+
+// LinkProfileRegistry holds link profiles by name, it is safe for
+// concurrent use.
+type LinkProfileRegistry struct {
+	mu       sync.RWMutex
+	profiles map[string]LinkProfile
+}
+
+// NewLinkProfileRegistry returns a registry with the built-in profiles.
+func NewLinkProfileRegistry() *LinkProfileRegistry {
+	r := &LinkProfileRegistry{profiles: make(map[string]LinkProfile)}
+	for _, p := range BuiltinLinkProfiles() {
+		r.profiles[p.Name] = p
+	}
+	return r
+}
+
+// Register adds the profile, replacing a profile with the same name,
+// including the built-in ones.
+func (r *LinkProfileRegistry) Register(p LinkProfile) error {
+	if len(p.Name) == 0 {
+		return cmlerror.Wrap(cmlerror.ErrMissingRequired, "register link profile: name")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.profiles[p.Name] = p
+	return nil
+}
+
+// Get returns the profile with the given name, ErrElementNotFound if there
+// is none.
+func (r *LinkProfileRegistry) Get(name string) (LinkProfile, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, found := r.profiles[name]
+	if !found {
+		return LinkProfile{}, cmlerror.Wrapf(cmlerror.ErrElementNotFound, "link profile %q", name)
+	}
+	return p, nil
+}
+
+// Names returns the names of all profiles, sorted.
+func (r *LinkProfileRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.profiles))
+	for name := range r.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadYAML registers the profiles of a YAML (or JSON) document, a list of
+// profiles:
+//
+//	# profiles.yaml
+//	- name: branch-dsl
+//	  description: branch office DSL line
+//	  condition:
+//	    bandwidth: 16000
+//	    latency: 25
+//
+// Nothing is registered if the document is invalid.
+func (r *LinkProfileRegistry) LoadYAML(data []byte) error {
+	var profiles []LinkProfile
+	if err := yaml.Unmarshal(data, &profiles); err != nil {
+		return fmt.Errorf("parse link profiles: %w", err)
+	}
+	for idx, p := range profiles {
+		if len(p.Name) == 0 {
+			return cmlerror.Wrapf(cmlerror.ErrMissingRequired, "parse link profiles: name of profile %d", idx)
+		}
+	}
+	for _, p := range profiles {
+		_ = r.Register(p)
+	}
+	return nil
+}
+
+// LoadFile registers the profiles of a YAML file, see LoadYAML.
+func (r *LinkProfileRegistry) LoadFile(name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("read link profiles: %w", err)
+	}
+	if err := r.LoadYAML(data); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// LinkSelector selects links of a lab. Empty criteria match all links, a
+// link has to match all given criteria.
+type LinkSelector struct {
+	// Labels are matched exactly or as shell pattern, e.g. "wan-*".
+	Labels []string
+	// Node selects links with at least one end on a node matching the
+	// query.
+	Node *NodeQuery
+	// States are the link states, e.g. LinkStateStarted.
+	States []string
+}
+
+// Matches returns `true` if the link matches the selector. The nodes of the
+// lab are only needed if the selector has a node query.
+func (s LinkSelector) Matches(link *Link, nodes NodeMap) bool {
+	if len(s.Labels) > 0 && !slices.ContainsFunc(s.Labels, func(pattern string) bool {
+		matched, err := path.Match(pattern, link.Label)
+		return pattern == link.Label || (err == nil && matched)
+	}) {
+		return false
+	}
+	if len(s.States) > 0 && !slices.Contains(s.States, link.State) {
+		return false
+	}
+	if s.Node != nil {
+		matches := func(id UUID) bool {
+			node, found := nodes[id]
+			return found && s.Node.Matches(node)
+		}
+		if !matches(link.SrcNode) && !matches(link.DstNode) {
+			return false
+		}
+	}
+	return true
+}
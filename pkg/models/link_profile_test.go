@@ -0,0 +1,111 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cmlerror "github.com/rschmied/gocmlclient/pkg/errors"
+)
+
+func TestLinkProfileRegistry(t *testing.T) {
+	r := NewLinkProfileRegistry()
+	assert.Equal(t, []string{"3g", "lte", "satellite", "wan-intercontinental", "wifi-lossy"}, r.Names())
+
+	sat, err := r.Get(LinkProfileSatellite)
+	assert.NoError(t, err)
+	assert.Equal(t, 600, sat.Condition.Latency)
+	assert.False(t, sat.Condition.Enabled)
+	cfg := sat.Configuration()
+	assert.True(t, cfg.Enabled)
+	assert.Equal(t, 600, cfg.Latency)
+
+	_, err = r.Get("dsl")
+	assert.ErrorIs(t, err, cmlerror.ErrElementNotFound)
+
+	assert.ErrorIs(t, r.Register(LinkProfile{}), cmlerror.ErrMissingRequired)
+	assert.NoError(t, r.Register(LinkProfile{Name: LinkProfileSatellite, Condition: LinkConditionConfiguration{Latency: 40}}))
+	sat, _ = r.Get(LinkProfileSatellite)
+	assert.Equal(t, 40, sat.Condition.Latency, "built-in profiles can be replaced")
+
+	// the built-in profiles are not shared between registries
+	sat, _ = NewLinkProfileRegistry().Get(LinkProfileSatellite)
+	assert.Equal(t, 600, sat.Condition.Latency)
+}
+
+func TestLinkProfileRegistry_Load(t *testing.T) {
+	r := NewLinkProfileRegistry()
+
+	file := filepath.Join(t.TempDir(), "profiles.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(`
+- name: branch-dsl
+  description: branch office DSL line
+  condition:
+    bandwidth: 16000
+    latency: 25
+    loss: 0.2
+- name: lte
+  condition:
+    latency: 35
+`), 0o600))
+	assert.NoError(t, r.LoadFile(file))
+
+	dsl, err := r.Get("branch-dsl")
+	assert.NoError(t, err)
+	assert.Equal(t, "branch office DSL line", dsl.Description)
+	assert.Equal(t, LinkConditionConfiguration{Bandwidth: 16000, Latency: 25, Loss: 0.2}, dsl.Condition)
+	lte, _ := r.Get(LinkProfileLTE)
+	assert.Equal(t, 35, lte.Condition.Latency)
+
+	err = r.LoadYAML([]byte(`[{"name": "ok"}, {"description": "no name"}]`))
+	assert.ErrorIs(t, err, cmlerror.ErrMissingRequired)
+	_, err = r.Get("ok")
+	assert.Error(t, err, "nothing is registered from an invalid document")
+
+	assert.Error(t, r.LoadYAML([]byte(`name: not a list`)))
+	err = r.LoadFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestLinkSelector_Matches(t *testing.T) {
+	nodes := NodeMap{
+		"n1": {ID: "n1", Label: "core-1", Tags: []string{"core"}},
+		"n2": {ID: "n2", Label: "branch-1", Tags: []string{"branch"}},
+		"n3": {ID: "n3", Label: "branch-2", Tags: []string{"branch"}},
+	}
+	links := []Link{
+		{ID: "l1", Label: "wan-1", State: LinkStateStarted, SrcNode: "n1", DstNode: "n2"},
+		{ID: "l2", Label: "wan-2", State: LinkStateStopped, SrcNode: "n3", DstNode: "n1"},
+		{ID: "l3", Label: "lan-1", State: LinkStateStarted, SrcNode: "n2", DstNode: "n3"},
+	}
+
+	tests := []struct {
+		name     string
+		selector LinkSelector
+		expected []UUID
+	}{
+		{"all", LinkSelector{}, []UUID{"l1", "l2", "l3"}},
+		{"label pattern", LinkSelector{Labels: []string{"wan-*"}}, []UUID{"l1", "l2"}},
+		{"state", LinkSelector{States: []string{LinkStateStarted}}, []UUID{"l1", "l3"}},
+		{"node", LinkSelector{Node: &NodeQuery{Tags: []string{"core"}}}, []UUID{"l1", "l2"}},
+		{"node label", LinkSelector{Node: &NodeQuery{Labels: []string{"branch-2"}}}, []UUID{"l2", "l3"}},
+		{
+			"combined",
+			LinkSelector{Labels: []string{"wan-*"}, States: []string{LinkStateStarted}, Node: &NodeQuery{Tags: []string{"branch"}}},
+			[]UUID{"l1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched := []UUID{}
+			for idx := range links {
+				if tt.selector.Matches(&links[idx], nodes) {
+					matched = append(matched, links[idx].ID)
+				}
+			}
+			assert.Equal(t, tt.expected, matched)
+		})
+	}
+}
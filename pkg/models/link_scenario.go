@@ -0,0 +1,121 @@
+// Package models provides the models for Cisco Modeling Labs
+// here: link impairment scenario related types
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	cmlerror "github.com/rschmied/gocmlclient/pkg/errors"
+)
+
+// LinkScenarioAction is what a scenario step does to the links.
+type LinkScenarioAction string
+
+const (
+	// LinkScenarioCondition applies the condition of the step.
+	LinkScenarioCondition LinkScenarioAction = "condition"
+	// LinkScenarioDown stops the links, like pulling the cables.
+	LinkScenarioDown LinkScenarioAction = "down"
+	// LinkScenarioUp starts the links stopped by the scenario again.
+	LinkScenarioUp LinkScenarioAction = "up"
+	// LinkScenarioRestore restores the original conditions and starts the
+	// links stopped by the scenario.
+	LinkScenarioRestore LinkScenarioAction = "restore"
+)
+
+// LinkScenarioStep is a step of a link scenario, applied at the offset `At`
+// from the start of the scenario.
+type LinkScenarioStep struct {
+	At        time.Duration
+	Action    LinkScenarioAction
+	Condition *LinkConditionConfiguration // for LinkScenarioCondition
+}
+
+// String returns a short description of the step.
+func (s LinkScenarioStep) String() string {
+	return fmt.Sprintf("t=%s %s", s.At, s.Action)
+}
+
+// ConditionAt returns a step applying `cfg` at `at`. The condition replaces
+// the condition of the previous step, it is not merged.
+func ConditionAt(at time.Duration, cfg LinkConditionConfiguration) LinkScenarioStep {
+	cfg.Enabled = true
+	return LinkScenarioStep{At: at, Action: LinkScenarioCondition, Condition: &cfg}
+}
+
+// DownAt returns a step stopping the links at `at`.
+func DownAt(at time.Duration) LinkScenarioStep {
+	return LinkScenarioStep{At: at, Action: LinkScenarioDown}
+}
+
+// UpAt returns a step starting the links again at `at`.
+func UpAt(at time.Duration) LinkScenarioStep {
+	return LinkScenarioStep{At: at, Action: LinkScenarioUp}
+}
+
+// RestoreAt returns a step restoring the original state of the links at
+// `at`.
+func RestoreAt(at time.Duration) LinkScenarioStep {
+	return LinkScenarioStep{At: at, Action: LinkScenarioRestore}
+}
+
+// LinkScenario is a timeline of impairments applied to links, e.g.
+//
+//	scenario := models.LinkScenario{Name: "wan-degradation", Steps: []models.LinkScenarioStep{
+//		models.ConditionAt(0, models.LinkConditionConfiguration{Latency: 20}),
+//		models.ConditionAt(30*time.Second, models.LinkConditionConfiguration{Latency: 20, Loss: 5}),
+//		models.DownAt(60 * time.Second),
+//		models.RestoreAt(90 * time.Second),
+//	}}
+//
+// The original conditions and link states are restored at the end of the
+// scenario in any case.
+type LinkScenario struct {
+	Name  string
+	Steps []LinkScenarioStep
+}
+
+// Validate checks the steps of the scenario.
+func (s LinkScenario) Validate() error {
+	if len(s.Steps) == 0 {
+		return cmlerror.Wrapf(cmlerror.ErrMissingRequired, "scenario %q: steps", s.Name)
+	}
+	for idx, step := range s.Steps {
+		switch {
+		case step.At < 0:
+			return cmlerror.Wrapf(cmlerror.ErrInvalidInput, "scenario %q: step %d: negative offset", s.Name, idx)
+		case step.Action == LinkScenarioCondition && step.Condition == nil:
+			return cmlerror.Wrapf(cmlerror.ErrMissingRequired, "scenario %q: step %d: condition", s.Name, idx)
+		case step.Action != LinkScenarioCondition && step.Action != LinkScenarioDown &&
+			step.Action != LinkScenarioUp && step.Action != LinkScenarioRestore:
+			return cmlerror.Wrapf(cmlerror.ErrInvalidInput, "scenario %q: step %d: action %q", s.Name, idx, step.Action)
+		}
+	}
+	return nil
+}
+
+// Timeline returns the steps sorted by their offset, steps with the same
+// offset keep their order.
+func (s LinkScenario) Timeline() []LinkScenarioStep {
+	steps := make([]LinkScenarioStep, len(s.Steps))
+	copy(steps, s.Steps)
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].At < steps[j].At })
+	return steps
+}
+
+// Duration returns the offset of the last step.
+func (s LinkScenario) Duration() time.Duration {
+	var d time.Duration
+	for _, step := range s.Steps {
+		d = max(d, step.At)
+	}
+	return d
+}
+
+// LinkScenarioOptions control a scenario run.
+type LinkScenarioOptions struct {
+	// Progress is called after each step has been applied to all links.
+	Progress func(step LinkScenarioStep)
+}
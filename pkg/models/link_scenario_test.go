@@ -0,0 +1,52 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	cmlerror "github.com/rschmied/gocmlclient/pkg/errors"
+)
+
+func TestLinkScenario(t *testing.T) {
+	scenario := LinkScenario{Name: "wan", Steps: []LinkScenarioStep{
+		RestoreAt(90 * time.Second),
+		ConditionAt(0, LinkConditionConfiguration{Latency: 20}),
+		DownAt(60 * time.Second),
+		ConditionAt(30*time.Second, LinkConditionConfiguration{Loss: 5}),
+		UpAt(60 * time.Second),
+	}}
+	assert.NoError(t, scenario.Validate())
+	assert.Equal(t, 90*time.Second, scenario.Duration())
+
+	timeline := scenario.Timeline()
+	steps := make([]string, len(timeline))
+	for i, step := range timeline {
+		steps[i] = step.String()
+	}
+	assert.Equal(t, []string{"t=0s condition", "t=30s condition", "t=1m0s down", "t=1m0s up", "t=1m30s restore"}, steps)
+	assert.True(t, timeline[0].Condition.Enabled)
+	assert.Equal(t, 20, timeline[0].Condition.Latency)
+	// the scenario itself is not reordered
+	assert.Equal(t, LinkScenarioRestore, scenario.Steps[0].Action)
+}
+
+func TestLinkScenario_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []LinkScenarioStep
+		err   error
+	}{
+		{"no steps", nil, cmlerror.ErrMissingRequired},
+		{"negative offset", []LinkScenarioStep{DownAt(-time.Second)}, cmlerror.ErrInvalidInput},
+		{"missing condition", []LinkScenarioStep{{Action: LinkScenarioCondition}}, cmlerror.ErrMissingRequired},
+		{"unknown action", []LinkScenarioStep{{Action: "explode"}}, cmlerror.ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := LinkScenario{Name: "bad", Steps: tt.steps}.Validate()
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
@@ -5,11 +5,11 @@ package models
 // LinkTopology defines the data structure for a CML link in topology context.
 // This is used for topology import/export operations, not for API operations.
 type LinkTopology struct {
-	ID           string                      `json:"id"`
-	I1           string                      `json:"i1"`
-	I2           string                      `json:"i2"`
-	N1           string                      `json:"n1"`
-	N2           string                      `json:"n2"`
-	Label        string                      `json:"label,omitempty"`
-	Conditioning *LinkConditionConfiguration `json:"conditioning,omitempty"`
+	ID           string                      `json:"id" yaml:"id"`
+	I1           string                      `json:"i1" yaml:"i1"`
+	I2           string                      `json:"i2" yaml:"i2"`
+	N1           string                      `json:"n1" yaml:"n1"`
+	N2           string                      `json:"n2" yaml:"n2"`
+	Label        string                      `json:"label,omitempty" yaml:"label,omitempty"`
+	Conditioning *LinkConditionConfiguration `json:"conditioning,omitempty" yaml:"conditioning,omitempty"`
 }
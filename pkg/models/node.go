@@ -28,8 +28,19 @@ const (
 
 // NodeConfig represents a named configuration for a node.
 type NodeConfig struct {
-	Name    string `json:"name"`
-	Content string `json:"content"`
+	Name    string `json:"name" yaml:"name"`
+	Content string `json:"content" yaml:"content"`
+}
+
+// DefaultExtractParallelism is the default number of concurrent
+// configuration extractions of a lab.
+const DefaultExtractParallelism = 4
+
+// ConfigExtractOptions controls the configuration extraction of a lab.
+type ConfigExtractOptions struct {
+	// Parallelism limits the number of concurrent extractions. Zero uses
+	// DefaultExtractParallelism.
+	Parallelism int
 }
 
 // PyAtsCredentials represents node-level PyATS credentials.
@@ -0,0 +1,83 @@
+// Package models provides the models for Cisco Modeling Labs
+// here: bulk node operation related types
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultBulkParallelism is the default number of concurrent requests of
+// the bulk node operations.
+const DefaultBulkParallelism = 8
+
+// NodeBulkOptions control the bulk node operations.
+type NodeBulkOptions struct {
+	// Parallelism limits the number of concurrent requests, defaults to
+	// DefaultBulkParallelism.
+	Parallelism int
+}
+
+// NodeBulkError is returned by the bulk node operations when the operation
+// failed for some of the nodes. The errors are keyed by node ID; nodes which
+// could not be created have no ID yet, their errors are keyed by the index of
+// the node in the input instead.
+type NodeBulkError struct {
+	Op          string
+	Total       int
+	Errors      map[UUID]error
+	InputErrors map[int]error
+}
+
+// Error implements the error interface, the nodes are listed in ID order,
+// followed by the failed inputs in input order.
+func (e *NodeBulkError) Error() string {
+	var parts []string
+	for _, id := range e.ids() {
+		parts = append(parts, fmt.Sprintf("%s: %s", id, e.Errors[id]))
+	}
+	for _, idx := range e.FailedInputs() {
+		parts = append(parts, fmt.Sprintf("#%d: %s", idx, e.InputErrors[idx]))
+	}
+	return fmt.Sprintf("%s: %d of %d nodes failed: %s", e.Op, len(parts), e.Total, strings.Join(parts, "; "))
+}
+
+// Unwrap returns the errors of the nodes so that errors.Is and errors.As
+// match any of them.
+func (e *NodeBulkError) Unwrap() []error {
+	var errs []error
+	for _, id := range e.ids() {
+		errs = append(errs, e.Errors[id])
+	}
+	for _, idx := range e.FailedInputs() {
+		errs = append(errs, e.InputErrors[idx])
+	}
+	return errs
+}
+
+// Failed returns the IDs of the nodes for which the operation failed,
+// sorted.
+func (e *NodeBulkError) Failed() []UUID {
+	return e.ids()
+}
+
+// FailedInputs returns the indices of the input nodes which could not be
+// created, sorted.
+func (e *NodeBulkError) FailedInputs() []int {
+	indices := make([]int, 0, len(e.InputErrors))
+	for idx := range e.InputErrors {
+		indices = append(indices, idx)
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+func (e *NodeBulkError) ids() []UUID {
+	ids := make([]UUID, 0, len(e.Errors))
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeBulkError(t *testing.T) {
+	errNoImage := errors.New("no image")
+	err := &NodeBulkError{
+		Op:    "start nodes",
+		Total: 3,
+		Errors: map[UUID]error{
+			"n2": errors.New("busy"),
+			"n1": errNoImage,
+		},
+	}
+	assert.Equal(t, "start nodes: 2 of 3 nodes failed: n1: no image; n2: busy", err.Error())
+	assert.Equal(t, []UUID{"n1", "n2"}, err.Failed())
+	assert.ErrorIs(t, err, errNoImage)
+}
+
+func TestNodeBulkError_InputErrors(t *testing.T) {
+	errInvalid := errors.New("invalid")
+	err := &NodeBulkError{
+		Op:    "create nodes",
+		Total: 12,
+		InputErrors: map[int]error{
+			10: errors.New("busy"),
+			2:  errInvalid,
+		},
+	}
+	assert.Equal(t, "create nodes: 2 of 12 nodes failed: #2: invalid; #10: busy", err.Error())
+	assert.Empty(t, err.Failed())
+	assert.Equal(t, []int{2, 10}, err.FailedInputs())
+	assert.ErrorIs(t, err, errInvalid)
+}
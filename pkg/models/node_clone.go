@@ -0,0 +1,21 @@
+// Package models provides the models for Cisco Modeling Labs
+// here: node clone related types
+package models
+
+// NodeCloneOptions controls how a node is cloned.
+type NodeCloneOptions struct {
+	// Label is the label of the copy, required.
+	Label string
+	// X and Y are the position of the copy.
+	X, Y int
+	// CopyLinks re-creates the links of the source node to the same peers.
+	// Free interfaces are used on both ends, new interfaces are created if
+	// there are none.
+	CopyLinks bool
+}
+
+// NodeClone is the result of a node clone.
+type NodeClone struct {
+	Node  Node
+	Links []Link
+}
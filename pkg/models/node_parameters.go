@@ -0,0 +1,164 @@
+// Package models provides the models for Cisco Modeling Labs
+// here: node parameter related types
+package models
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"sort"
+
+	cmlerror "github.com/rschmied/gocmlclient/pkg/errors"
+)
+
+// Well-known node parameters, the SMBIOS fields presented to the VM.
+const (
+	ParamSMBIOSBIOSVendor         = "smbios.bios.vendor"
+	ParamSMBIOSBIOSVersion        = "smbios.bios.version"
+	ParamSMBIOSSystemManufacturer = "smbios.system.manufacturer"
+	ParamSMBIOSSystemProduct      = "smbios.system.product"
+	ParamSMBIOSSystemVersion      = "smbios.system.version"
+	ParamSMBIOSSystemSerial       = "smbios.system.serial"
+	ParamSMBIOSSystemUUID         = "smbios.system.uuid"
+	ParamSMBIOSSystemSKU          = "smbios.system.sku"
+	ParamSMBIOSSystemFamily       = "smbios.system.family"
+)
+
+var smbiosUUIDRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// SMBIOS holds the SMBIOS fields of a node, empty fields are not set.
+type SMBIOS struct {
+	BIOSVendor         string
+	BIOSVersion        string
+	SystemManufacturer string
+	SystemProduct      string
+	SystemVersion      string
+	SystemSerial       string
+	SystemUUID         string
+	SystemSKU          string
+	SystemFamily       string
+}
+
+func (s *SMBIOS) fields() []struct {
+	key   string
+	value *string
+} {
+	return []struct {
+		key   string
+		value *string
+	}{
+		{ParamSMBIOSBIOSVendor, &s.BIOSVendor},
+		{ParamSMBIOSBIOSVersion, &s.BIOSVersion},
+		{ParamSMBIOSSystemManufacturer, &s.SystemManufacturer},
+		{ParamSMBIOSSystemProduct, &s.SystemProduct},
+		{ParamSMBIOSSystemVersion, &s.SystemVersion},
+		{ParamSMBIOSSystemSerial, &s.SystemSerial},
+		{ParamSMBIOSSystemUUID, &s.SystemUUID},
+		{ParamSMBIOSSystemSKU, &s.SystemSKU},
+		{ParamSMBIOSSystemFamily, &s.SystemFamily},
+	}
+}
+
+// String returns the parameter as a string. Numbers and booleans are
+// formatted, false is returned if the parameter is not set.
+func (p NodeParameters) String(key string) (string, bool) {
+	value, found := p[key]
+	if !found || value == nil {
+		return "", false
+	}
+	if s, ok := value.(string); ok {
+		return s, true
+	}
+	return fmt.Sprint(value), true
+}
+
+// SMBIOS returns the SMBIOS fields set in the parameters.
+func (p NodeParameters) SMBIOS() SMBIOS {
+	var s SMBIOS
+	for _, f := range s.fields() {
+		*f.value, _ = p.String(f.key)
+	}
+	return s
+}
+
+// SetSMBIOS replaces the SMBIOS parameters with the fields of `s`, empty
+// fields are removed. The map is allocated if needed.
+func (p *NodeParameters) SetSMBIOS(s SMBIOS) {
+	if *p == nil {
+		*p = NodeParameters{}
+	}
+	for _, f := range s.fields() {
+		if len(*f.value) == 0 {
+			delete(*p, f.key)
+			continue
+		}
+		(*p)[f.key] = *f.value
+	}
+}
+
+// Validate checks the parameters against the parameters declared by the
+// node definition: each parameter must be declared, its value must be a
+// string, number or boolean of the same kind as the declared default, and
+// the SMBIOS UUID must be a UUID. All problems found are returned.
+func (p NodeParameters) Validate(nodeDef NodeDefinition) cmlerror.ValidationErrors {
+	return validateNodeParameters("parameters", p, nodeDef)
+}
+
+func validateNodeParameters(field string, params NodeParameters, nodeDef NodeDefinition) cmlerror.ValidationErrors {
+	var errs cmlerror.ValidationErrors
+	add := func(key string, value any, cause error, format string, args ...any) {
+		errs = append(errs, cmlerror.NewValidationError(field+"."+key, value, fmt.Sprintf(format, args...), cause))
+	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := params[key]
+		def, declared := nodeDef.Sim.Parameters[key]
+		if !declared {
+			add(key, value, cmlerror.ErrInvalidInput, "parameter is not declared by node definition %q", nodeDef.ID)
+			continue
+		}
+		kind := parameterKind(value)
+		if len(kind) == 0 {
+			add(key, value, cmlerror.ErrInvalidInput, "parameter value must be a string, number or boolean")
+			continue
+		}
+		if defKind := parameterKind(def); len(defKind) > 0 && defKind != kind {
+			add(key, value, cmlerror.ErrInvalidInput, "parameter value must be a %s", defKind)
+			continue
+		}
+		if key == ParamSMBIOSSystemUUID && !smbiosUUIDRe.MatchString(fmt.Sprint(value)) {
+			add(key, value, cmlerror.ErrInvalidInput, "parameter value must be a UUID")
+		}
+	}
+	return errs
+}
+
+func parameterKind(value any) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return "number"
+	}
+	return ""
+}
+
+// NodeParameters returns the parameters of the node, nil if none are set.
+// The returned map is a copy, assign it to Parameters after changing it.
+func (n *Node) NodeParameters() NodeParameters {
+	switch params := n.Parameters.(type) {
+	case NodeParameters:
+		return maps.Clone(params)
+	case map[string]any:
+		return maps.Clone(NodeParameters(params))
+	}
+	return nil
+}
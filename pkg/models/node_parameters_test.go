@@ -0,0 +1,90 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cmlerror "github.com/rschmied/gocmlclient/pkg/errors"
+)
+
+func TestNodeParameters_SMBIOS(t *testing.T) {
+	var params NodeParameters
+	params.SetSMBIOS(SMBIOS{SystemProduct: "UCS C220", SystemSerial: "FCH1234"})
+	assert.Equal(t, NodeParameters{
+		ParamSMBIOSSystemProduct: "UCS C220",
+		ParamSMBIOSSystemSerial:  "FCH1234",
+	}, params)
+
+	params["other"] = 42
+	params.SetSMBIOS(SMBIOS{SystemProduct: "UCS C240"})
+	assert.Equal(t, NodeParameters{ParamSMBIOSSystemProduct: "UCS C240", "other": 42}, params)
+	assert.Equal(t, SMBIOS{SystemProduct: "UCS C240"}, params.SMBIOS())
+
+	value, ok := params.String("other")
+	assert.True(t, ok)
+	assert.Equal(t, "42", value)
+	_, ok = params.String("missing")
+	assert.False(t, ok)
+}
+
+func TestNode_NodeParameters(t *testing.T) {
+	node := Node{}
+	assert.Nil(t, node.NodeParameters())
+
+	node.Parameters = map[string]any{ParamSMBIOSBIOSVendor: "Cisco"}
+	params := node.NodeParameters()
+	assert.Equal(t, "Cisco", params.SMBIOS().BIOSVendor)
+
+	// a copy is returned
+	params[ParamSMBIOSBIOSVendor] = "Lenovo"
+	assert.Equal(t, "Cisco", node.NodeParameters().SMBIOS().BIOSVendor)
+
+	node.Parameters = params
+	assert.Equal(t, "Lenovo", node.NodeParameters().SMBIOS().BIOSVendor)
+}
+
+func TestNodeParameters_Validate(t *testing.T) {
+	nodeDef := NodeDefinition{
+		ID: "ubuntu",
+		Sim: simData{Parameters: NodeParameters{
+			ParamSMBIOSBIOSVendor:   "",
+			ParamSMBIOSSystemUUID:   nil,
+			"nested_virtualization": false,
+		}},
+	}
+
+	assert.Empty(t, NodeParameters{
+		ParamSMBIOSBIOSVendor:   "Cisco",
+		ParamSMBIOSSystemUUID:   "4c4c4544-0042-3510-8052-b4c04f4e4e31",
+		"nested_virtualization": true,
+	}.Validate(nodeDef))
+
+	errs := NodeParameters{
+		ParamSMBIOSBIOSVendor:   1,
+		ParamSMBIOSSystemUUID:   "not-a-uuid",
+		ParamSMBIOSSystemSerial: "FCH1234",
+		"nested_virtualization": []string{"yes"},
+	}.Validate(nodeDef)
+	fields := map[string]string{}
+	for _, err := range errs {
+		assert.ErrorIs(t, err, cmlerror.ErrInvalidInput)
+		fields[err.Field] = err.Reason
+	}
+	assert.Equal(t, map[string]string{
+		"parameters.nested_virtualization":      "parameter value must be a string, number or boolean",
+		"parameters." + ParamSMBIOSBIOSVendor:   "parameter value must be a string",
+		"parameters." + ParamSMBIOSSystemSerial: `parameter is not declared by node definition "ubuntu"`,
+		"parameters." + ParamSMBIOSSystemUUID:   "parameter value must be a UUID",
+	}, fields)
+
+	// parameters are part of the topology validation
+	topo := &LabTopology{Nodes: []NodeTopology{{
+		ID: "n0", Label: "srv", NodeDefinition: "ubuntu",
+		Parameters: NodeParameters{"unknown": "x"},
+	}}}
+	verrs := topo.Validate(NodeDefinitionMap{"ubuntu": nodeDef}, nil)
+	if assert.Len(t, verrs, 1) {
+		assert.Equal(t, "nodes[0].parameters.unknown", verrs[0].Field)
+	}
+}
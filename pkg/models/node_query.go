@@ -0,0 +1,112 @@
+// Package models provides the models for Cisco Modeling Labs
+// here: node query related types
+package models
+
+import (
+	"path"
+	"slices"
+	"sort"
+)
+
+// TagMatch defines how the tags of a node query are matched.
+type TagMatch int
+
+const (
+	// TagMatchAny matches nodes which have at least one of the tags.
+	TagMatchAny TagMatch = iota
+	// TagMatchAll matches nodes which have all of the tags.
+	TagMatchAll
+)
+
+// NodeQuery selects nodes of a lab. Empty criteria match all nodes, a node
+// has to match all given criteria. Within a criterion any value matches,
+// except for tags where TagMatch decides.
+type NodeQuery struct {
+	// Labels are matched exactly or as shell pattern, e.g. "spoke-*".
+	Labels          []string
+	Tags            []string
+	TagMatch        TagMatch
+	NodeDefinitions []string
+	States          []NodeState
+}
+
+// Matches returns `true` if the node matches the query.
+func (q NodeQuery) Matches(node *Node) bool {
+	if len(q.Labels) > 0 && !slices.ContainsFunc(q.Labels, func(pattern string) bool {
+		matched, err := path.Match(pattern, node.Label)
+		return pattern == node.Label || (err == nil && matched)
+	}) {
+		return false
+	}
+	if len(q.Tags) > 0 {
+		hasTag := func(tag string) bool { return slices.Contains(node.Tags, tag) }
+		if q.TagMatch == TagMatchAll {
+			if !all(q.Tags, hasTag) {
+				return false
+			}
+		} else if !slices.ContainsFunc(q.Tags, hasTag) {
+			return false
+		}
+	}
+	if len(q.NodeDefinitions) > 0 && !slices.Contains(q.NodeDefinitions, node.NodeDefinition) {
+		return false
+	}
+	if len(q.States) > 0 && !slices.Contains(q.States, node.State) {
+		return false
+	}
+	return true
+}
+
+func all[T any](list []T, f func(T) bool) bool {
+	for _, v := range list {
+		if !f(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// NodeList is a list of nodes, e.g. the result of a node query.
+type NodeList []*Node
+
+// IDs returns the IDs of the nodes in list order, e.g. as input for the
+// bulk node operations.
+func (l NodeList) IDs() []UUID {
+	ids := make([]UUID, len(l))
+	for i, node := range l {
+		ids[i] = node.ID
+	}
+	return ids
+}
+
+// Labels returns the labels of the nodes in list order.
+func (l NodeList) Labels() []string {
+	labels := make([]string, len(l))
+	for i, node := range l {
+		labels[i] = node.Label
+	}
+	return labels
+}
+
+// Find returns the nodes matching the query, sorted by label.
+func (nmap NodeMap) Find(q NodeQuery) NodeList {
+	result := NodeList{}
+	for _, node := range nmap {
+		if q.Matches(node) {
+			result = append(result, node)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Label == result[j].Label {
+			return result[i].ID < result[j].ID
+		}
+		return result[i].Label < result[j].Label
+	})
+	return result
+}
+
+// FindNodes returns the nodes of the lab matching the query, sorted by
+// label.
+func (l *Lab) FindNodes(q NodeQuery) NodeList {
+	return l.Nodes.Find(q)
+}
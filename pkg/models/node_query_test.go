@@ -0,0 +1,54 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func queryTestNodes() NodeMap {
+	return NodeMap{
+		"n1": {ID: "n1", Label: "core-1", NodeDefinition: "iosv", Tags: []string{"core", "site-a"}, State: NodeStateBooted},
+		"n2": {ID: "n2", Label: "core-2", NodeDefinition: "iosv", Tags: []string{"core", "site-b"}, State: NodeStateStopped},
+		"n3": {ID: "n3", Label: "spoke-1", NodeDefinition: "csr1000v", Tags: []string{"site-a"}, State: NodeStateBooted},
+		"n4": {ID: "n4", Label: "srv", NodeDefinition: "alpine", State: NodeStateDefined},
+	}
+}
+
+func TestNodeMap_Find(t *testing.T) {
+	nodes := queryTestNodes()
+
+	tests := []struct {
+		name     string
+		query    NodeQuery
+		expected []string
+	}{
+		{"all", NodeQuery{}, []string{"core-1", "core-2", "spoke-1", "srv"}},
+		{"label", NodeQuery{Labels: []string{"srv"}}, []string{"srv"}},
+		{"label pattern", NodeQuery{Labels: []string{"core-*", "srv"}}, []string{"core-1", "core-2", "srv"}},
+		{"label missing", NodeQuery{Labels: []string{"missing"}}, []string{}},
+		{"tag any", NodeQuery{Tags: []string{"core", "site-a"}}, []string{"core-1", "core-2", "spoke-1"}},
+		{"tag all", NodeQuery{Tags: []string{"core", "site-a"}, TagMatch: TagMatchAll}, []string{"core-1"}},
+		{"node definition", NodeQuery{NodeDefinitions: []string{"iosv", "alpine"}}, []string{"core-1", "core-2", "srv"}},
+		{"state", NodeQuery{States: []NodeState{NodeStateBooted}}, []string{"core-1", "spoke-1"}},
+		{
+			"combined",
+			NodeQuery{Tags: []string{"site-a"}, NodeDefinitions: []string{"iosv"}, States: []NodeState{NodeStateBooted}},
+			[]string{"core-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, nodes.Find(tt.query).Labels())
+		})
+	}
+}
+
+func TestLab_FindNodes(t *testing.T) {
+	lab := &Lab{Nodes: queryTestNodes()}
+	result := lab.FindNodes(NodeQuery{Tags: []string{"core"}})
+	assert.Equal(t, []UUID{"n1", "n2"}, result.IDs())
+
+	lab = &Lab{}
+	assert.Empty(t, lab.FindNodes(NodeQuery{}))
+}
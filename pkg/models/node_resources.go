@@ -0,0 +1,152 @@
+// Package models provides the models for Cisco Modeling Labs
+// here: effective node resource related types
+package models
+
+import (
+	cmlerror "github.com/rschmied/gocmlclient/pkg/errors"
+)
+
+// ResourceSource tells where the effective value of a node resource comes
+// from.
+type ResourceSource string
+
+const (
+	// ResourceSourceNone indicates that no value is set anywhere.
+	ResourceSourceNone ResourceSource = ""
+	// ResourceSourceNode indicates a value set on the node itself.
+	ResourceSourceNode ResourceSource = "node"
+	// ResourceSourceImageDefinition indicates a value inherited from the
+	// image definition of the node.
+	ResourceSourceImageDefinition ResourceSource = "image_definition"
+	// ResourceSourceNodeDefinition indicates a value inherited from the node
+	// definition of the node.
+	ResourceSourceNodeDefinition ResourceSource = "node_definition"
+)
+
+// ResourceValue is the effective value of a node resource and its source.
+type ResourceValue struct {
+	Value  int            `json:"value"`
+	Source ResourceSource `json:"source"`
+}
+
+// IsSet returns true if the value is set anywhere.
+func (rv ResourceValue) IsSet() bool {
+	return rv.Source != ResourceSourceNone
+}
+
+// NodeResources are the effective resources of a node. RAM, data volume
+// and boot disk size are in the units of the node definition (MB for RAM,
+// GB for disks), the CPU limit is in percent.
+type NodeResources struct {
+	NodeID          UUID          `json:"node_id"`
+	ImageDefinition string        `json:"image_definition,omitempty"`
+	RAM             ResourceValue `json:"ram"`
+	CPUs            ResourceValue `json:"cpus"`
+	CPULimit        ResourceValue `json:"cpu_limit"`
+	DataVolume      ResourceValue `json:"data_volume"`
+	BootDiskSize    ResourceValue `json:"boot_disk_size"`
+}
+
+// NodeResourcesMap maps node IDs to their effective resources.
+type NodeResourcesMap map[UUID]NodeResources
+
+// ResourceResolver computes the effective resources of nodes from the node
+// and image definitions. A value set on the node wins over the value of its
+// image definition which wins over the value of its node definition. The
+// controller does not report the inherited values consistently (see
+// NodeService.GetByID), hence they are resolved on the client side.
+type ResourceResolver struct {
+	nodeDefs  NodeDefinitionMap
+	imageDefs map[UUID]ImageDefinition
+}
+
+// NewResourceResolver returns a resolver for the provided node and image
+// definitions, typically fetched once via the node and image definition
+// services and cached.
+func NewResourceResolver(nodeDefs NodeDefinitionMap, imageDefs []ImageDefinition) *ResourceResolver {
+	r := &ResourceResolver{
+		nodeDefs:  nodeDefs,
+		imageDefs: make(map[UUID]ImageDefinition, len(imageDefs)),
+	}
+	for _, imageDef := range imageDefs {
+		r.imageDefs[imageDef.ID] = imageDef
+	}
+	return r
+}
+
+// Resolve returns the effective resources of the node. The image definition
+// is the one set on the node or, if none is set, the one the controller
+// reported as used for a started node. Without an image definition, the
+// values of the node definition apply. An unknown node or image definition
+// results in ErrElementNotFound.
+func (r *ResourceResolver) Resolve(node *Node) (NodeResources, error) {
+	nodeDef, found := r.nodeDefs[UUID(node.NodeDefinition)]
+	if !found {
+		return NodeResources{}, cmlerror.Wrapf(cmlerror.ErrElementNotFound, "resolve resources of node %s: node definition %q", node.Label, node.NodeDefinition)
+	}
+
+	var imageDef *ImageDefinition
+	imageID := node.ImageDefinition
+	if (imageID == nil || len(*imageID) == 0) && node.Operational != nil {
+		imageID = node.Operational.ImageDefinition
+	}
+	if imageID != nil && len(*imageID) > 0 {
+		def, found := r.imageDefs[UUID(*imageID)]
+		if !found {
+			return NodeResources{}, cmlerror.Wrapf(cmlerror.ErrElementNotFound, "resolve resources of node %s: image definition %q", node.Label, *imageID)
+		}
+		imageDef = &def
+	}
+
+	var cpus *int
+	if node.CPUs > 0 {
+		cpus = &node.CPUs
+	}
+	sim := nodeDef.Sim
+	result := NodeResources{
+		NodeID:       node.ID,
+		RAM:          resolveResource(node.RAM, imageDef, func(d *ImageDefinition) *int { return d.RAM }, sim.LinuxNative.RAM, sim.RAM),
+		CPUs:         resolveResource(cpus, imageDef, func(d *ImageDefinition) *int { return d.CPUs }, sim.LinuxNative.CPUs, sim.CPUs),
+		CPULimit:     resolveResource(node.CPUlimit, imageDef, func(d *ImageDefinition) *int { return d.CPUlimit }, sim.LinuxNative.CPULimit, sim.CPULimit),
+		DataVolume:   resolveResource(node.DataVolume, imageDef, func(d *ImageDefinition) *int { return d.DataVolume }, sim.LinuxNative.DataVolume, sim.DataVolume),
+		BootDiskSize: resolveResource(node.BootDiskSize, imageDef, func(d *ImageDefinition) *int { return d.BootDiskSize }, sim.LinuxNative.BootDiskSize, sim.BootDiskSize),
+	}
+	if imageDef != nil {
+		result.ImageDefinition = string(imageDef.ID)
+	}
+	return result, nil
+}
+
+// ResolveAll returns the effective resources of all nodes. The first node
+// which can't be resolved stops the resolution with an error.
+func (r *ResourceResolver) ResolveAll(nodes NodeMap) (NodeResourcesMap, error) {
+	result := make(NodeResourcesMap, len(nodes))
+	for id, node := range nodes {
+		res, err := r.Resolve(node)
+		if err != nil {
+			return nil, err
+		}
+		result[id] = res
+	}
+	return result, nil
+}
+
+// resolveResource picks the first set value. Node definitions carry the
+// values in the linux native simulation data, older ones at the top level.
+func resolveResource(nodeValue *int, imageDef *ImageDefinition, imageValue func(*ImageDefinition) *int, native, sim int) ResourceValue {
+	if nodeValue != nil {
+		return ResourceValue{Value: *nodeValue, Source: ResourceSourceNode}
+	}
+	if imageDef != nil {
+		if v := imageValue(imageDef); v != nil {
+			return ResourceValue{Value: *v, Source: ResourceSourceImageDefinition}
+		}
+	}
+	if native > 0 {
+		return ResourceValue{Value: native, Source: ResourceSourceNodeDefinition}
+	}
+	if sim > 0 {
+		return ResourceValue{Value: sim, Source: ResourceSourceNodeDefinition}
+	}
+	return ResourceValue{}
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cmlerror "github.com/rschmied/gocmlclient/pkg/errors"
+)
+
+func resourceTestResolver() *ResourceResolver {
+	nodeDefs := NodeDefinitionMap{
+		"iosv": {
+			ID:  "iosv",
+			Sim: simData{LinuxNative: LinuxNativeSimulation{RAM: 512, CPUs: 1, CPULimit: 100}},
+		},
+		"legacy": {
+			ID:  "legacy",
+			Sim: simData{RAM: 256, CPUs: 1},
+		},
+	}
+	imageDefs := []ImageDefinition{
+		{ID: "iosv-159", NodeDefID: "iosv", RAM: intPtr(1024), DataVolume: intPtr(2)},
+		{ID: "iosv-158", NodeDefID: "iosv"},
+	}
+	return NewResourceResolver(nodeDefs, imageDefs)
+}
+
+func TestResourceResolver_Resolve(t *testing.T) {
+	r := resourceTestResolver()
+
+	tests := []struct {
+		name     string
+		node     Node
+		image    string
+		ram      ResourceValue
+		cpus     ResourceValue
+		cpuLimit ResourceValue
+		data     ResourceValue
+		bootDisk ResourceValue
+	}{
+		{
+			name:     "node definition only",
+			node:     Node{ID: "n1", NodeDefinition: "iosv"},
+			ram:      ResourceValue{512, ResourceSourceNodeDefinition},
+			cpus:     ResourceValue{1, ResourceSourceNodeDefinition},
+			cpuLimit: ResourceValue{100, ResourceSourceNodeDefinition},
+		},
+		{
+			name:     "image definition",
+			node:     Node{ID: "n1", NodeDefinition: "iosv", ImageDefinition: stringPtr("iosv-159")},
+			image:    "iosv-159",
+			ram:      ResourceValue{1024, ResourceSourceImageDefinition},
+			cpus:     ResourceValue{1, ResourceSourceNodeDefinition},
+			cpuLimit: ResourceValue{100, ResourceSourceNodeDefinition},
+			data:     ResourceValue{2, ResourceSourceImageDefinition},
+		},
+		{
+			name: "node values",
+			node: Node{
+				ID: "n1", NodeDefinition: "iosv", ImageDefinition: stringPtr("iosv-159"),
+				CPUs: 2, RAM: intPtr(2048), CPUlimit: intPtr(50), DataVolume: intPtr(0), BootDiskSize: intPtr(16),
+			},
+			image:    "iosv-159",
+			ram:      ResourceValue{2048, ResourceSourceNode},
+			cpus:     ResourceValue{2, ResourceSourceNode},
+			cpuLimit: ResourceValue{50, ResourceSourceNode},
+			data:     ResourceValue{0, ResourceSourceNode},
+			bootDisk: ResourceValue{16, ResourceSourceNode},
+		},
+		{
+			name: "operational image definition",
+			node: Node{
+				ID: "n1", NodeDefinition: "iosv",
+				Operational: &NodeOperational{ImageDefinition: stringPtr("iosv-159")},
+			},
+			image:    "iosv-159",
+			ram:      ResourceValue{1024, ResourceSourceImageDefinition},
+			cpus:     ResourceValue{1, ResourceSourceNodeDefinition},
+			cpuLimit: ResourceValue{100, ResourceSourceNodeDefinition},
+			data:     ResourceValue{2, ResourceSourceImageDefinition},
+		},
+		{
+			name:     "image definition without values",
+			node:     Node{ID: "n1", NodeDefinition: "iosv", ImageDefinition: stringPtr("iosv-158")},
+			image:    "iosv-158",
+			ram:      ResourceValue{512, ResourceSourceNodeDefinition},
+			cpus:     ResourceValue{1, ResourceSourceNodeDefinition},
+			cpuLimit: ResourceValue{100, ResourceSourceNodeDefinition},
+		},
+		{
+			name: "legacy node definition",
+			node: Node{ID: "n1", NodeDefinition: "legacy"},
+			ram:  ResourceValue{256, ResourceSourceNodeDefinition},
+			cpus: ResourceValue{1, ResourceSourceNodeDefinition},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := r.Resolve(&tt.node)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.node.ID, res.NodeID)
+			assert.Equal(t, tt.image, res.ImageDefinition)
+			assert.Equal(t, tt.ram, res.RAM)
+			assert.Equal(t, tt.cpus, res.CPUs)
+			assert.Equal(t, tt.cpuLimit, res.CPULimit)
+			assert.Equal(t, tt.data, res.DataVolume)
+			assert.Equal(t, tt.bootDisk, res.BootDiskSize)
+		})
+	}
+	assert.False(t, ResourceValue{}.IsSet())
+}
+
+func TestResourceResolver_Errors(t *testing.T) {
+	r := resourceTestResolver()
+
+	_, err := r.Resolve(&Node{Label: "r1", NodeDefinition: "unknown"})
+	assert.ErrorIs(t, err, cmlerror.ErrElementNotFound)
+	assert.Contains(t, err.Error(), "node definition")
+
+	_, err = r.Resolve(&Node{Label: "r1", NodeDefinition: "iosv", ImageDefinition: stringPtr("unknown")})
+	assert.ErrorIs(t, err, cmlerror.ErrElementNotFound)
+	assert.Contains(t, err.Error(), "image definition")
+
+	_, err = r.ResolveAll(NodeMap{"n1": {ID: "n1", NodeDefinition: "unknown"}})
+	assert.Error(t, err)
+
+	all, err := r.ResolveAll(NodeMap{"n1": {ID: "n1", NodeDefinition: "iosv"}, "n2": {ID: "n2", NodeDefinition: "legacy"}})
+	assert.NoError(t, err)
+	assert.Len(t, all, 2)
+	assert.Equal(t, 256, all["n2"].RAM.Value)
+}
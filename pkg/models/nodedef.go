@@ -226,3 +226,15 @@ func (nd NodeDefinition) HasSerial() bool {
 func (nd NodeDefinition) SerialPorts() int {
 	return nd.Device.Interfaces.SerialPorts
 }
+
+// PhysicalSlot returns the slot of the physical interface with the given
+// label (e.g. "GigabitEthernet0/2"), false if the node definition has no
+// such interface.
+func (nd NodeDefinition) PhysicalSlot(label string) (int, bool) {
+	for slot, name := range nd.Device.Interfaces.Physical {
+		if name == label {
+			return slot, true
+		}
+	}
+	return -1, false
+}
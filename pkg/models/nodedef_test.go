@@ -263,6 +263,24 @@ func TestNodeDefinition_Methods(t *testing.T) {
 	}
 }
 
+func TestNodeDefinition_PhysicalSlot(t *testing.T) {
+	nodeDef := NodeDefinition{
+		Device: deviceData{
+			Interfaces: interfaceData{
+				Physical: []string{"GigabitEthernet0/0", "GigabitEthernet0/1", "GigabitEthernet0/2"},
+			},
+		},
+	}
+
+	slot, ok := nodeDef.PhysicalSlot("GigabitEthernet0/2")
+	assert.True(t, ok)
+	assert.Equal(t, 2, slot)
+
+	slot, ok = nodeDef.PhysicalSlot("Loopback0")
+	assert.False(t, ok)
+	assert.Equal(t, -1, slot)
+}
+
 func TestEnums(t *testing.T) {
 	// Test DeviceNature
 	assert.Equal(t, DeviceNature("server"), DeviceNatureServer)
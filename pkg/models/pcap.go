@@ -0,0 +1,38 @@
+// Package models provides the models for Cisco Modeling Labs
+// here: packet capture related types
+package models
+
+// PCAPConfig defines a packet capture on a link. The capture stops when
+// either limit is reached, a zero value means no limit. At least one limit
+// is required by the controller.
+type PCAPConfig struct {
+	// MaxPackets is the maximum number of packets to capture.
+	MaxPackets int `json:"maxpackets,omitempty"`
+	// MaxTime is the maximum capture duration in seconds.
+	MaxTime int `json:"maxtime,omitempty"`
+	// BPFilter is a Berkeley packet filter expression, e.g. "icmp or arp".
+	BPFilter string `json:"bpfilter,omitempty"`
+	// Encap is the link encapsulation, defaults to ethernet.
+	Encap string `json:"encap,omitempty"`
+}
+
+// PCAPStatus is the status of the packet capture of a link.
+type PCAPStatus struct {
+	Config PCAPConfig `json:"config"`
+	// StartTime is empty if no capture has been started.
+	StartTime string `json:"starttime"`
+	Packets   int    `json:"packetscaptured"`
+	Running   bool   `json:"running"`
+}
+
+// PCAPPacket is the summary of a captured packet as listed by the
+// controller.
+type PCAPPacket struct {
+	Number      int    `json:"packet_number"`
+	Timestamp   string `json:"timestamp"`
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Protocol    string `json:"protocol"`
+	Length      int    `json:"length"`
+	Info        string `json:"info"`
+}
@@ -0,0 +1,234 @@
+// Package models provides the models for Cisco Modeling Labs
+// here: reconcile plan types
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PlanAction is the action planned for an element of a lab.
+type PlanAction string
+
+const (
+	// PlanActionCreate creates a new element.
+	PlanActionCreate PlanAction = "create"
+	// PlanActionUpdate changes an existing element in place.
+	PlanActionUpdate PlanAction = "update"
+	// PlanActionReplace deletes an existing element and creates it again.
+	PlanActionReplace PlanAction = "replace"
+	// PlanActionDelete deletes an existing element.
+	PlanActionDelete PlanAction = "delete"
+)
+
+// symbol returns the Terraform style marker for the action.
+func (a PlanAction) symbol() string {
+	switch a {
+	case PlanActionCreate:
+		return "+"
+	case PlanActionUpdate:
+		return "~"
+	case PlanActionReplace:
+		return "-/+"
+	case PlanActionDelete:
+		return "-"
+	}
+	return "?"
+}
+
+// PlanChange is a single attribute change of a planned update or replace.
+// Old and New hold the values as they are sent to / received from the API.
+type PlanChange struct {
+	Field string
+	Old   any
+	New   any
+	// ForcesReplace is set when the attribute can't be changed in place, e.g.
+	// because the node isn't in DEFINED_ON_CORE state.
+	ForcesReplace bool
+}
+
+// NodePlan is the planned action for a node. Nodes are matched by label.
+type NodePlan struct {
+	Action PlanAction
+	Label  string
+	// ID and State of the existing node, empty on create
+	ID    UUID
+	State NodeState
+	// Desired holds the node from the desired topology, nil on delete
+	Desired *NodeTopology
+	Changes []PlanChange
+}
+
+// InterfacePlan is a planned interface creation or deletion on an existing
+// node. Interfaces of created nodes are created together with the node.
+type InterfacePlan struct {
+	Action PlanAction
+	Node   string
+	Label  string
+	Slot   int
+	// ID of the existing interface, empty on create
+	ID UUID
+}
+
+// LinkEndpoint identifies one side of a link by node and interface label.
+type LinkEndpoint struct {
+	Node      string
+	Interface string
+}
+
+// String returns the endpoint as "node:interface".
+func (e LinkEndpoint) String() string {
+	return fmt.Sprintf("%q:%q", e.Node, e.Interface)
+}
+
+// LinkPlan is the planned action for a link. Links are matched by their
+// endpoints.
+type LinkPlan struct {
+	Action PlanAction
+	// ID of the existing link, empty on create
+	ID UUID
+	A  LinkEndpoint
+	B  LinkEndpoint
+}
+
+// LinkConditionPlan is a planned change of the conditioning of a link. The
+// action is either update or delete.
+type LinkConditionPlan struct {
+	Action PlanAction
+	// LinkID is empty if the link is created by the plan
+	LinkID  UUID
+	A       LinkEndpoint
+	B       LinkEndpoint
+	Desired *LinkConditionConfiguration
+	Changes []PlanChange
+}
+
+// AnnotationPlan is a planned annotation creation or deletion. Annotations
+// have no identity besides their content, changed annotations are deleted
+// and created again.
+type AnnotationPlan struct {
+	Action PlanAction
+	// ID of the existing annotation, empty on create
+	ID         UUID
+	Annotation AnnotationTopology
+}
+
+// ReconcilePlan holds the actions needed to turn an existing lab into the
+// desired topology.
+type ReconcilePlan struct {
+	LabID          UUID
+	Nodes          []NodePlan
+	Interfaces     []InterfacePlan
+	Links          []LinkPlan
+	LinkConditions []LinkConditionPlan
+	Annotations    []AnnotationPlan
+	// Wait controls the polling while waiting for removed and replaced nodes
+	// to stop and to be wiped when the plan is applied.
+	Wait WaitOptions
+}
+
+// Empty returns true if the plan has no actions.
+func (p *ReconcilePlan) Empty() bool {
+	return len(p.Nodes) == 0 && len(p.Interfaces) == 0 && len(p.Links) == 0 &&
+		len(p.LinkConditions) == 0 && len(p.Annotations) == 0
+}
+
+// Summary returns the number of elements to add, change and destroy. Like
+// with Terraform, a replaced element is counted as added and destroyed.
+func (p *ReconcilePlan) Summary() (add, change, destroy int) {
+	count := func(action PlanAction) {
+		switch action {
+		case PlanActionCreate:
+			add++
+		case PlanActionUpdate:
+			change++
+		case PlanActionReplace:
+			add++
+			destroy++
+		case PlanActionDelete:
+			destroy++
+		}
+	}
+	for _, n := range p.Nodes {
+		count(n.Action)
+	}
+	for _, i := range p.Interfaces {
+		count(i.Action)
+	}
+	for _, l := range p.Links {
+		count(l.Action)
+	}
+	for _, c := range p.LinkConditions {
+		// setting or removing the conditioning changes the link
+		if c.Action == PlanActionUpdate || c.Action == PlanActionDelete {
+			change++
+		}
+	}
+	for _, a := range p.Annotations {
+		count(a.Action)
+	}
+	return add, change, destroy
+}
+
+// String returns a human readable, Terraform style description of the plan.
+func (p *ReconcilePlan) String() string {
+	if p.Empty() {
+		return "No changes. The lab matches the desired topology.\n"
+	}
+
+	var b strings.Builder
+	for _, n := range p.Nodes {
+		switch n.Action {
+		case PlanActionCreate:
+			fmt.Fprintf(&b, "  %s node %q (%s)\n", n.Action.symbol(), n.Label, n.Desired.NodeDefinition)
+		case PlanActionReplace:
+			fmt.Fprintf(&b, "%s node %q (forces replacement)\n", n.Action.symbol(), n.Label)
+		default:
+			fmt.Fprintf(&b, "  %s node %q\n", n.Action.symbol(), n.Label)
+		}
+		writeChanges(&b, n.Changes)
+	}
+	for _, i := range p.Interfaces {
+		fmt.Fprintf(&b, "  %s interface %q on node %q (slot %d)\n", i.Action.symbol(), i.Label, i.Node, i.Slot)
+	}
+	for _, l := range p.Links {
+		fmt.Fprintf(&b, "  %s link %s <-> %s\n", l.Action.symbol(), l.A, l.B)
+	}
+	for _, c := range p.LinkConditions {
+		if c.Action == PlanActionDelete {
+			fmt.Fprintf(&b, "  %s link condition %s <-> %s\n", c.Action.symbol(), c.A, c.B)
+			continue
+		}
+		fmt.Fprintf(&b, "  %s link condition %s <-> %s\n", PlanActionUpdate.symbol(), c.A, c.B)
+		writeChanges(&b, c.Changes)
+	}
+	for _, a := range p.Annotations {
+		fmt.Fprintf(&b, "  %s annotation %s at (%g, %g)\n", a.Action.symbol(), a.Annotation.Type, a.Annotation.X1, a.Annotation.Y1)
+	}
+
+	add, change, destroy := p.Summary()
+	fmt.Fprintf(&b, "\nPlan: %d to add, %d to change, %d to destroy.\n", add, change, destroy)
+	return b.String()
+}
+
+func writeChanges(b *strings.Builder, changes []PlanChange) {
+	for _, c := range changes {
+		fmt.Fprintf(b, "      ~ %s: %s -> %s", c.Field, planValue(c.Old), planValue(c.New))
+		if c.ForcesReplace {
+			b.WriteString(" # forces replacement")
+		}
+		b.WriteString("\n")
+	}
+}
+
+func planValue(v any) string {
+	if v == nil {
+		return "null"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcilePlan_String(t *testing.T) {
+	plan := ReconcilePlan{}
+	assert.True(t, plan.Empty())
+	assert.Equal(t, "No changes. The lab matches the desired topology.\n", plan.String())
+
+	plan = ReconcilePlan{
+		LabID: "lab-1",
+		Nodes: []NodePlan{
+			{Action: PlanActionCreate, Label: "r3", Desired: &NodeTopology{NodeDefinition: "iosv"}},
+			{Action: PlanActionReplace, Label: "r2", Changes: []PlanChange{
+				{Field: "configuration", Old: "hostname a", New: "hostname b", ForcesReplace: true},
+			}},
+			{Action: PlanActionDelete, Label: "r1"},
+		},
+		Interfaces: []InterfacePlan{
+			{Action: PlanActionCreate, Node: "r4", Label: "eth2", Slot: 2},
+			{Action: PlanActionDelete, Node: "r4", Label: "eth3", Slot: 3, ID: "i3"},
+		},
+		Links: []LinkPlan{
+			{Action: PlanActionCreate, A: LinkEndpoint{"r3", "eth0"}, B: LinkEndpoint{"r4", "eth0"}},
+		},
+		LinkConditions: []LinkConditionPlan{
+			{Action: PlanActionUpdate, A: LinkEndpoint{"r3", "eth0"}, B: LinkEndpoint{"r4", "eth0"}, Changes: []PlanChange{
+				{Field: "latency", Old: nil, New: float64(10)},
+			}},
+		},
+		Annotations: []AnnotationPlan{
+			{Action: PlanActionDelete, ID: "a1", Annotation: AnnotationTopology{Type: AnnotationTypeText, X1: 10, Y1: 20}},
+		},
+	}
+	assert.False(t, plan.Empty())
+
+	add, change, destroy := plan.Summary()
+	assert.Equal(t, 4, add)
+	assert.Equal(t, 1, change)
+	assert.Equal(t, 4, destroy)
+
+	expected := `  + node "r3" (iosv)
+-/+ node "r2" (forces replacement)
+      ~ configuration: "hostname a" -> "hostname b" # forces replacement
+  - node "r1"
+  + interface "eth2" on node "r4" (slot 2)
+  - interface "eth3" on node "r4" (slot 3)
+  + link "r3":"eth0" <-> "r4":"eth0"
+  ~ link condition "r3":"eth0" <-> "r4":"eth0"
+      ~ latency: null -> 10
+  - annotation text at (10, 20)
+
+Plan: 4 to add, 1 to change, 4 to destroy.
+`
+	assert.Equal(t, expected, plan.String())
+}
@@ -0,0 +1,303 @@
+// Package models provides the models for Cisco Modeling Labs
+// here: topology (import/export) related types
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LabTopology is the typed form of a CML topology document as it is used by
+// the lab import and export APIs. The IDs in a topology document are local to
+// the document (e.g. "n0", "i0", "l0") and are not controller UUIDs.
+type LabTopology struct {
+	Lab              TopologyLab               `json:"lab" yaml:"lab"`
+	Nodes            []NodeTopology            `json:"nodes" yaml:"nodes"`
+	Links            []LinkTopology            `json:"links" yaml:"links"`
+	Annotations      []AnnotationTopology      `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	SmartAnnotations []SmartAnnotationTopology `json:"smart_annotations,omitempty" yaml:"smart_annotations,omitempty"`
+}
+
+// TopologyLab is the lab header of a topology document.
+type TopologyLab struct {
+	Title       string `json:"title" yaml:"title"`
+	Description string `json:"description" yaml:"description"`
+	Notes       string `json:"notes" yaml:"notes"`
+	Version     string `json:"version,omitempty" yaml:"version,omitempty"`
+}
+
+// NodeTopology defines the data structure for a CML node in topology context.
+type NodeTopology struct {
+	ID              string   `json:"id" yaml:"id"`
+	Label           string   `json:"label" yaml:"label"`
+	X               int      `json:"x" yaml:"x"`
+	Y               int      `json:"y" yaml:"y"`
+	NodeDefinition  string   `json:"node_definition" yaml:"node_definition"`
+	ImageDefinition *string  `json:"image_definition,omitempty" yaml:"image_definition,omitempty"`
+	RAM             *int     `json:"ram,omitempty" yaml:"ram,omitempty"`
+	CPUs            *int     `json:"cpus,omitempty" yaml:"cpus,omitempty"`
+	CPUlimit        *int     `json:"cpu_limit,omitempty" yaml:"cpu_limit,omitempty"`
+	DataVolume      *int     `json:"data_volume,omitempty" yaml:"data_volume,omitempty"`
+	BootDiskSize    *int     `json:"boot_disk_size,omitempty" yaml:"boot_disk_size,omitempty"`
+	HideLinks       bool     `json:"hide_links,omitempty" yaml:"hide_links,omitempty"`
+	Priority        *int     `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Tags            []string `json:"tags" yaml:"tags"`
+
+	Parameters NodeParameters `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+
+	// Configuration is either a single configuration string or a list of
+	// named configurations, same as with Node.
+	Configuration  string       `json:"-" yaml:"-"`
+	Configurations []NodeConfig `json:"-" yaml:"-"`
+
+	Interfaces []InterfaceTopology `json:"interfaces" yaml:"interfaces"`
+}
+
+// InterfaceTopology defines the data structure for a CML interface in
+// topology context.
+type InterfaceTopology struct {
+	ID         string    `json:"id" yaml:"id"`
+	Label      string    `json:"label" yaml:"label"`
+	Slot       *int      `json:"slot,omitempty" yaml:"slot,omitempty"`
+	Type       IfaceType `json:"type" yaml:"type"`
+	MACAddress *string   `json:"mac_address,omitempty" yaml:"mac_address,omitempty"`
+}
+
+// AnnotationTopology defines the data structure for a classic annotation in
+// topology context. All annotation types share this flat structure, the
+// fields which only apply to some of the types are optional.
+type AnnotationTopology struct {
+	Type        AnnotationType `json:"type" yaml:"type"`
+	BorderColor string         `json:"border_color" yaml:"border_color"`
+	BorderStyle BorderStyle    `json:"border_style" yaml:"border_style"`
+	Color       string         `json:"color" yaml:"color"`
+	Thickness   float64        `json:"thickness" yaml:"thickness"`
+	X1          float64        `json:"x1" yaml:"x1"`
+	Y1          float64        `json:"y1" yaml:"y1"`
+	ZIndex      float64        `json:"z_index" yaml:"z_index"`
+
+	// rectangle, ellipse and line
+	X2 *float64 `json:"x2,omitempty" yaml:"x2,omitempty"`
+	Y2 *float64 `json:"y2,omitempty" yaml:"y2,omitempty"`
+
+	// all but line
+	Rotation *float64 `json:"rotation,omitempty" yaml:"rotation,omitempty"`
+
+	// rectangle
+	BorderRadius *float64 `json:"border_radius,omitempty" yaml:"border_radius,omitempty"`
+
+	// text
+	TextBold    *bool    `json:"text_bold,omitempty" yaml:"text_bold,omitempty"`
+	TextContent *string  `json:"text_content,omitempty" yaml:"text_content,omitempty"`
+	TextFont    *string  `json:"text_font,omitempty" yaml:"text_font,omitempty"`
+	TextItalic  *bool    `json:"text_italic,omitempty" yaml:"text_italic,omitempty"`
+	TextSize    *float64 `json:"text_size,omitempty" yaml:"text_size,omitempty"`
+	TextUnit    *string  `json:"text_unit,omitempty" yaml:"text_unit,omitempty"`
+
+	// line
+	LineStart *LineStyle `json:"line_start,omitempty" yaml:"line_start,omitempty"`
+	LineEnd   *LineStyle `json:"line_end,omitempty" yaml:"line_end,omitempty"`
+}
+
+// SmartAnnotationTopology defines the data structure for a smart annotation
+// in topology context. Smart annotations are tied to node tags.
+type SmartAnnotationTopology struct {
+	Tag           string      `json:"tag" yaml:"tag"`
+	IsOn          bool        `json:"is_on" yaml:"is_on"`
+	Label         string      `json:"label" yaml:"label"`
+	Padding       int         `json:"padding" yaml:"padding"`
+	TagOffsetX    int         `json:"tag_offset_x" yaml:"tag_offset_x"`
+	TagOffsetY    int         `json:"tag_offset_y" yaml:"tag_offset_y"`
+	TagSize       int         `json:"tag_size" yaml:"tag_size"`
+	GroupDistance int         `json:"group_distance" yaml:"group_distance"`
+	Thickness     int         `json:"thickness" yaml:"thickness"`
+	BorderStyle   BorderStyle `json:"border_style" yaml:"border_style"`
+	FillColor     string      `json:"fill_color" yaml:"fill_color"`
+	BorderColor   string      `json:"border_color" yaml:"border_color"`
+	ZIndex        int         `json:"z_index" yaml:"z_index"`
+}
+
+// ParseLabTopology parses a topology document. Since YAML is a superset of
+// JSON, both formats are accepted.
+func ParseLabTopology(data []byte) (*LabTopology, error) {
+	var topo LabTopology
+	if err := yaml.Unmarshal(data, &topo); err != nil {
+		return nil, fmt.Errorf("parse topology: %w", err)
+	}
+	return &topo, nil
+}
+
+// YAML returns the topology document as YAML, e.g. to be used with the lab
+// import. The indentation matches the one used by the controller.
+func (t *LabTopology) YAML() (string, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(t); err != nil {
+		return "", fmt.Errorf("marshal topology: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("marshal topology: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// NodeByID returns the node with the given topology ID or nil.
+func (t *LabTopology) NodeByID(id string) *NodeTopology {
+	for i := range t.Nodes {
+		if t.Nodes[i].ID == id {
+			return &t.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// NodeByLabel returns the node with the given label or nil.
+func (t *LabTopology) NodeByLabel(label string) *NodeTopology {
+	for i := range t.Nodes {
+		if t.Nodes[i].Label == label {
+			return &t.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// InterfaceByID returns the interface with the given topology ID or nil.
+// Interface IDs are only unique within a node.
+func (n *NodeTopology) InterfaceByID(id string) *InterfaceTopology {
+	for i := range n.Interfaces {
+		if n.Interfaces[i].ID == id {
+			return &n.Interfaces[i]
+		}
+	}
+	return nil
+}
+
+// CreateRequest returns the create payload for the annotation.
+func (a AnnotationTopology) CreateRequest() (AnnotationCreate, error) {
+	// the topology form uses the same field names as the API
+	data, err := json.Marshal(a)
+	if err != nil {
+		return AnnotationCreate{}, err
+	}
+	var annotation Annotation
+	if err := json.Unmarshal(data, &annotation); err != nil {
+		return AnnotationCreate{}, err
+	}
+	return annotation.CreateRequest(), nil
+}
+
+// Topology returns the annotation in topology document form.
+func (a Annotation) Topology() (AnnotationTopology, error) {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return AnnotationTopology{}, err
+	}
+	var annotation AnnotationTopology
+	if err := json.Unmarshal(data, &annotation); err != nil {
+		return AnnotationTopology{}, err
+	}
+	return annotation, nil
+}
+
+// LabExportOptions controls what is included in a lab export.
+type LabExportOptions struct {
+	// ExcludeConfigurations omits the node configurations from the export.
+	ExcludeConfigurations bool
+	// ExtractConfigurations extracts the running configurations of all
+	// booted nodes before the export so that changes made on the nodes are
+	// included. Ignored when configurations are excluded.
+	ExtractConfigurations bool
+}
+
+// LabExport is the result of a lab export. YAML holds the topology document
+// exactly as downloaded from the controller, Topology is the typed form
+// parsed from it.
+type LabExport struct {
+	YAML     string
+	Topology LabTopology
+}
+
+// configurationValue returns the configuration in the shape used by the
+// topology document: a list for named configs, a string otherwise.
+func (n NodeTopology) configurationValue() any {
+	if len(n.Configurations) > 0 {
+		return n.Configurations
+	}
+	if len(n.Configuration) > 0 {
+		return n.Configuration
+	}
+	return nil
+}
+
+// setConfiguration takes the generically decoded configuration value and
+// stores it either as a string or as a list of named configs.
+func (n *NodeTopology) setConfiguration(v any) error {
+	n.Configuration = ""
+	n.Configurations = nil
+
+	switch thing := v.(type) {
+	case nil:
+		return nil
+	case string:
+		n.Configuration = thing
+		return nil
+	case []any, map[string]any:
+		b, err := json.Marshal(thing)
+		if err != nil {
+			return err
+		}
+		if _, ok := thing.(map[string]any); ok {
+			var cfg NodeConfig
+			if err := json.Unmarshal(b, &cfg); err != nil {
+				return err
+			}
+			n.Configurations = []NodeConfig{cfg}
+			return nil
+		}
+		return json.Unmarshal(b, &n.Configurations)
+	default:
+		return fmt.Errorf("unexpected configuration type: %T", thing)
+	}
+}
+
+type nodeTopologyAlias NodeTopology
+
+type nodeTopologyWire struct {
+	nodeTopologyAlias `yaml:",inline"`
+	Configuration     any `json:"configuration,omitempty" yaml:"configuration,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler for NodeTopology, handling the
+// string / named configuration variants.
+func (n NodeTopology) MarshalJSON() ([]byte, error) {
+	return json.Marshal(nodeTopologyWire{nodeTopologyAlias(n), n.configurationValue()})
+}
+
+// UnmarshalJSON implements json.Unmarshaler for NodeTopology.
+func (n *NodeTopology) UnmarshalJSON(data []byte) error {
+	var tmp nodeTopologyWire
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+	*n = NodeTopology(tmp.nodeTopologyAlias)
+	return n.setConfiguration(tmp.Configuration)
+}
+
+// MarshalYAML implements yaml.Marshaler for NodeTopology.
+func (n NodeTopology) MarshalYAML() (any, error) {
+	return nodeTopologyWire{nodeTopologyAlias(n), n.configurationValue()}, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler for NodeTopology.
+func (n *NodeTopology) UnmarshalYAML(value *yaml.Node) error {
+	var tmp nodeTopologyWire
+	if err := value.Decode(&tmp); err != nil {
+		return err
+	}
+	*n = NodeTopology(tmp.nodeTopologyAlias)
+	return n.setConfiguration(tmp.Configuration)
+}
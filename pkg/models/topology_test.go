@@ -0,0 +1,189 @@
+package models
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestNodeTopology_ConfigurationString(t *testing.T) {
+	data := `{"id": "n0", "label": "r1", "node_definition": "iosv", "x": 1, "y": 2, "tags": [], "configuration": "hostname r1", "interfaces": []}`
+
+	var node NodeTopology
+	err := json.Unmarshal([]byte(data), &node)
+	assert.NoError(t, err)
+	assert.Equal(t, "hostname r1", node.Configuration)
+	assert.Nil(t, node.Configurations)
+
+	out, err := json.Marshal(node)
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), `"configuration":"hostname r1"`)
+}
+
+func TestNodeTopology_NamedConfigurations(t *testing.T) {
+	data := `
+id: n0
+label: r1
+node_definition: iosv
+x: 1
+y: 2
+tags: []
+configuration:
+  - name: ios_config.txt
+    content: hostname r1
+interfaces:
+  - id: i0
+    label: Loopback0
+    type: loopback
+`
+	var node NodeTopology
+	err := yaml.Unmarshal([]byte(data), &node)
+	assert.NoError(t, err)
+	assert.Empty(t, node.Configuration)
+	assert.Equal(t, []NodeConfig{{Name: "ios_config.txt", Content: "hostname r1"}}, node.Configurations)
+	assert.Len(t, node.Interfaces, 1)
+	assert.Equal(t, IfaceTypeLoopback, node.Interfaces[0].Type)
+
+	out, err := yaml.Marshal(node)
+	assert.NoError(t, err)
+
+	var again NodeTopology
+	err = yaml.Unmarshal(out, &again)
+	assert.NoError(t, err)
+	assert.Equal(t, node, again)
+}
+
+func TestNodeTopology_NoConfiguration(t *testing.T) {
+	node := NodeTopology{ID: "n0", Label: "r1", NodeDefinition: "iosv"}
+
+	out, err := json.Marshal(node)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(out), "configuration")
+
+	out, err = yaml.Marshal(node)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(out), "configuration")
+}
+
+func TestNodeTopology_InvalidConfiguration(t *testing.T) {
+	var node NodeTopology
+	err := json.Unmarshal([]byte(`{"id": "n0", "configuration": 42}`), &node)
+	assert.Error(t, err)
+}
+
+func TestParseLabTopology_TestData(t *testing.T) {
+	data, err := os.ReadFile("../../integration/testdata/twonodes.yaml")
+	assert.NoError(t, err)
+
+	topo, err := ParseLabTopology(data)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "vlandrop", topo.Lab.Title)
+	assert.Len(t, topo.Nodes, 2)
+	assert.Len(t, topo.Links, 1)
+
+	n0 := topo.NodeByID("n0")
+	assert.NotNil(t, n0)
+	assert.Equal(t, "alpine-0", n0.Label)
+	assert.Equal(t, 512, *n0.RAM)
+	assert.Contains(t, n0.Configuration, "10.0.0.1/24")
+	assert.NotNil(t, n0.InterfaceByID("i0"))
+	assert.Nil(t, n0.InterfaceByID("i9"))
+
+	assert.Equal(t, "n1", topo.NodeByLabel("alpine-1").ID)
+	assert.Nil(t, topo.NodeByLabel("missing"))
+	assert.Nil(t, topo.NodeByID("missing"))
+
+	// a YAML round trip yields the same typed document
+	out, err := topo.YAML()
+	assert.NoError(t, err)
+	again, err := ParseLabTopology([]byte(out))
+	assert.NoError(t, err)
+	assert.Equal(t, topo, again)
+}
+
+func TestLabTopology_Complete(t *testing.T) {
+	img := "iosv-159-3"
+	slot := 0
+	x2, y2 := 200.0, 100.0
+	content := "core"
+	start := LineStyleArrow
+
+	topo := LabTopology{
+		Lab: TopologyLab{Title: "complete", Description: "desc", Notes: "notes", Version: "0.3.0"},
+		Nodes: []NodeTopology{{
+			ID:              "n0",
+			Label:           "r1",
+			NodeDefinition:  "iosv",
+			ImageDefinition: &img,
+			Tags:            []string{"core"},
+			Parameters:      NodeParameters{"smbios.bios.vendor": "Cisco"},
+			Configurations:  []NodeConfig{{Name: "ios_config.txt", Content: "hostname r1"}},
+			Interfaces:      []InterfaceTopology{{ID: "i0", Label: "GigabitEthernet0/0", Slot: &slot, Type: IfaceTypePhysical}},
+		}},
+		Links: []LinkTopology{{
+			ID: "l0", N1: "n0", I1: "i0", N2: "n0", I2: "i0",
+			Conditioning: &LinkConditionConfiguration{Enabled: true, Latency: 20, DelayCorr: 10, Loss: 1.5},
+		}},
+		Annotations: []AnnotationTopology{
+			{Type: AnnotationTypeText, Color: "#000000", TextContent: &content},
+			{Type: AnnotationTypeLine, X2: &x2, Y2: &y2, LineStart: &start},
+		},
+		SmartAnnotations: []SmartAnnotationTopology{{Tag: "core", IsOn: true, Label: "core", Padding: 35}},
+	}
+
+	// YAML
+	out, err := topo.YAML()
+	assert.NoError(t, err)
+	assert.Contains(t, out, "delay_corr")
+	assert.NotContains(t, out, "delaycorr")
+	assert.Contains(t, out, "line_start: arrow")
+	assert.Contains(t, out, "smbios.bios.vendor: Cisco")
+	assert.Contains(t, out, "\n  title: complete\n")
+
+	fromYAML, err := ParseLabTopology([]byte(out))
+	assert.NoError(t, err)
+	assert.Equal(t, &topo, fromYAML)
+
+	// JSON
+	b, err := json.Marshal(topo)
+	assert.NoError(t, err)
+	var fromJSON LabTopology
+	err = json.Unmarshal(b, &fromJSON)
+	assert.NoError(t, err)
+	assert.Equal(t, topo, fromJSON)
+
+	// JSON is YAML, too
+	fromJSONAsYAML, err := ParseLabTopology(b)
+	assert.NoError(t, err)
+	assert.Equal(t, &topo, fromJSONAsYAML)
+}
+
+func TestParseLabTopology_Invalid(t *testing.T) {
+	_, err := ParseLabTopology([]byte("nodes: [this is: not valid"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "parse topology")
+}
+
+func TestAnnotationTopology_Conversion(t *testing.T) {
+	var a Annotation
+	data := `{"id":"l1","type":"line","border_color":"#000","border_style":"","color":"#fff","thickness":1,"x1":1,"y1":2,"x2":3,"y2":4,"z_index":0,"line_start":"arrow","line_end":null}`
+	assert.NoError(t, json.Unmarshal([]byte(data), &a))
+
+	topo, err := a.Topology()
+	assert.NoError(t, err)
+	assert.Equal(t, AnnotationTypeLine, topo.Type)
+	assert.Equal(t, 3.0, *topo.X2)
+	assert.Equal(t, LineStyleArrow, *topo.LineStart)
+	assert.Nil(t, topo.LineEnd)
+
+	create, err := topo.CreateRequest()
+	assert.NoError(t, err)
+	assert.Equal(t, a.CreateRequest(), create)
+
+	_, err = AnnotationTopology{Type: "circle"}.CreateRequest()
+	assert.Error(t, err)
+}
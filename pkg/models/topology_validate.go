@@ -0,0 +1,186 @@
+// Package models provides the models for Cisco Modeling Labs
+// here: offline topology validation
+package models
+
+import (
+	"fmt"
+
+	cmlerror "github.com/rschmied/gocmlclient/pkg/errors"
+)
+
+// Validate checks the topology against the provided node and image
+// definitions without talking to a controller. The definitions are typically
+// fetched once via the node and image definition services and cached. All
+// problems found are returned, the result is empty if the topology is valid.
+func (t *LabTopology) Validate(nodeDefs NodeDefinitionMap, imageDefs []ImageDefinition) cmlerror.ValidationErrors {
+	v := topologyValidator{
+		nodeDefs:  nodeDefs,
+		imageDefs: make(map[UUID]ImageDefinition, len(imageDefs)),
+	}
+	for _, imageDef := range imageDefs {
+		v.imageDefs[imageDef.ID] = imageDef
+	}
+
+	v.validateNodes(t.Nodes)
+	v.validateLinks(t)
+	return v.errs
+}
+
+type topologyValidator struct {
+	nodeDefs  NodeDefinitionMap
+	imageDefs map[UUID]ImageDefinition
+	errs      cmlerror.ValidationErrors
+}
+
+func (v *topologyValidator) add(field string, value any, cause error, format string, args ...any) {
+	v.errs = append(v.errs, cmlerror.NewValidationError(field, value, fmt.Sprintf(format, args...), cause))
+}
+
+func (v *topologyValidator) validateNodes(nodes []NodeTopology) {
+	ids := make(map[string]int)
+	labels := make(map[string]int)
+
+	for idx := range nodes {
+		node := &nodes[idx]
+		field := fmt.Sprintf("nodes[%d]", idx)
+
+		if len(node.ID) == 0 {
+			v.add(field+".id", node.ID, cmlerror.ErrMissingRequired, "node ID is required")
+		} else if other, found := ids[node.ID]; found {
+			v.add(field+".id", node.ID, cmlerror.ErrInvalidInput, "duplicate node ID, also used by nodes[%d]", other)
+		} else {
+			ids[node.ID] = idx
+		}
+
+		if other, found := labels[node.Label]; found {
+			v.add(field+".label", node.Label, cmlerror.ErrInvalidInput, "duplicate node label, also used by nodes[%d]", other)
+		} else {
+			labels[node.Label] = idx
+		}
+
+		v.validateInterfaceIDs(field, node)
+
+		if len(node.NodeDefinition) == 0 {
+			v.add(field+".node_definition", node.NodeDefinition, cmlerror.ErrMissingRequired, "node definition is required")
+			continue
+		}
+		nodeDef, found := v.nodeDefs[UUID(node.NodeDefinition)]
+		if !found {
+			v.add(field+".node_definition", node.NodeDefinition, cmlerror.ErrElementNotFound, "unknown node definition")
+			continue
+		}
+
+		v.validateImage(field, node)
+		v.validateSlots(field, node, nodeDef)
+		v.validateResources(field, node, nodeDef)
+		v.errs = append(v.errs, validateNodeParameters(field+".parameters", node.Parameters, nodeDef)...)
+	}
+}
+
+func (v *topologyValidator) validateInterfaceIDs(field string, node *NodeTopology) {
+	ids := make(map[string]int)
+	for idx, iface := range node.Interfaces {
+		ifaceField := fmt.Sprintf("%s.interfaces[%d].id", field, idx)
+		if other, found := ids[iface.ID]; found {
+			v.add(ifaceField, iface.ID, cmlerror.ErrInvalidInput, "duplicate interface ID, also used by interfaces[%d]", other)
+			continue
+		}
+		ids[iface.ID] = idx
+	}
+}
+
+func (v *topologyValidator) validateImage(field string, node *NodeTopology) {
+	if node.ImageDefinition == nil || len(*node.ImageDefinition) == 0 {
+		return
+	}
+	imageDef, found := v.imageDefs[UUID(*node.ImageDefinition)]
+	if !found {
+		v.add(field+".image_definition", *node.ImageDefinition, cmlerror.ErrElementNotFound, "unknown image definition")
+		return
+	}
+	if imageDef.NodeDefID != node.NodeDefinition {
+		v.add(field+".image_definition", *node.ImageDefinition, cmlerror.ErrInvalidInput,
+			"image definition belongs to node definition %q, not %q", imageDef.NodeDefID, node.NodeDefinition)
+	}
+}
+
+func (v *topologyValidator) validateSlots(field string, node *NodeTopology, nodeDef NodeDefinition) {
+	physical := len(nodeDef.Device.Interfaces.Physical)
+	for idx, iface := range node.Interfaces {
+		if iface.Type != IfaceTypePhysical || iface.Slot == nil {
+			continue
+		}
+		if *iface.Slot < 0 || *iface.Slot >= physical {
+			v.add(fmt.Sprintf("%s.interfaces[%d].slot", field, idx), *iface.Slot, cmlerror.ErrInvalidInput,
+				"slot out of range, node definition %q has %d physical interfaces", node.NodeDefinition, physical)
+		}
+	}
+}
+
+func (v *topologyValidator) validateResources(field string, node *NodeTopology, nodeDef NodeDefinition) {
+	allowed := nodeDef.Inherited.Node
+	resources := []struct {
+		name     string
+		value    *int
+		settable bool
+		max      int
+	}{
+		{"ram", node.RAM, allowed.RAM, 0},
+		{"cpus", node.CPUs, allowed.CPUs, 0},
+		{"cpu_limit", node.CPUlimit, allowed.CPULimit, 100},
+		{"data_volume", node.DataVolume, allowed.DataVolume, 0},
+		{"boot_disk_size", node.BootDiskSize, allowed.BootDiskSize, 0},
+	}
+	for _, res := range resources {
+		if res.value == nil {
+			continue
+		}
+		resField := field + "." + res.name
+		switch {
+		case !res.settable:
+			v.add(resField, *res.value, cmlerror.ErrInvalidInput,
+				"%s can not be set on nodes of node definition %q", res.name, node.NodeDefinition)
+		case *res.value < 0:
+			v.add(resField, *res.value, cmlerror.ErrInvalidInput, "%s can not be negative", res.name)
+		case res.max > 0 && *res.value > res.max:
+			v.add(resField, *res.value, cmlerror.ErrInvalidInput, "%s can not exceed %d", res.name, res.max)
+		}
+	}
+}
+
+func (v *topologyValidator) validateLinks(t *LabTopology) {
+	ids := make(map[string]int)
+	used := make(map[[2]string]int)
+
+	for idx, link := range t.Links {
+		field := fmt.Sprintf("links[%d]", idx)
+
+		if other, found := ids[link.ID]; found {
+			v.add(field+".id", link.ID, cmlerror.ErrInvalidInput, "duplicate link ID, also used by links[%d]", other)
+		} else {
+			ids[link.ID] = idx
+		}
+
+		endpoints := []struct{ n, i, nField, iField string }{
+			{link.N1, link.I1, "n1", "i1"},
+			{link.N2, link.I2, "n2", "i2"},
+		}
+		for _, ep := range endpoints {
+			node := t.NodeByID(ep.n)
+			if node == nil {
+				v.add(field+"."+ep.nField, ep.n, cmlerror.ErrElementNotFound, "link references unknown node")
+				continue
+			}
+			if node.InterfaceByID(ep.i) == nil {
+				v.add(field+"."+ep.iField, ep.i, cmlerror.ErrElementNotFound, "link references unknown interface of node %q", ep.n)
+				continue
+			}
+			key := [2]string{ep.n, ep.i}
+			if other, found := used[key]; found {
+				v.add(field+"."+ep.iField, ep.i, cmlerror.ErrInvalidInput, "interface of node %q is already used by links[%d]", ep.n, other)
+				continue
+			}
+			used[key] = idx
+		}
+	}
+}
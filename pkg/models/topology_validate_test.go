@@ -0,0 +1,194 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cmlerror "github.com/rschmied/gocmlclient/pkg/errors"
+)
+
+func validationDefs() (NodeDefinitionMap, []ImageDefinition) {
+	nodeDefs := NodeDefinitionMap{
+		"iosv": NodeDefinition{
+			ID: "iosv",
+			Device: deviceData{Interfaces: interfaceData{
+				Physical: []string{"GigabitEthernet0/0", "GigabitEthernet0/1"},
+			}},
+			Inherited: NodeDefinitionInherited{
+				Node: VMProperties{RAM: true, CPUs: true, CPULimit: true},
+			},
+		},
+		"alpine": NodeDefinition{
+			ID: "alpine",
+			Device: deviceData{Interfaces: interfaceData{
+				Physical: []string{"eth0"},
+			}},
+			Inherited: NodeDefinitionInherited{
+				Node: VMProperties{RAM: true, CPUs: true, CPULimit: true, DataVolume: true, BootDiskSize: true},
+			},
+		},
+	}
+	imageDefs := []ImageDefinition{
+		{ID: "iosv-159-3", NodeDefID: "iosv"},
+		{ID: "alpine-3-21", NodeDefID: "alpine"},
+	}
+	return nodeDefs, imageDefs
+}
+
+func validTopology() *LabTopology {
+	slot0, slot1 := 0, 1
+	img := "iosv-159-3"
+	ram := 512
+	return &LabTopology{
+		Lab: TopologyLab{Title: "valid"},
+		Nodes: []NodeTopology{
+			{
+				ID: "n0", Label: "r1", NodeDefinition: "iosv", ImageDefinition: &img, RAM: &ram,
+				Interfaces: []InterfaceTopology{
+					{ID: "i0", Label: "Loopback0", Type: IfaceTypeLoopback},
+					{ID: "i1", Label: "GigabitEthernet0/0", Slot: &slot0, Type: IfaceTypePhysical},
+					{ID: "i2", Label: "GigabitEthernet0/1", Slot: &slot1, Type: IfaceTypePhysical},
+				},
+			},
+			{
+				ID: "n1", Label: "h1", NodeDefinition: "alpine",
+				Interfaces: []InterfaceTopology{
+					{ID: "i0", Label: "eth0", Slot: &slot0, Type: IfaceTypePhysical},
+				},
+			},
+		},
+		Links: []LinkTopology{
+			{ID: "l0", N1: "n0", I1: "i1", N2: "n1", I2: "i0"},
+		},
+	}
+}
+
+func TestLabTopologyValidate_Valid(t *testing.T) {
+	nodeDefs, imageDefs := validationDefs()
+	errs := validTopology().Validate(nodeDefs, imageDefs)
+	assert.Empty(t, errs)
+	assert.NoError(t, errs.Err())
+}
+
+func TestLabTopologyValidate_Problems(t *testing.T) {
+	nodeDefs, imageDefs := validationDefs()
+	slot5 := 5
+	wrongImage := "alpine-3-21"
+	unknownImage := "does-not-exist"
+	dataVolume := 10
+	cpuLimit := 150
+
+	tests := []struct {
+		name   string
+		modify func(topo *LabTopology)
+		field  string
+		cause  error
+	}{
+		{
+			name:   "unknown node definition",
+			modify: func(topo *LabTopology) { topo.Nodes[1].NodeDefinition = "nxos" },
+			field:  "nodes[1].node_definition",
+			cause:  cmlerror.ErrElementNotFound,
+		},
+		{
+			name:   "missing node definition",
+			modify: func(topo *LabTopology) { topo.Nodes[1].NodeDefinition = "" },
+			field:  "nodes[1].node_definition",
+			cause:  cmlerror.ErrMissingRequired,
+		},
+		{
+			name:   "image of other node definition",
+			modify: func(topo *LabTopology) { topo.Nodes[0].ImageDefinition = &wrongImage },
+			field:  "nodes[0].image_definition",
+			cause:  cmlerror.ErrInvalidInput,
+		},
+		{
+			name:   "unknown image definition",
+			modify: func(topo *LabTopology) { topo.Nodes[0].ImageDefinition = &unknownImage },
+			field:  "nodes[0].image_definition",
+			cause:  cmlerror.ErrElementNotFound,
+		},
+		{
+			name:   "slot out of range",
+			modify: func(topo *LabTopology) { topo.Nodes[0].Interfaces[2].Slot = &slot5 },
+			field:  "nodes[0].interfaces[2].slot",
+			cause:  cmlerror.ErrInvalidInput,
+		},
+		{
+			name:   "duplicate node ID",
+			modify: func(topo *LabTopology) { topo.Nodes[1].ID = "n0"; topo.Links = nil },
+			field:  "nodes[1].id",
+			cause:  cmlerror.ErrInvalidInput,
+		},
+		{
+			name:   "duplicate node label",
+			modify: func(topo *LabTopology) { topo.Nodes[1].Label = "r1" },
+			field:  "nodes[1].label",
+			cause:  cmlerror.ErrInvalidInput,
+		},
+		{
+			name:   "duplicate interface ID",
+			modify: func(topo *LabTopology) { topo.Nodes[0].Interfaces[2].ID = "i1" },
+			field:  "nodes[0].interfaces[2].id",
+			cause:  cmlerror.ErrInvalidInput,
+		},
+		{
+			name:   "link to missing node",
+			modify: func(topo *LabTopology) { topo.Links[0].N2 = "n7" },
+			field:  "links[0].n2",
+			cause:  cmlerror.ErrElementNotFound,
+		},
+		{
+			name:   "link to missing interface",
+			modify: func(topo *LabTopology) { topo.Links[0].I1 = "i7" },
+			field:  "links[0].i1",
+			cause:  cmlerror.ErrElementNotFound,
+		},
+		{
+			name: "interface used twice",
+			modify: func(topo *LabTopology) {
+				topo.Links = append(topo.Links, LinkTopology{ID: "l1", N1: "n0", I1: "i2", N2: "n1", I2: "i0"})
+			},
+			field: "links[1].i2",
+			cause: cmlerror.ErrInvalidInput,
+		},
+		{
+			name:   "resource not settable",
+			modify: func(topo *LabTopology) { topo.Nodes[0].DataVolume = &dataVolume },
+			field:  "nodes[0].data_volume",
+			cause:  cmlerror.ErrInvalidInput,
+		},
+		{
+			name:   "resource out of range",
+			modify: func(topo *LabTopology) { topo.Nodes[1].CPUlimit = &cpuLimit },
+			field:  "nodes[1].cpu_limit",
+			cause:  cmlerror.ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topo := validTopology()
+			tt.modify(topo)
+			errs := topo.Validate(nodeDefs, imageDefs)
+			if assert.Len(t, errs, 1, errs.Error()) {
+				assert.Equal(t, tt.field, errs[0].Field)
+				assert.True(t, errors.Is(errs[0], tt.cause))
+			}
+		})
+	}
+}
+
+func TestLabTopologyValidate_AllProblemsReported(t *testing.T) {
+	nodeDefs, imageDefs := validationDefs()
+	topo := validTopology()
+	topo.Nodes[0].NodeDefinition = "unknown"
+	topo.Nodes[1].Label = "r1"
+	topo.Links[0].N2 = "n9"
+
+	errs := topo.Validate(nodeDefs, imageDefs)
+	assert.Len(t, errs, 3)
+	assert.Error(t, errs.Err())
+}
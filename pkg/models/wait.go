@@ -0,0 +1,26 @@
+// Package models provides the models for Cisco Modeling Labs
+// here: polling related types
+package models
+
+import "time"
+
+// Defaults used for unset WaitOptions fields.
+const (
+	DefaultWaitInterval    = time.Second
+	DefaultWaitMaxInterval = 10 * time.Second
+)
+
+// WaitOptions controls how operations poll the controller while waiting for
+// a state change. Zero values use the defaults.
+type WaitOptions struct {
+	// Interval is the time between the first polls (default 1s).
+	Interval time.Duration
+	// MaxInterval caps the interval when Backoff is used (default 10s).
+	MaxInterval time.Duration
+	// Backoff is the factor the interval grows by after each poll. Values
+	// less or equal to 1 keep the interval constant.
+	Backoff float64
+	// Timeout limits the time spent waiting, in addition to the context
+	// deadline. Zero means no additional limit.
+	Timeout time.Duration
+}